@@ -0,0 +1,53 @@
+package main
+
+import (
+	"control/pki"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// 使用控制面内置的 CA 为节点签发证书
+// 输出目录中的 ca.crt、node.crt、node.key、node_id 拷贝到节点的工作目录即可
+func main() {
+	dir := flag.String("dir", "../config/pki", "CA 所在目录，不存在时自动生成")
+	nodeID := flag.String("node-id", "", "节点 ID")
+	out := flag.String("out", ".", "证书输出目录")
+	flag.Parse()
+
+	if *nodeID == "" {
+		log.Fatal("-node-id is required")
+	}
+	ca, err := pki.LoadOrCreateCA(*dir)
+	if err != nil {
+		log.Fatalf("Failed to load CA: %v", err)
+	}
+	if *nodeID == pki.ControlCommonName {
+		log.Fatalf("Node ID %q is reserved for the control plane", *nodeID)
+	}
+	certPEM, keyPEM, err := ca.IssueKeyPair(*nodeID)
+	if err != nil {
+		log.Fatalf("Failed to issue certificate: %v", err)
+	}
+
+	if err := os.MkdirAll(*out, 0700); err != nil {
+		log.Fatal(err)
+	}
+	files := []struct {
+		name string
+		data []byte
+		perm os.FileMode
+	}{
+		{"ca.crt", ca.CertPEM, 0644},
+		{"node.crt", certPEM, 0644},
+		{"node.key", keyPEM, 0600},
+		{"node_id", []byte(*nodeID + "\n"), 0644},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(*out, f.name), f.data, f.perm); err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Printf("Certificate for node %s written to %s", *nodeID, *out)
+}
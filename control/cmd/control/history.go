@@ -0,0 +1,44 @@
+package main
+
+import (
+	"control/storage"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// 查询一条链路的历史聚合
+// 用法：control history -src <节点ID> -dst <节点ID> [-tier 1h] [-since 168h] [-config conf.toml]
+func runHistory(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件路径")
+	src := fs.String("src", "", "源节点 ID")
+	dst := fs.String("dst", "", "目的节点 ID")
+	tier := fs.String("tier", storage.TierHour, "聚合粒度，1m 或 1h")
+	since := fs.Duration("since", 7*24*time.Hour, "查询最近多长时间的聚合")
+	fs.Parse(args)
+	if *src == "" || *dst == "" {
+		fmt.Fprintln(os.Stderr, "usage: control history -src <node> -dst <node> [-tier 1h] [-since 168h] [-config conf.toml]")
+		os.Exit(2)
+	}
+
+	_, c := loadConfig(*configPath)
+	store, err := storage.Open(c)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	rollups, err := store.History.LinkRollups(*tier, *src, *dst, now.Add(-*since), now)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%-19s %8s %8s %10s %10s %10s %7s\n", "BUCKET", "SAMPLES", "SENT", "MIN(ms)", "AVG(ms)", "P95(ms)", "LOSS")
+	for _, r := range rollups {
+		fmt.Printf("%-19s %8d %8d %10.3f %10.3f %10.3f %6.2f%%\n",
+			r.Bucket.Local().Format("2006-01-02 15:04:05"), r.Samples, r.Sent, r.MinDelay, r.AvgDelay, r.P95Delay, r.Loss*100)
+	}
+}
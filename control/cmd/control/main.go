@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"control/config"
+	"control/pki"
+	"control/server"
+	"control/storage"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+func main() {
+	// 子命令 token：生成节点加入令牌后退出
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		runToken(os.Args[2:])
+		return
+	}
+	// 子命令 history：查询链路历史后退出
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		runHistory(os.Args[2:])
+		return
+	}
+	// 子命令 tasks：列出节点当前的探测任务后退出
+	if len(os.Args) > 1 && os.Args[1] == "tasks" {
+		runTasks(os.Args[2:])
+		return
+	}
+	// 子命令 migrate：升级、回滚数据库或查看迁移状态后退出
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	configPath := flag.String("config", "", "配置文件路径，未指定时使用环境变量 "+config.EnvConfig+" 或依次查找 "+strings.Join(config.SearchPaths, ", "))
+	flag.Parse()
+	path, c := loadConfig(*configPath)
+
+	// 收到 SIGINT/SIGTERM 后取消 ctx，各服务依次优雅退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 配置文件变化或收到 SIGHUP 时重新加载，探测周期、路由参数等无需重启即可生效
+	watcher := config.NewWatcher(path, c)
+	updates := watcher.Subscribe()
+	historyUpdates := watcher.Subscribe()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go watcher.Run(ctx, hup)
+
+	// 所有服务共享一个数据库连接池和一个 redis 连接池，通过存储接口读写数据
+	store, err := storage.Open(c)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+	if err := store.Ping(ctx); err != nil {
+		log.Fatalf("Failed to connect to storage: %v", err)
+	}
+	// 建表和升级表结构
+	if c.AutoMigrate {
+		if _, err := store.MigrateUp(); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	}
+
+	// 加载内置 CA 并签发控制面证书，之后所有 gRPC 服务要求双向 TLS
+	if c.TLSEnabled {
+		if err := pki.Init(c.PKIDir); err != nil {
+			log.Fatalf("Failed to initialize PKI: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+
+	// 接收节点信息上报
+	go func() {
+		defer wg.Done()
+		if err := server.ReceiveMetrics(ctx, store, c); err != nil {
+			log.Printf("Metrics server stopped: %v", err)
+			stop()
+		}
+	}()
+
+	// 接收探测结果和节点注册、心跳
+	go func() {
+		defer wg.Done()
+		if err := server.ReceiveProbe(ctx, store, c); err != nil {
+			log.Printf("Probe result server stopped: %v", err)
+			stop()
+		}
+	}()
+
+	// 定时下发探测任务并计算路由
+	go func() {
+		defer wg.Done()
+		server.StartProbeScheduler(ctx, store, c, updates)
+	}()
+
+	// 汇总链路历史并按保留时长清理
+	go func() {
+		defer wg.Done()
+		server.StartLinkHistory(ctx, store, c, historyUpdates)
+	}()
+
+	log.Printf("Control plane started, metrics port %s, probe port %s", c.ReceivePort, c.DetectPort)
+	<-ctx.Done()
+	log.Println("Shutting down control plane...")
+	wg.Wait()
+	log.Println("Control plane stopped")
+}
+
+// 启动时加载一次配置，返回配置文件路径和配置，配置文件缺失或不合法时退出
+func loadConfig(path string) (string, config.ConfigInfo) {
+	path, err := config.Resolve(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, err := config.Load(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Loaded config from %s", path)
+	return path, c
+}
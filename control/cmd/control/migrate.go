@@ -0,0 +1,59 @@
+package main
+
+import (
+	"control/storage"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// 管理数据库迁移
+// 用法：control migrate up|down|status [-config conf.toml] [-steps 1]
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: control migrate up|down|status [-config conf.toml] [-steps 1]")
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("migrate "+action, flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件路径")
+	steps := fs.Int("steps", 1, "down 回滚的迁移数")
+	fs.Parse(args[1:])
+
+	_, c := loadConfig(*configPath)
+	store, err := storage.Open(c)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+
+	switch action {
+	case "up":
+		n, err := store.MigrateUp()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d migrations applied\n", n)
+	case "down":
+		n, err := store.MigrateDown(*steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d migrations rolled back\n", n)
+	case "status":
+		states, err := store.MigrationStatus()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, state := range states {
+			applied := "pending"
+			if state.Applied {
+				applied = "applied at " + state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-24s %s\n", state.Version, state.Name, applied)
+		}
+	default:
+		log.Fatalf("unknown migrate action %q, expected up, down or status", action)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"control/pki"
+	pb "control/proto"
+	"control/storage"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// 列出节点当前的探测任务和探测轮次的统计，用于排查探测任务是否下发到位、能否在一个探测间隔内完成
+// 用法：control tasks -node <节点ID> [-config conf.toml]
+func runTasks(args []string) {
+	fs := flag.NewFlagSet("tasks", flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件路径")
+	nodeID := fs.String("node", "", "节点 ID")
+	fs.Parse(args)
+	if *nodeID == "" {
+		fmt.Fprintln(os.Stderr, "usage: control tasks -node <node> [-config conf.toml]")
+		os.Exit(2)
+	}
+
+	_, c := loadConfig(*configPath)
+	store, err := storage.Open(c)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+	node, err := store.Nodes.Node(*nodeID)
+	if err != nil {
+		log.Fatalf("Failed to find node %s: %v", *nodeID, err)
+	}
+	// 以控制面的身份连接节点的探测任务端口
+	if c.TLSEnabled {
+		if err := pki.Init(c.PKIDir); err != nil {
+			log.Fatalf("Failed to initialize PKI: %v", err)
+		}
+	}
+	conn, err := grpc.Dial(net.JoinHostPort(node.Address, strconv.Itoa(node.ProbePort)), pki.DialOption(node.ID))
+	if err != nil {
+		log.Fatalf("Failed to connect to node %s: %v", node.ID, err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := pb.NewProbeTaskServiceClient(conn).ListProbeTasks(ctx, &pb.ListProbeTasksRequest{})
+	if err != nil {
+		log.Fatalf("Failed to list probe tasks of node %s: %v", node.ID, err)
+	}
+
+	fmt.Printf("Node %s, generation %d, %d tasks\n", node.ID, resp.Generation, len(resp.Tasks))
+	// 旧版本数据面不返回轮次统计
+	if r := resp.Rounds; r != nil {
+		fmt.Printf("Rounds %d, overran %d (%d tasks skipped, %d cut short), last %v, max %v\n",
+			r.Rounds, r.Overruns, r.Skipped, r.Cut, time.Duration(r.LastDurationMs)*time.Millisecond, time.Duration(r.MaxDurationMs)*time.Millisecond)
+	}
+	fmt.Printf("%-20s %-16s %-6s %6s %6s %-19s %-19s\n", "TARGET", "ADDRESS", "TYPE", "PORT", "PHASE", "LAST RUN", "EXPIRES")
+	for _, state := range resp.Tasks {
+		task := state.Task
+		fmt.Printf("%-20s %-16s %-6s %6d %6.2f %-19s %-19s\n",
+			task.NodeId2, task.Ip2, probeTypeName(task.Type), task.Port, task.Phase, formatMillis(state.LastRunAt), formatMillis(state.ExpiresAt))
+	}
+}
+
+// 探测类型的简短名称，如 PROBE_TYPE_TCP 显示为 tcp
+func probeTypeName(t pb.ProbeType) string {
+	name := t.String()
+	if len(name) > len("PROBE_TYPE_") {
+		name = name[len("PROBE_TYPE_"):]
+	}
+	return strings.ToLower(name)
+}
+
+// Unix 毫秒时间的本地时间表示，0 显示为 "-"
+func formatMillis(ms int64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"control/pki"
+	"control/server"
+	"control/storage"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+)
+
+// 生成一次性的节点加入令牌并打印节点上执行的加入命令
+// 用法：control token [-config conf.toml] [-ttl 1h] [-control host:port] [-node <node-id>]
+// 指定 -node 时令牌绑定该节点 ID，供已加入的节点重新申请证书
+func runToken(args []string) {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件路径")
+	ttl := fs.Duration("ttl", 0, "令牌有效期，默认使用配置中的 JoinTokenTTL")
+	control := fs.String("control", "", "节点加入时连接的控制面地址，默认使用配置中的 AdvertiseHost 和 DetectPort")
+	nodeID := fs.String("node", "", "已加入节点的 ID，生成只能供该节点重新加入的令牌，例如证书过期或重新安装后")
+	fs.Parse(args)
+
+	_, c := loadConfig(*configPath)
+	if *ttl == 0 {
+		*ttl = c.JoinTokenTTL
+	}
+	if *control == "" {
+		host := c.AdvertiseHost
+		if host == "" {
+			host = "<control-host>"
+		}
+		*control = net.JoinHostPort(host, c.DetectPort)
+	}
+
+	if !c.TLSEnabled {
+		log.Fatal("TLS is disabled, nodes can connect without joining")
+	}
+	// 与控制面启动时加载同一个 CA，加入命令附带其指纹，节点据此确认控制面身份
+	ca, err := pki.LoadOrCreateCA(c.PKIDir)
+	if err != nil {
+		log.Fatalf("Failed to load CA: %v", err)
+	}
+	store, err := storage.Open(c)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+	token, expiresAt, err := server.NewJoinToken(store.Tokens, *ttl, *nodeID)
+	if err != nil {
+		log.Fatalf("Failed to create join token: %v", err)
+	}
+	fmt.Printf("Join token expires at %s, run on the node:\n\n", expiresAt.Format("2006-01-02 15:04:05"))
+	force := ""
+	if *nodeID != "" {
+		force = " --force"
+	}
+	fmt.Printf("  dataplane join --token %s --control %s --ca-hash %s%s\n", token, *control, ca.Fingerprint(), force)
+}
@@ -2,24 +2,117 @@ package config
 
 import "time"
 
-//配置文件结构体
+// 配置文件结构体
 type ConfigInfo struct {
-	PoolNum        int           //协程池数量
-	ReceivePort    string        //接收节点信息端口号
-	DetectPort     string		 //接收探测信息端口号
-	DetectCycle    time.Duration //下发一次探测任务时长 单位ns *time.Second 变成秒
-	ExpireDuration time.Duration //redis列表过期
-	CalculateCycle time.Duration // redis计算周期
-	K              int           //路径数量
-	Theta          float64       //惩罚系数
-	Skip           int           //跳数限制
+	PoolNum           int           //协程池数量
+	ReceivePort       string        //接收节点信息端口号
+	DetectPort        string        //接收探测信息端口号
+	DetectCycle       time.Duration //下发一次探测任务时长
+	ExpireDuration    time.Duration //探测样本过期时间
+	CalculateCycle    time.Duration // redis计算周期
+	K                 int           //路径数量
+	Theta             float64       //惩罚系数
+	Skip              int           //跳数限制
+	HeartbeatInterval time.Duration //节点心跳间隔
+	NodeTimeout       time.Duration //超过该时长未收到心跳的节点视为下线
+	ProbeType         string        //探测类型 tcp/udp/http/https/tls/icmp/tcp_echo
+	ProbeTargetPort   int           //探测目标端口，0 表示使用探测类型的默认端口
+	ProbeTimeout      time.Duration //单次探测超时
+	ProbePath         string        //http/https 探测的请求路径
+	ProbeSamples      int           //每次探测发送的样本数
+	SampleInterval    time.Duration //相邻样本的间隔
+	MaxLinkLoss       float64       //丢包率达到该值的链路不参与路由计算
+	TLSEnabled        bool          //是否对所有 gRPC 服务启用双向 TLS
+	PKIDir            string        //内置 CA 的证书和私钥目录，不存在时自动生成
+	JoinTokenTTL      time.Duration //节点加入令牌的有效期
+	AdvertiseHost     string        //节点加入后连接控制面使用的主机名，为空时沿用加入时连接的主机
+	Driver            string        //数据库类型 mysql/sqlite
+	DSN               string        //mysql 连接串或 sqlite 数据库文件，可由环境变量 SIRIUS_DSN 覆盖
+	RedisAddr         string        //redis 地址，可由环境变量 SIRIUS_REDIS_ADDR 覆盖
+	RedisPassword     string        //redis 密码，可由环境变量 SIRIUS_REDIS_PASSWORD 覆盖
+	RedisDB           int           //redis 数据库编号，可由环境变量 SIRIUS_REDIS_DB 覆盖
+	DBMaxOpenConns    int           //数据库连接池最大连接数
+	DBMaxIdleConns    int           //数据库连接池最大空闲连接数
+	DBConnMaxLifetime time.Duration //数据库连接最长复用时长
+	RedisMaxIdle      int           //redis 连接池最大空闲连接数
+	RedisMaxActive    int           //redis 连接池最大连接数，连接用尽时等待空闲连接
+	RedisIdleTimeout  time.Duration //redis 空闲连接超过该时长后关闭
+	AutoMigrate       bool          //启动时自动应用数据库迁移
+	SampleStore       string        //探测样本存储 redis/memory，memory 时不需要 redis
+	SampleCapacity    int           //每条链路最多保留的探测样本数
+	StatsWindow       time.Duration //计算链路统计时只使用该时长内的样本，0 表示不限
+	StatsSamples      int           //计算链路统计时最多使用的最新样本数
+	StatsMinSamples   int           //计算链路统计所需的最少样本数，不足时保留上一次的统计
+	StatsMethod       string        //链路延迟的统计方法 mean/median/percentile/ewma
+	StatsPercentile   float64       //percentile 方法使用的百分位
+	StatsEWMAAlpha    float64       //ewma 方法中每个新样本的权重
+	RawRetention      time.Duration //link_info 中原始链路统计的保留时长，0 表示不清理
+	MinuteRetention   time.Duration //1 分钟聚合的保留时长，0 表示不清理
+	HourRetention     time.Duration //1 小时聚合的保留时长，0 表示不清理
+	ProbeStrategy     string        //探测对端的选择策略 full/nearest/landmarks
+	ProbeNearest      int           //nearest 策略中每个节点探测的延迟最低的对端数
+	ProbeRandom       int           //nearest 和 landmarks 策略中每个周期额外随机探测的对端数
+	ProbeLandmarks    []string      //landmarks 策略中的地标节点 ID，地标节点探测全部节点，其余节点只探测地标节点
+	ProbeSpread       bool          //把每个节点的探测均匀分散到探测周期内，避免同时发出
 }
 
-//探测结构体
+// 探测结构体
 type ProbeResult struct {
-	SourceIP      string  `json:"ip1"`
-	DestinationIP string  `json:"ip2"`
-	Delay         int64 `json:"tcp_delay"`
-	Timestamp     string  `json:"timestamp"`
+	SourceIP      string    `json:"ip1"`
+	DestinationIP string    `json:"ip2"`
+	SourceID      string    `json:"node_id1"`
+	DestinationID string    `json:"node_id2"`
+	Type          string    `json:"type"`
+	Delay         int64     `json:"tcp_delay"`              //平均延迟，单位ms，兼容旧数据
+	MinDelay      int64     `json:"min_us"`                 //最小延迟，单位us
+	AvgDelay      int64     `json:"avg_us"`                 //平均延迟，单位us
+	MaxDelay      int64     `json:"max_us"`                 //最大延迟，单位us
+	StdDev        int64     `json:"stddev_us"`              //延迟标准差，单位us
+	Jitter        int64     `json:"jitter_us"`              //抖动，单位us
+	Loss          float64   `json:"loss"`                   //丢包率
+	Sent          int       `json:"sent"`                   //发送的样本数
+	Received      int       `json:"received"`               //成功的样本数
+	Failed        bool      `json:"failed"`                 //全部样本失败，链路不通
+	ErrorClass    string    `json:"error_class,omitempty"`  //失败原因 timeout/refused/unreachable/reset/other
+	Error         string    `json:"error,omitempty"`        //失败的错误信息
+	OneWay        bool      `json:"one_way,omitempty"`      //是否测得单向丢包和乱序，对端运行回显服务的 udp 探测才有
+	ForwardLoss   float64   `json:"forward_loss,omitempty"` //去程丢包率
+	ReverseLoss   float64   `json:"reverse_loss,omitempty"` //回程丢包率
+	Reordered     int       `json:"reordered,omitempty"`    //去程和回程乱序的报文数
+	Timestamp     string    `json:"timestamp"`
+	ReceivedAt    time.Time `json:"received_at"` //控制面收到探测结果的时间
 }
 
+// 链路信息结构体，对应 link_info 表中的一行，SourceIP、DestinationIP 存放节点 ID
+type LinkInfo struct {
+	SourceIP      string
+	DestinationIP string
+	Delay         float64 //平均延迟，单位ms，只统计成功的探测
+	Loss          float64 //丢包率，失败的探测按全部丢包计入
+	Timestamp     string
+}
+
+// 链路历史的聚合结果，对应 link_rollup_1m、link_rollup_1h 表中的一行，SourceIP、DestinationIP 存放节点 ID
+type LinkRollup struct {
+	SourceIP      string
+	DestinationIP string
+	Bucket        time.Time //聚合区间的开始时间
+	Samples       int       //区间内成功的探测数
+	Sent          int       //区间内发送的样本数
+	Received      int       //区间内成功的样本数
+	MinDelay      float64   //最小延迟，单位ms
+	AvgDelay      float64   //平均延迟，单位ms
+	P95Delay      float64   //95 分位延迟，单位ms
+	Loss          float64   //丢包率
+}
+
+// 节点信息结构体，对应 node_info 表中的一行
+type NodeInfo struct {
+	ID        string            //节点 ID
+	Address   string            //节点对外通告的主地址
+	Addresses []string          //节点全部通告地址
+	ProbePort int               //接收探测任务的端口
+	Labels    map[string]string //节点标签
+	LastSeen  time.Time         //最近一次心跳时间
+	State     string            //节点状态 alive/dead
+}
@@ -1,6 +0,0 @@
-package config
-
-//mysql信息配置
-const (
-	Mysqldb = "root:000000@tcp(127.0.0.1:3306)/db_info?charset=utf8&parseTime=True&loc=Local"
-)
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// 指定配置文件路径的环境变量
+const EnvConfig = "SIRIUS_CONFIG"
+
+// 未通过 -config 或 SIRIUS_CONFIG 指定配置文件时依次查找的路径
+var SearchPaths = []string{"conf.toml", "config/conf.toml", "../config/conf.toml", "/etc/sirius/conf.toml"}
+
+// 可由环境变量覆盖的配置项，用于不便写入配置文件的密钥和地址
+var envOverrides = map[string]func(c *ConfigInfo, v string) error{
+	"SIRIUS_DRIVER":         func(c *ConfigInfo, v string) error { c.Driver = v; return nil },
+	"SIRIUS_DSN":            func(c *ConfigInfo, v string) error { c.DSN = v; return nil },
+	"SIRIUS_REDIS_ADDR":     func(c *ConfigInfo, v string) error { c.RedisAddr = v; return nil },
+	"SIRIUS_REDIS_PASSWORD": func(c *ConfigInfo, v string) error { c.RedisPassword = v; return nil },
+	"SIRIUS_REDIS_DB": func(c *ConfigInfo, v string) error {
+		db, err := strconv.Atoi(v)
+		c.RedisDB = db
+		return err
+	},
+}
+
+// 默认配置，配置文件中没有的项使用默认值
+// DSN 没有默认值，须由配置文件或环境变量 SIRIUS_DSN 指定
+func Default() ConfigInfo {
+	return ConfigInfo{
+		PoolNum:           10,
+		ReceivePort:       "8080",
+		DetectPort:        "8081",
+		DetectCycle:       30 * time.Second,
+		ExpireDuration:    24 * time.Hour,
+		CalculateCycle:    60 * time.Second,
+		K:                 6,
+		Theta:             0.1,
+		Skip:              3,
+		HeartbeatInterval: 10 * time.Second,
+		NodeTimeout:       30 * time.Second,
+		ProbeType:         "tcp",
+		ProbeTimeout:      5 * time.Second,
+		ProbePath:         "/",
+		ProbeSamples:      5,
+		SampleInterval:    100 * time.Millisecond,
+		MaxLinkLoss:       0.5,
+		TLSEnabled:        true,
+		PKIDir:            "pki",
+		JoinTokenTTL:      time.Hour,
+		Driver:            "mysql",
+		RedisAddr:         "localhost:6379",
+		DBMaxOpenConns:    20,
+		DBMaxIdleConns:    10,
+		DBConnMaxLifetime: 30 * time.Minute,
+		RedisMaxIdle:      10,
+		RedisMaxActive:    50,
+		RedisIdleTimeout:  240 * time.Second,
+		AutoMigrate:       true,
+		SampleStore:       "redis",
+		SampleCapacity:    100,
+		StatsSamples:      10,
+		StatsMinSamples:   1,
+		StatsMethod:       "mean",
+		StatsPercentile:   95,
+		StatsEWMAAlpha:    0.3,
+		RawRetention:      48 * time.Hour,
+		MinuteRetention:   7 * 24 * time.Hour,
+		HourRetention:     90 * 24 * time.Hour,
+		ProbeStrategy:     "full",
+		ProbeNearest:      8,
+		ProbeRandom:       4,
+		ProbeSpread:       true,
+	}
+}
+
+// 确定配置文件路径：优先使用 path，其次是环境变量 SIRIUS_CONFIG，最后依次查找 SearchPaths
+func Resolve(path string) (string, error) {
+	if path == "" {
+		path = os.Getenv(EnvConfig)
+	}
+	if path != "" {
+		return path, nil
+	}
+	for _, candidate := range SearchPaths {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("config file not found in %v, use -config or %s to specify one", SearchPaths, EnvConfig)
+}
+
+// 读取配置文件，叠加环境变量后校验
+// PKIDir 为相对路径时相对于配置文件所在目录，不随工作目录变化
+func Load(path string) (ConfigInfo, error) {
+	c := Default()
+	md, err := toml.DecodeFile(path, &c)
+	if err != nil {
+		return ConfigInfo{}, fmt.Errorf("failed to load config %s: %v", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return ConfigInfo{}, fmt.Errorf("unknown keys in config %s: %v", path, undecoded)
+	}
+	for name, set := range envOverrides {
+		if v, ok := os.LookupEnv(name); ok {
+			if err := set(&c, v); err != nil {
+				return ConfigInfo{}, fmt.Errorf("invalid %s: %v", name, err)
+			}
+		}
+	}
+	if c.PKIDir != "" && !filepath.IsAbs(c.PKIDir) {
+		c.PKIDir = filepath.Join(filepath.Dir(path), c.PKIDir)
+	}
+	if err := c.Validate(); err != nil {
+		return ConfigInfo{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// 校验配置，返回全部不合法的配置项
+func (c ConfigInfo) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	// 时长以 "30s"、"100ms" 这样的字符串表示，旧版配置中的整数会被解析为纳秒
+	durations := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"DetectCycle", c.DetectCycle},
+		{"ExpireDuration", c.ExpireDuration},
+		{"CalculateCycle", c.CalculateCycle},
+		{"HeartbeatInterval", c.HeartbeatInterval},
+		{"NodeTimeout", c.NodeTimeout},
+		{"ProbeTimeout", c.ProbeTimeout},
+		{"JoinTokenTTL", c.JoinTokenTTL},
+		{"DBConnMaxLifetime", c.DBConnMaxLifetime},
+		{"RedisIdleTimeout", c.RedisIdleTimeout},
+	}
+	for _, d := range durations {
+		check(d.d >= time.Millisecond, "%s: %v is too short, use a duration such as \"30s\"", d.name, d.d)
+	}
+	check(c.SampleInterval >= 0, "SampleInterval: must not be negative, got %v", c.SampleInterval)
+	check(c.NodeTimeout > c.HeartbeatInterval, "NodeTimeout: %v must be longer than HeartbeatInterval %v", c.NodeTimeout, c.HeartbeatInterval)
+	check(c.PoolNum > 0, "PoolNum: must be positive, got %d", c.PoolNum)
+	check(validPort(c.ReceivePort), "ReceivePort: %q is not a valid port", c.ReceivePort)
+	check(validPort(c.DetectPort), "DetectPort: %q is not a valid port", c.DetectPort)
+	check(c.ReceivePort != c.DetectPort, "DetectPort: must differ from ReceivePort %s", c.ReceivePort)
+	check(c.K > 0, "K: must be positive, got %d", c.K)
+	check(c.Theta >= 0, "Theta: must not be negative, got %v", c.Theta)
+	check(c.Skip > 0, "Skip: must be positive, got %d", c.Skip)
+	switch c.ProbeType {
+	case "", "tcp", "udp", "http", "https", "tls", "icmp", "tcp_echo":
+	default:
+		check(false, "ProbeType: %q is not supported, use tcp, udp, http, https, tls, icmp or tcp_echo", c.ProbeType)
+	}
+	check(c.ProbeTargetPort >= 0 && c.ProbeTargetPort < 65536, "ProbeTargetPort: %d is not a valid port", c.ProbeTargetPort)
+	check(c.ProbeSamples > 0, "ProbeSamples: must be positive, got %d", c.ProbeSamples)
+	check(c.MaxLinkLoss > 0 && c.MaxLinkLoss <= 1, "MaxLinkLoss: must be in (0, 1], got %v", c.MaxLinkLoss)
+	check(!c.TLSEnabled || c.PKIDir != "", "PKIDir: required when TLS is enabled")
+	check(c.Driver == "mysql" || c.Driver == "sqlite", "Driver: %q is not supported, use mysql or sqlite", c.Driver)
+	check(c.DSN != "", "DSN: must not be empty")
+	_, _, err := net.SplitHostPort(c.RedisAddr)
+	check(err == nil, "RedisAddr: %q is not a valid host:port address", c.RedisAddr)
+	check(c.RedisDB >= 0, "RedisDB: must not be negative, got %d", c.RedisDB)
+	check(c.SampleStore == "redis" || c.SampleStore == "memory", "SampleStore: %q is not supported, use redis or memory", c.SampleStore)
+	check(c.SampleCapacity > 0, "SampleCapacity: must be positive, got %d", c.SampleCapacity)
+	switch c.ProbeStrategy {
+	case "full":
+	case "nearest":
+		check(c.ProbeNearest+c.ProbeRandom > 0, "ProbeNearest: ProbeNearest and ProbeRandom must not both be 0 with the nearest strategy")
+	case "landmarks":
+		check(len(c.ProbeLandmarks) > 0, "ProbeLandmarks: required with the landmarks strategy")
+	default:
+		check(false, "ProbeStrategy: %q is not supported, use full, nearest or landmarks", c.ProbeStrategy)
+	}
+	check(c.ProbeNearest >= 0, "ProbeNearest: must not be negative, got %d", c.ProbeNearest)
+	check(c.ProbeRandom >= 0, "ProbeRandom: must not be negative, got %d", c.ProbeRandom)
+	check(c.RawRetention >= 0, "RawRetention: must not be negative, got %v", c.RawRetention)
+	check(c.MinuteRetention == 0 || c.MinuteRetention >= time.Hour, "MinuteRetention: must be 0 or at least 1h, got %v", c.MinuteRetention)
+	check(c.HourRetention >= 0, "HourRetention: must not be negative, got %v", c.HourRetention)
+	check(c.StatsWindow >= 0, "StatsWindow: must not be negative, got %v", c.StatsWindow)
+	check(c.StatsSamples > 0 && c.StatsSamples <= c.SampleCapacity, "StatsSamples: must be in [1, SampleCapacity], got %d", c.StatsSamples)
+	check(c.StatsMinSamples > 0 && c.StatsMinSamples <= c.StatsSamples, "StatsMinSamples: must be in [1, StatsSamples], got %d", c.StatsMinSamples)
+	switch c.StatsMethod {
+	case "mean", "median", "ewma":
+	case "percentile":
+		check(c.StatsPercentile > 0 && c.StatsPercentile <= 100, "StatsPercentile: must be in (0, 100], got %v", c.StatsPercentile)
+	default:
+		check(false, "StatsMethod: %q is not supported, use mean, median, percentile or ewma", c.StatsMethod)
+	}
+	check(c.StatsMethod != "ewma" || (c.StatsEWMAAlpha > 0 && c.StatsEWMAAlpha <= 1), "StatsEWMAAlpha: must be in (0, 1], got %v", c.StatsEWMAAlpha)
+	check(c.DBMaxOpenConns > 0, "DBMaxOpenConns: must be positive, got %d", c.DBMaxOpenConns)
+	check(c.DBMaxIdleConns >= 0 && c.DBMaxIdleConns <= c.DBMaxOpenConns, "DBMaxIdleConns: must be in [0, DBMaxOpenConns], got %d", c.DBMaxIdleConns)
+	check(c.RedisMaxActive > 0, "RedisMaxActive: must be positive, got %d", c.RedisMaxActive)
+	check(c.RedisMaxIdle >= 0 && c.RedisMaxIdle <= c.RedisMaxActive, "RedisMaxIdle: must be in [0, RedisMaxActive], got %d", c.RedisMaxIdle)
+	return errors.Join(errs...)
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n < 65536
+}
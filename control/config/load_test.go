@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试加载仓库自带的配置文件
+func TestLoadRepoConfig(t *testing.T) {
+	c, err := Load("conf.toml")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.DetectCycle != 30*time.Second || c.SampleInterval != 100*time.Millisecond || c.ExpireDuration != 24*time.Hour {
+		t.Fatalf("unexpected durations: %v, %v, %v", c.DetectCycle, c.SampleInterval, c.ExpireDuration)
+	}
+	if c.Skip != 3 || c.RedisAddr != "localhost:6379" {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+}
+
+// 测试环境变量覆盖密钥和地址
+func TestLoadEnvOverrides(t *testing.T) {
+	path := writeConfig(t, `K = 4`)
+	t.Setenv("SIRIUS_DSN", "user:secret@tcp(db:3306)/sirius")
+	t.Setenv("SIRIUS_REDIS_PASSWORD", "secret")
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.K != 4 || c.DSN != "user:secret@tcp(db:3306)/sirius" || c.RedisPassword != "secret" {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+	if c.DetectCycle != Default().DetectCycle {
+		t.Fatalf("expected default DetectCycle, got %v", c.DetectCycle)
+	}
+	// 相对路径的 PKIDir 相对于配置文件所在目录
+	if want := filepath.Join(filepath.Dir(path), "pki"); c.PKIDir != want {
+		t.Fatalf("expected PKIDir %s, got %s", want, c.PKIDir)
+	}
+	t.Setenv("SIRIUS_REDIS_DB", "x")
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected invalid SIRIUS_REDIS_DB to be rejected")
+	}
+}
+
+// 测试不合法的配置被拒绝，错误信息指明配置项
+func TestLoadInvalid(t *testing.T) {
+	cases := map[string]string{
+		"K = 0":                         "K:",
+		"Theta = -0.1":                  "Theta:",
+		"DetectCycle = 30":              "DetectCycle:",
+		"NodeTimeout = \"5s\"":          "NodeTimeout:",
+		"DetectPort = \"8080\"":         "DetectPort:",
+		"MaxLinkLoss = 0":               "MaxLinkLoss:",
+		"Driver = \"postgres\"":         "Driver:",
+		"StatsMethod = \"max\"":         "StatsMethod:",
+		"ProbeStrategy = \"ring\"":      "ProbeStrategy:",
+		"ProbeType = \"ftp\"":           "ProbeType:",
+		"ProbeStrategy = \"landmarks\"": "ProbeLandmarks:",
+		"StatsMinSamples = 20":          "StatsMinSamples:",
+		"Unknown = 1":                   "unknown keys",
+		"K = 4":                         "DSN:",
+	}
+	for content, want := range cases {
+		_, err := Load(writeConfig(t, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error mentioning %q, got %v", content, want, err)
+		}
+	}
+}
+
+// 测试配置文件路径的确定顺序
+func TestResolve(t *testing.T) {
+	if path, err := Resolve("explicit.toml"); err != nil || path != "explicit.toml" {
+		t.Fatalf("expected explicit path, got %q, %v", path, err)
+	}
+	t.Setenv(EnvConfig, "env.toml")
+	if path, _ := Resolve(""); path != "env.toml" {
+		t.Fatalf("expected path from environment, got %q", path)
+	}
+	t.Setenv(EnvConfig, "")
+	if path, err := Resolve(""); err != nil || path != "conf.toml" {
+		t.Fatalf("expected conf.toml from search paths, got %q, %v", path, err)
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "conf.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// 检查配置文件是否变化的间隔
+var WatchInterval = 2 * time.Second
+
+// 无需重启即可生效的配置项，其余配置项变化后只记录日志，重启后生效
+var Reloadable = map[string]bool{
+	"DetectCycle":     true,
+	"CalculateCycle":  true,
+	"NodeTimeout":     true,
+	"K":               true,
+	"Theta":           true,
+	"Skip":            true,
+	"ProbeType":       true,
+	"ProbeTargetPort": true,
+	"ProbeTimeout":    true,
+	"ProbePath":       true,
+	"ProbeSamples":    true,
+	"SampleInterval":  true,
+	"StatsWindow":     true,
+	"StatsSamples":    true,
+	"StatsMinSamples": true,
+	"StatsMethod":     true,
+	"StatsPercentile": true,
+	"StatsEWMAAlpha":  true,
+	"RawRetention":    true,
+	"MinuteRetention": true,
+	"HourRetention":   true,
+	"ProbeStrategy":   true,
+	"ProbeNearest":    true,
+	"ProbeRandom":     true,
+	"ProbeLandmarks":  true,
+	"ProbeSpread":     true,
+}
+
+// 日志中不打印取值的配置项
+var secrets = map[string]bool{"DSN": true, "RedisPassword": true}
+
+// 监视配置文件，文件内容变化或收到 SIGHUP 时重新加载，校验通过后通知订阅者
+type Watcher struct {
+	path        string
+	mu          sync.Mutex
+	current     ConfigInfo
+	sum         [sha256.Size]byte
+	subscribers []chan ConfigInfo
+}
+
+// 创建 Watcher，c 为启动时从 path 加载的配置
+func NewWatcher(path string, c ConfigInfo) *Watcher {
+	w := &Watcher{path: path, current: c}
+	if data, err := os.ReadFile(path); err == nil {
+		w.sum = sha256.Sum256(data)
+	}
+	return w
+}
+
+// 当前生效的配置，需要重启才能生效的配置项为启动时的取值
+func (w *Watcher) Current() ConfigInfo {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// 订阅配置变化，通道中只保留最新的配置，订阅者处理不及时时旧的配置被丢弃
+func (w *Watcher) Subscribe() <-chan ConfigInfo {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch := make(chan ConfigInfo, 1)
+	w.subscribers = append(w.subscribers, ch)
+	return ch
+}
+
+// 重新加载配置文件，文件为空或不合法时保留当前配置并返回错误
+// 无论是否成功都记录文件内容的摘要，同一份不合法的文件不会被反复加载
+func (w *Watcher) Reload() error {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.sum = sha256.Sum256(data)
+	w.mu.Unlock()
+	// 保存到一半被截断的文件会让所有配置项回到默认值
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fmt.Errorf("config %s is empty", w.path)
+	}
+	c, err := Load(w.path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changes := Diff(w.current, c)
+	if len(changes) == 0 {
+		log.Printf("Config %s reloaded, nothing changed", w.path)
+		return nil
+	}
+	for _, change := range changes {
+		log.Printf("Config changed: %s", change)
+	}
+	// 需要重启的配置项保持进程正在使用的取值，之后每次重新加载都会再次提示
+	next := reloaded(w.current, c)
+	if reflect.DeepEqual(next, w.current) {
+		log.Printf("Config %s reloaded, no change takes effect before restart", w.path)
+		return nil
+	}
+	if err := next.Validate(); err != nil {
+		return fmt.Errorf("config %s conflicts with settings that take effect after restart: %v", w.path, err)
+	}
+	w.current = next
+	for _, ch := range w.subscribers {
+		// 丢弃订阅者尚未取走的旧配置
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- next
+	}
+	return nil
+}
+
+// 在 current 的基础上应用 c 中无需重启即可生效的配置项，其余配置项保持 current 的取值
+func reloaded(current, c ConfigInfo) ConfigInfo {
+	cv, nv := reflect.ValueOf(&current).Elem(), reflect.ValueOf(c)
+	for i := 0; i < cv.NumField(); i++ {
+		if Reloadable[cv.Type().Field(i).Name] {
+			cv.Field(i).Set(nv.Field(i))
+		}
+	}
+	return current
+}
+
+// 每隔 WatchInterval 检查配置文件内容，变化时重新加载；收到 hup 时立即重新加载，直到 ctx 取消
+func (w *Watcher) Run(ctx context.Context, hup <-chan os.Signal) {
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Printf("Received SIGHUP, reloading config %s", w.path)
+		case <-ticker.C:
+			if !w.changed() {
+				continue
+			}
+			log.Printf("Config %s modified, reloading", w.path)
+		}
+		if err := w.Reload(); err != nil {
+			log.Printf("Failed to reload config, keeping the current one: %v", err)
+		}
+	}
+}
+
+// 配置文件内容是否与上次加载时不同
+func (w *Watcher) changed() bool {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return false
+	}
+	sum := sha256.Sum256(data)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !bytes.Equal(sum[:], w.sum[:])
+}
+
+// 列出两份配置中取值不同的配置项，格式为 "K: 6 -> 4"，需要重启才能生效的配置项附加说明
+func Diff(old, new ConfigInfo) []string {
+	var changes []string
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+	for i := 0; i < ov.NumField(); i++ {
+		name := ov.Type().Field(i).Name
+		a, b := ov.Field(i).Interface(), nv.Field(i).Interface()
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		change := fmt.Sprintf("%s: %v -> %v", name, a, b)
+		if secrets[name] {
+			change = name + ": changed"
+		}
+		if !Reloadable[name] {
+			change += " (takes effect after restart)"
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试配置差异：只列出变化的项，密钥不打印取值，不可热加载的项提示需要重启
+func TestDiff(t *testing.T) {
+	old := Default()
+	new := old
+	new.K = 4
+	new.DetectCycle = 10 * time.Second
+	new.DSN = "user:secret@tcp(db:3306)/sirius"
+	new.ReceivePort = "9080"
+
+	changes := strings.Join(Diff(old, new), "\n")
+	for _, want := range []string{"K: 6 -> 4", "DetectCycle: 30s -> 10s", "DSN: changed (takes effect after restart)", "ReceivePort: 8080 -> 9080 (takes effect after restart)"} {
+		if !strings.Contains(changes, want) {
+			t.Errorf("expected %q in diff:\n%s", want, changes)
+		}
+	}
+	if strings.Contains(changes, "secret") {
+		t.Errorf("diff leaks secret:\n%s", changes)
+	}
+	if len(Diff(old, old)) != 0 {
+		t.Error("expected no changes between identical configs")
+	}
+}
+
+// 测试文件变化和 SIGHUP 触发重新加载，不合法的配置和空文件被忽略
+func TestWatcher(t *testing.T) {
+	t.Setenv("SIRIUS_DSN", "sirius.db")
+	interval := WatchInterval
+	t.Cleanup(func() { WatchInterval = interval })
+	WatchInterval = 10 * time.Millisecond
+	path := writeConfig(t, "K = 6")
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	w := NewWatcher(path, c)
+	updates := w.Subscribe()
+	hup := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx, hup)
+
+	// 文件内容变化后自动重新加载
+	os.WriteFile(path, []byte(`K = 4
+DetectCycle = "10s"`), 0644)
+	select {
+	case c := <-updates:
+		if c.K != 4 || c.DetectCycle != 10*time.Second {
+			t.Fatalf("unexpected reloaded config: K=%d DetectCycle=%v", c.K, c.DetectCycle)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("config change not detected")
+	}
+
+	// 需要重启的配置项不进入当前配置，之后对它的修改仍会与进程正在使用的取值比较
+	os.WriteFile(path, []byte(`K = 5
+DetectCycle = "10s"
+PoolNum = 7`), 0644)
+	select {
+	case c := <-updates:
+		if c.K != 5 || c.PoolNum != Default().PoolNum {
+			t.Fatalf("unexpected reloaded config: K=%d PoolNum=%d", c.K, c.PoolNum)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("config change not detected")
+	}
+	if w.Current().PoolNum != Default().PoolNum {
+		t.Fatalf("restart-only setting applied, PoolNum=%d", w.Current().PoolNum)
+	}
+	os.WriteFile(path, []byte(`K = 4
+DetectCycle = "10s"
+PoolNum = 8`), 0644)
+	select {
+	case c := <-updates:
+		if c.K != 4 || c.PoolNum != Default().PoolNum {
+			t.Fatalf("unexpected reloaded config: K=%d PoolNum=%d", c.K, c.PoolNum)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("config change not detected")
+	}
+
+	// 不合法的配置不生效
+	os.WriteFile(path, []byte("K = 0"), 0644)
+	time.Sleep(100 * time.Millisecond)
+	if w.Current().K != 4 {
+		t.Fatalf("invalid config applied, K=%d", w.Current().K)
+	}
+	select {
+	case c := <-updates:
+		t.Fatalf("unexpected update for invalid config: %+v", c)
+	default:
+	}
+	// 失败后同样记录文件内容，同一份不合法的文件不会被反复加载
+	if w.changed() {
+		t.Fatal("expected the invalid file to be recorded after a failed reload")
+	}
+
+	// 空文件不会让配置项回到默认值
+	os.WriteFile(path, nil, 0644)
+	time.Sleep(100 * time.Millisecond)
+	if w.Current().K != 4 {
+		t.Fatalf("empty config applied, K=%d", w.Current().K)
+	}
+	select {
+	case c := <-updates:
+		t.Fatalf("unexpected update for empty config: %+v", c)
+	default:
+	}
+	if err := w.Reload(); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Fatalf("expected empty config to be rejected, got %v", err)
+	}
+
+	// 不检查文件变化时，SIGHUP 同样触发重新加载
+	cancel()
+	WatchInterval = time.Hour
+	w = NewWatcher(path, w.Current())
+	updates = w.Subscribe()
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx, hup)
+	os.WriteFile(path, []byte("Theta = 0.5"), 0644)
+	hup <- os.Interrupt
+	select {
+	case c := <-updates:
+		if c.Theta != 0.5 || c.K != Default().K {
+			t.Fatalf("unexpected reloaded config: K=%d Theta=%v", c.K, c.Theta)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("SIGHUP did not trigger a reload")
+	}
+}
@@ -3,39 +3,62 @@ package dao
 import (
 	"control/config"
 	"database/sql"
-	"fmt"
-	"log"
-	"github.com/BurntSushi/toml"
+	"time"
+
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gomodule/redigo/redis"
+	_ "github.com/mattn/go-sqlite3"
 )
 
-// 连接数据库
-func ConnectToDB() *sql.DB {
-	dsn := config.Mysqldb
-	db, err := sql.Open("mysql", dsn)
+// 打开数据库连接池，按配置限制连接数和连接复用时长，所有服务共享
+func OpenDB(c config.ConfigInfo) (*sql.DB, error) {
+	if c.Driver == "sqlite" {
+		return openSQLite(c.DSN)
+	}
+	db, err := sql.Open("mysql", c.DSN)
 	if err != nil {
-		fmt.Println("Error ConnectToDB:", err)
-		return nil
+		return nil, err
 	}
-	return db
+	db.SetMaxOpenConns(c.DBMaxOpenConns)
+	db.SetMaxIdleConns(c.DBMaxIdleConns)
+	db.SetConnMaxLifetime(c.DBConnMaxLifetime)
+	return db, nil
 }
-// 连接redis
-func ConnRedis() redis.Conn {
-	// 连接 Redis
-	conn, err := redis.Dial("tcp", "localhost:6379")
+
+// 打开 sqlite 数据库，dsn 为数据库文件路径
+// sqlite 同一时间只允许一个写入，连接池只保留一个连接，写入排队而不是返回 database is locked；
+// 连接不过期，否则 :memory: 数据库会随连接关闭而丢失
+func openSQLite(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+		return nil, err
 	}
-	return conn
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	return db, nil
 }
-// 暴露配置文件参数方法
-func UseToml() config.ConfigInfo {
-	var c config.ConfigInfo
-	var path string = "../config/conf.toml"
-	if _, err := toml.DecodeFile(path, &c); err != nil {
-		log.Fatal(err)
 
+// 创建redis连接池，供各个服务共享，连接用尽时等待空闲连接
+func NewRedisPool(c config.ConfigInfo) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     c.RedisMaxIdle,
+		MaxActive:   c.RedisMaxActive,
+		IdleTimeout: c.RedisIdleTimeout,
+		Wait:        true,
+		Dial: func() (redis.Conn, error) {
+			return dialRedis(c)
+		},
+		// 空闲超过一分钟的连接取出时先确认仍然可用
+		TestOnBorrow: func(conn redis.Conn, idleSince time.Time) error {
+			if time.Since(idleSince) < time.Minute {
+				return nil
+			}
+			_, err := conn.Do("PING")
+			return err
+		},
 	}
-	return c
-}
\ No newline at end of file
+}
+
+func dialRedis(c config.ConfigInfo) (redis.Conn, error) {
+	return redis.Dial("tcp", c.RedisAddr, redis.DialPassword(c.RedisPassword), redis.DialDatabase(c.RedisDB))
+}
@@ -1,11 +1,29 @@
 package dao
 
 import (
-	"fmt"
+	"control/config"
 	"testing"
 )
 
-func TestUseToml(t *testing.T) {
-	c := UseToml()
-	fmt.Println(c)
-}
\ No newline at end of file
+// 测试连接池按配置设置连接数上限
+func TestOpenDB(t *testing.T) {
+	c := config.Default()
+	db, err := OpenDB(c)
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+	if max := db.Stats().MaxOpenConnections; max != c.DBMaxOpenConns {
+		t.Errorf("expected %d max open connections, got %d", c.DBMaxOpenConns, max)
+	}
+}
+
+// 测试 redis 连接池按配置设置连接数上限
+func TestNewRedisPool(t *testing.T) {
+	c := config.Default()
+	pool := NewRedisPool(c)
+	defer pool.Close()
+	if pool.MaxActive != c.RedisMaxActive || pool.MaxIdle != c.RedisMaxIdle || !pool.Wait {
+		t.Errorf("unexpected pool limits: %+v", pool)
+	}
+}
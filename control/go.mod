@@ -7,6 +7,7 @@ toolchain go1.23.7
 require (
 	github.com/go-sql-driver/mysql v1.9.0
 	github.com/gomodule/redigo v1.9.2
+	github.com/mattn/go-sqlite3 v1.14.24
 	github.com/panjf2000/ants/v2 v2.11.2
 	google.golang.org/grpc v1.71.0
 	google.golang.org/protobuf v1.36.5
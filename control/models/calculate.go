@@ -0,0 +1,144 @@
+package models
+
+import (
+	"control/config"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// 链路延迟的统计方法
+const (
+	StatsMean       = "mean"       //成功样本延迟的平均值
+	StatsMedian     = "median"     //成功样本延迟的中位数
+	StatsPercentile = "percentile" //成功样本延迟的百分位数
+	StatsEWMA       = "ewma"       //成功样本延迟的指数加权移动平均，越新的样本权重越大
+)
+
+// 样本数少于 StatsOptions.MinSamples，不足以计算链路统计
+var ErrTooFewSamples = errors.New("too few samples")
+
+// 链路统计参数
+type StatsOptions struct {
+	Method     string  //延迟的统计方法 mean/median/percentile/ewma，为空时使用 mean
+	Percentile float64 //percentile 方法使用的百分位，取值 (0, 100]
+	Alpha      float64 //ewma 方法中每个新样本的权重，取值 (0, 1]
+	MinSamples int     //计算所需的最少样本数
+}
+
+// 计算一条链路的延迟（单位ms）和丢包率，results 按从新到旧排列
+// 没有样本或没有成功的样本时视为链路不通，延迟为 0、丢包率为 1；样本少于 MinSamples 时返回 ErrTooFewSamples
+func CalculateLinkStats(results []config.ProbeResult, opts StatsOptions) (float64, float64, error) {
+	if len(results) == 0 {
+		return 0, 1, nil
+	}
+	if len(results) < opts.MinSamples {
+		return 0, 0, fmt.Errorf("%w: %d of %d", ErrTooFewSamples, len(results), opts.MinSamples)
+	}
+	avgDelay, loss := CalculateAvgDelay(results)
+	var delays []float64
+	for _, result := range results {
+		if !result.Failed {
+			delays = append(delays, delayMillis(result))
+		}
+	}
+	if len(delays) == 0 {
+		return avgDelay, loss, nil
+	}
+	switch opts.Method {
+	case "", StatsMean:
+		return avgDelay, loss, nil
+	case StatsMedian:
+		return percentile(delays, 50), loss, nil
+	case StatsPercentile:
+		return percentile(delays, opts.Percentile), loss, nil
+	case StatsEWMA:
+		return ewma(delays, opts.Alpha), loss, nil
+	}
+	return 0, 0, fmt.Errorf("unknown stats method %q", opts.Method)
+}
+
+// 线性插值计算百分位数，p 取值 (0, 100]
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// 指数加权移动平均，values 按从新到旧排列，从最旧的样本开始累计
+func ewma(values []float64, alpha float64) float64 {
+	avg := values[len(values)-1]
+	for i := len(values) - 2; i >= 0; i-- {
+		avg = alpha*values[i] + (1-alpha)*avg
+	}
+	return avg
+}
+
+// 筛选 since 之后的样本，优先使用控制面收到样本的时间，旧数据使用数据面上报的时间戳，无法判断时间的样本保留
+func SamplesSince(results []config.ProbeResult, since time.Time) []config.ProbeResult {
+	var recent []config.ProbeResult
+	for _, result := range results {
+		if at := sampleTime(result); at.IsZero() || !at.Before(since) {
+			recent = append(recent, result)
+		}
+	}
+	return recent
+}
+
+// 样本的时间，优先使用控制面收到样本的时间，无法判断时为零值
+func sampleTime(result config.ProbeResult) time.Time {
+	if !result.ReceivedAt.IsZero() {
+		return result.ReceivedAt
+	}
+	return parseTimestamp(result.Timestamp)
+}
+
+// 解析数据面上报的时间戳，新版本为 RFC3339，旧版本为本地时间
+func parseTimestamp(timestamp string) time.Time {
+	if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", timestamp, time.Local); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// 计算一条链路最近探测结果的平均延迟（单位ms）和丢包率
+// 失败的探测不计入平均延迟，按全部丢包计入丢包率；没有成功的探测时平均延迟为 0、丢包率为 1
+func CalculateAvgDelay(results []config.ProbeResult) (float64, float64) {
+	var totalDelay float64
+	var succeeded, sent, received int
+	for _, result := range results {
+		resultSent, resultReceived := result.Sent, result.Received
+		// 旧版本数据面每次只上报一个成功的样本
+		if resultSent == 0 {
+			resultSent, resultReceived = 1, 1
+		}
+		sent += resultSent
+		if result.Failed {
+			continue
+		}
+		received += resultReceived
+		totalDelay += delayMillis(result)
+		succeeded++
+	}
+	if succeeded == 0 {
+		return 0, 1
+	}
+	return totalDelay / float64(succeeded), float64(sent-received) / float64(sent)
+}
+
+// 探测结果的平均延迟，单位ms
+// 新版本数据面上报微秒精度的样本统计，旧版本只有毫秒精度的 tcp_delay
+func delayMillis(result config.ProbeResult) float64 {
+	if result.Received > 0 {
+		return float64(result.AvgDelay) / 1000
+	}
+	return float64(result.Delay)
+}
@@ -1,8 +1,10 @@
 package models
 
 import (
+	"control/config"
 	pb "control/proto"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -11,7 +13,7 @@ import (
 func InsertMetricsInfo(db *sql.DB, info *pb.Metrics) error {
 	query := `
 		INSERT INTO system_info (
-			ip, 
+			node_id, ip, 
 			cpu_cores, cpu_model_name, cpu_mhz, cpu_cache_size, cpu_usage,
 			memory_total, memory_available, memory_used, memory_used_percent,
 			disk_device, disk_total, disk_free, disk_used, disk_used_percent,
@@ -19,12 +21,12 @@ func InsertMetricsInfo(db *sql.DB, info *pb.Metrics) error {
 			network_packets_sent, network_packets_recv,
 			hostname, os, platform, platform_version, uptime,
 			load1, load5, load15, timestamp
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	fmt.Printf("Timestamp: %s\n", timestamp)
 	_, err := db.Exec(query,
-		info.Ip,
+		info.NodeId, info.Ip,
 		info.CpuInfo.Cores, info.CpuInfo.ModelName, info.CpuInfo.Mhz, info.CpuInfo.CacheSize, info.CpuInfo.Usage, //5
 		info.MemoryInfo.Total, info.MemoryInfo.Available, info.MemoryInfo.Used, info.MemoryInfo.UsedPercent, //4
 		info.DiskInfo.Device, info.DiskInfo.Total, info.DiskInfo.Free, info.DiskInfo.Used, info.DiskInfo.UsedPercent, //5
@@ -36,12 +38,188 @@ func InsertMetricsInfo(db *sql.DB, info *pb.Metrics) error {
 	return err
 }
 
-// 插入链路信息
-func InsertLinkInfo(db *sql.DB, sourceIP string, destinationIP string, delay float64, timestamp string) error {
+// 插入链路信息，loss 为统计窗口内的丢包率
+func InsertLinkInfo(db *sql.DB, sourceIP string, destinationIP string, delay float64, loss float64, timestamp string) error {
 	query := `
-		INSERT INTO link_info (SourceIP, DestinationIP, Delay, Timestamp)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO link_info (SourceIP, DestinationIP, Delay, Loss, Timestamp)
+		VALUES (?, ?, ?, ?, ?)
 	`
-	_, err := db.Exec(query, sourceIP, destinationIP, delay, timestamp)
+	_, err := db.Exec(query, sourceIP, destinationIP, delay, loss, timestamp)
 	return err
 }
+
+// 注册节点，节点已存在时更新其地址、端口和标签
+func UpsertNodeInfo(db *sql.DB, node config.NodeInfo) error {
+	labels, err := json.Marshal(node.Labels)
+	if err != nil {
+		return err
+	}
+	addresses, err := json.Marshal(node.Addresses)
+	if err != nil {
+		return err
+	}
+	// 写入全部列，REPLACE 与更新已有节点等价，mysql 和 sqlite 都支持
+	query := `
+		REPLACE INTO node_info (id, address, addresses, probe_port, labels, last_seen, state)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = db.Exec(query, node.ID, node.Address, string(addresses), node.ProbePort, string(labels), node.LastSeen, node.State)
+	return err
+}
+
+// 更新节点心跳时间，返回节点是否已注册
+func UpdateNodeHeartbeat(db *sql.DB, id string, lastSeen time.Time) (bool, error) {
+	result, err := db.Exec(`UPDATE node_info SET last_seen = ?, state = 'alive' WHERE id = ?`, lastSeen, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows > 0 {
+		return true, nil
+	}
+	// 心跳时间未变化时 RowsAffected 也为 0，再确认一次节点是否存在
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM node_info WHERE id = ?`, id).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// 将 deadline 之前没有心跳的节点标记为下线，返回下线的节点数
+func MarkDeadNodes(db *sql.DB, deadline time.Time) (int64, error) {
+	result, err := db.Exec(`UPDATE node_info SET state = 'dead' WHERE state = 'alive' AND last_seen < ?`, deadline)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// 保存加入令牌，只保存密钥的哈希
+func InsertJoinToken(db *sql.DB, id string, secretHash string, expiresAt time.Time, boundNodeID string) error {
+	var bound sql.NullString
+	if boundNodeID != "" {
+		bound = sql.NullString{String: boundNodeID, Valid: true}
+	}
+	_, err := db.Exec(`INSERT INTO join_token (id, secret_hash, expires_at, bound_node_id) VALUES (?, ?, ?, ?)`, id, secretHash, expiresAt, bound)
+	return err
+}
+
+// 使用未绑定节点的加入令牌，令牌存在、密钥匹配、未过期且未被使用时标记为已被 nodeID 使用并返回 true
+// 校验和标记在同一条语句中完成，同一令牌并发使用时只有一个请求成功；
+// node_id 上的唯一索引保证同一节点 ID 并发使用不同令牌时只有一个成功，其余返回错误
+func ConsumeJoinToken(db *sql.DB, id string, secretHash string, nodeID string, now time.Time) (bool, error) {
+	result, err := db.Exec(`
+		UPDATE join_token SET used_at = ?, node_id = ?
+		WHERE id = ? AND secret_hash = ? AND used_at IS NULL AND expires_at > ? AND bound_node_id IS NULL
+	`, now, nodeID, id, secretHash, now)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// 使用绑定 nodeID 的加入令牌，令牌存在、密钥匹配、未过期且未被使用时标记为已使用并返回 true
+// 不写入 node_id，节点首次加入时使用的令牌仍是该节点 ID 唯一的加入记录
+func ConsumeRejoinToken(db *sql.DB, id string, secretHash string, nodeID string, now time.Time) (bool, error) {
+	result, err := db.Exec(`
+		UPDATE join_token SET used_at = ?
+		WHERE id = ? AND secret_hash = ? AND used_at IS NULL AND expires_at > ? AND bound_node_id = ?
+	`, now, id, secretHash, now, nodeID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// 查询是否已有加入令牌被 nodeID 使用，即该节点 ID 已经加入过
+func JoinTokenUsedBy(db *sql.DB, nodeID string) (bool, error) {
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM join_token WHERE node_id = ?`, nodeID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// 递增节点探测任务表的版本号并返回，返回值不小于 atLeast
+// 在同一事务中更新和读取，mysql 的行锁保证并发下发时版本号不重复
+func NextTaskGeneration(db *sql.DB, nodeID string, atLeast uint64) (uint64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	result, err := tx.Exec(`
+		UPDATE task_generation SET generation = CASE WHEN generation + 1 > ? THEN generation + 1 ELSE ? END
+		WHERE node_id = ?
+	`, atLeast, atLeast, nodeID)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rows == 0 {
+		if _, err := tx.Exec(`INSERT INTO task_generation (node_id, generation) VALUES (?, ?)`, nodeID, max(atLeast, 1)); err != nil {
+			return 0, err
+		}
+	}
+	var generation uint64
+	if err := tx.QueryRow(`SELECT generation FROM task_generation WHERE node_id = ?`, nodeID).Scan(&generation); err != nil {
+		return 0, err
+	}
+	return generation, tx.Commit()
+}
+
+// 保存链路历史聚合，table 为 link_rollup_1m 或 link_rollup_1h，同一链路同一区间的聚合已存在时覆盖
+func InsertLinkRollups(db *sql.DB, table string, rollups []config.LinkRollup) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`
+		REPLACE INTO ` + table + ` (SourceIP, DestinationIP, Bucket, Samples, Sent, Received, MinDelay, AvgDelay, P95Delay, Loss)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range rollups {
+		if _, err := stmt.Exec(r.SourceIP, r.DestinationIP, r.Bucket, r.Samples, r.Sent, r.Received, r.MinDelay, r.AvgDelay, r.P95Delay, r.Loss); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// 删除 before 之前的链路历史聚合，返回删除的行数
+func DeleteLinkRollups(db *sql.DB, table string, before time.Time) (int64, error) {
+	result, err := db.Exec(`DELETE FROM `+table+` WHERE Bucket < ?`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// 删除 before 之前计算的原始链路统计，返回删除的行数
+func DeleteLinkInfo(db *sql.DB, before time.Time) (int64, error) {
+	// link_info 的时间以本地时间字符串写入，按相同格式比较
+	result, err := db.Exec(`DELETE FROM link_info WHERE Timestamp < ?`, before.Local().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -2,45 +2,152 @@ package models
 
 import (
 	"control/config"
-	"control/dao"
-	"encoding/json"
-	"fmt"
-	"log"
+	"errors"
+	"math"
 	"testing"
 	"time"
 )
 
-// 测试查询IP列表
-func TestQueryIp(t *testing.T) {
-	// 连接到数据库
-	db := dao.ConnectToDB()
-	defer db.Close()
-	ips,_ :=QueryIp(db)
-	fmt.Println("Query result:", ips)
-	// 例如：确保查询结果不为空
-	if len(ips) == 0 {
-		t.Error("Expected at least one IP, but got none")
+// 测试微秒精度的探测结果换算为毫秒，旧数据使用 tcp_delay
+func TestDelayMillis(t *testing.T) {
+	if d := delayMillis(config.ProbeResult{Delay: 0, AvgDelay: 250, Received: 5}); d != 0.25 {
+		t.Errorf("Expected 0.25 ms, got %v", d)
+	}
+	if d := delayMillis(config.ProbeResult{Delay: 3}); d != 3 {
+		t.Errorf("Expected 3 ms, got %v", d)
+	}
+}
+
+// 测试失败的探测计入丢包率但不计入平均延迟
+func TestLinkStats(t *testing.T) {
+	delay, loss := CalculateAvgDelay([]config.ProbeResult{
+		{AvgDelay: 1000, Sent: 5, Received: 5},
+		{AvgDelay: 3000, Sent: 5, Received: 4},
+		{Sent: 5, Failed: true, ErrorClass: "timeout"},
+		{Delay: 2}, // 旧版本数据
+	})
+	if delay != 2 || loss != 6.0/16 {
+		t.Errorf("Expected delay 2 ms and loss 6/16, got %v %v", delay, loss)
+	}
+	if delay, loss := CalculateAvgDelay(nil); delay != 0 || loss != 1 {
+		t.Errorf("Expected no data to be treated as dead link, got %v %v", delay, loss)
 	}
 }
-//测试redis计算方法
-func TestCalculateAvgDelay(t *testing.T) {
-	conn := dao.ConnRedis()
-	db := dao.ConnectToDB()
-	defer conn.Close()
-	defer db.Close()
-	var i int64
-	for i = 1; i < 4; i++ {
-		key := "192.168.1.1:192.168.2.2"
-		value, _ := json.Marshal(config.ProbeResult{
-			SourceIP:      "192.168.1.1",
-			DestinationIP: "192.168.2.2",
-			Delay:         i,
-			Timestamp:     time.Now().Format("2006-01-02 15:04:05"),
-		})
-		_, lpushErr := conn.Do("LPUSH", key, value)
-		if lpushErr != nil {
-			log.Printf("Error storing result in redis: %v", lpushErr)
+
+// 测试各统计方法只统计成功的样本，样本不足时返回 ErrTooFewSamples
+func TestCalculateLinkStats(t *testing.T) {
+	// 从新到旧排列
+	results := []config.ProbeResult{
+		{AvgDelay: 4000, Sent: 4, Received: 4},
+		{Sent: 4, Failed: true},
+		{AvgDelay: 1000, Sent: 4, Received: 4},
+		{AvgDelay: 2000, Sent: 4, Received: 3},
+		{AvgDelay: 9000, Sent: 4, Received: 4},
+	}
+	cases := []struct {
+		opts  StatsOptions
+		delay float64
+	}{
+		{StatsOptions{Method: StatsMean}, 4},
+		{StatsOptions{Method: StatsMedian}, 3},
+		{StatsOptions{Method: StatsPercentile, Percentile: 100}, 9},
+		{StatsOptions{Method: StatsPercentile, Percentile: 50}, 3},
+		// 从最旧的 9 开始：0.5*2+0.5*9=5.5，0.5*1+0.5*5.5=3.25，0.5*4+0.5*3.25=3.625
+		{StatsOptions{Method: StatsEWMA, Alpha: 0.5}, 3.625},
+	}
+	for _, c := range cases {
+		delay, loss, err := CalculateLinkStats(results, c.opts)
+		if err != nil || delay != c.delay || loss != 5.0/20 {
+			t.Errorf("%+v: expected delay %v and loss 5/20, got %v %v %v", c.opts, c.delay, delay, loss, err)
 		}
 	}
-	CalculateAvgDelay(conn,db,"192.168.1.1","192.168.2.2")
-}
\ No newline at end of file
+	if _, _, err := CalculateLinkStats(results[:2], StatsOptions{MinSamples: 3}); !errors.Is(err, ErrTooFewSamples) {
+		t.Errorf("expected ErrTooFewSamples, got %v", err)
+	}
+	// 没有样本或全部失败时视为链路不通
+	for _, dead := range [][]config.ProbeResult{nil, results[1:2]} {
+		delay, loss, err := CalculateLinkStats(dead, StatsOptions{Method: StatsMedian, MinSamples: 1})
+		if err != nil || delay != 0 || loss != 1 || math.IsNaN(delay) {
+			t.Errorf("expected dead link, got %v %v %v", delay, loss, err)
+		}
+	}
+	if _, _, err := CalculateLinkStats(results, StatsOptions{Method: "max"}); err == nil {
+		t.Error("expected unknown method to be rejected")
+	}
+}
+
+// 测试按收到样本的时间筛选，旧数据使用上报的时间戳
+func TestSamplesSince(t *testing.T) {
+	now := time.Now()
+	results := []config.ProbeResult{
+		{Delay: 1, ReceivedAt: now},
+		{Delay: 2, ReceivedAt: now.Add(-2 * time.Minute)},
+		{Delay: 3, Timestamp: now.Add(-30 * time.Second).Format(time.RFC3339)},
+		{Delay: 4, Timestamp: now.Add(-time.Hour).Format("2006-01-02 15:04:05")},
+		{Delay: 5},
+	}
+	recent := SamplesSince(results, now.Add(-time.Minute))
+	var got []int64
+	for _, result := range recent {
+		got = append(got, result.Delay)
+	}
+	if len(got) != 3 || got[0] != 1 || got[1] != 3 || got[2] != 5 {
+		t.Errorf("expected samples 1 3 5, got %v", got)
+	}
+}
+
+// 测试把一分钟内的探测样本汇总为聚合
+func TestRollupSamples(t *testing.T) {
+	bucket := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	results := []config.ProbeResult{
+		{AvgDelay: 2000, MinDelay: 1500, Sent: 4, Received: 4, ReceivedAt: bucket.Add(50 * time.Second)},
+		{Failed: true, Sent: 4, ReceivedAt: bucket.Add(40 * time.Second)},
+		{AvgDelay: 4000, MinDelay: 3000, Sent: 4, Received: 2, ReceivedAt: bucket.Add(10 * time.Second)},
+		{AvgDelay: 9000, Sent: 4, Received: 4, ReceivedAt: bucket.Add(-time.Second)},
+		{AvgDelay: 9000, Sent: 4, Received: 4, ReceivedAt: bucket.Add(time.Minute)},
+	}
+	r, ok := RollupSamples("node-1", "node-2", bucket, time.Minute, results)
+	if !ok {
+		t.Fatal("expected a rollup")
+	}
+	if r.SourceIP != "node-1" || r.DestinationIP != "node-2" || !r.Bucket.Equal(bucket) {
+		t.Errorf("unexpected link of rollup: %+v", r)
+	}
+	if r.Samples != 2 || r.Sent != 12 || r.Received != 6 || r.Loss != 0.5 {
+		t.Errorf("unexpected counts of rollup: %+v", r)
+	}
+	if r.MinDelay != 1.5 || r.AvgDelay != 3 || math.Abs(r.P95Delay-3.9) > 1e-9 {
+		t.Errorf("unexpected delays of rollup: %+v", r)
+	}
+
+	if _, ok := RollupSamples("node-1", "node-2", bucket.Add(time.Hour), time.Minute, results); ok {
+		t.Error("expected no rollup without samples in the bucket")
+	}
+	// 区间内的探测全部失败
+	r, ok = RollupSamples("node-1", "node-2", bucket.Add(30*time.Second), 15*time.Second, results)
+	if !ok || r.Samples != 0 || r.Loss != 1 || r.AvgDelay != 0 {
+		t.Errorf("expected a dead rollup, got %+v %v", r, ok)
+	}
+}
+
+// 测试把 1 分钟聚合合并为 1 小时聚合
+func TestMergeRollups(t *testing.T) {
+	bucket := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	merged := MergeRollups(bucket, []config.LinkRollup{
+		{SourceIP: "node-1", DestinationIP: "node-2", Samples: 1, Sent: 4, Received: 4, MinDelay: 2, AvgDelay: 3, P95Delay: 4},
+		{SourceIP: "node-1", DestinationIP: "node-2", Samples: 0, Sent: 4, Loss: 1},
+		{SourceIP: "node-1", DestinationIP: "node-2", Samples: 3, Sent: 8, Received: 8, MinDelay: 1, AvgDelay: 7, P95Delay: 8},
+	})
+	if merged.SourceIP != "node-1" || merged.DestinationIP != "node-2" || !merged.Bucket.Equal(bucket) {
+		t.Errorf("unexpected link of merged rollup: %+v", merged)
+	}
+	if merged.Samples != 4 || merged.Sent != 16 || merged.Received != 12 || merged.Loss != 0.25 {
+		t.Errorf("unexpected counts of merged rollup: %+v", merged)
+	}
+	if merged.MinDelay != 1 || merged.AvgDelay != 6 || math.Abs(merged.P95Delay-7.8) > 1e-9 {
+		t.Errorf("unexpected delays of merged rollup: %+v", merged)
+	}
+	if dead := MergeRollups(bucket, []config.LinkRollup{{Sent: 4, Loss: 1}}); dead.MinDelay != 0 || dead.Loss != 1 {
+		t.Errorf("expected a dead rollup, got %+v", dead)
+	}
+}
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"control/config"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // 查询ip列表
@@ -28,3 +31,136 @@ func QueryIp(db *sql.DB) ([]string, error) {
 
 	return ips, nil
 }
+
+// 查询每条链路最新一次计算出的平均延迟和丢包率
+func QueryLatestLinkInfo(db *sql.DB) ([]config.LinkInfo, error) {
+	query := `
+		SELECT l.SourceIP, l.DestinationIP, l.Delay, l.Loss, l.Timestamp
+		FROM link_info l
+		JOIN (
+			SELECT SourceIP, DestinationIP, MAX(Timestamp) AS Timestamp
+			FROM link_info
+			GROUP BY SourceIP, DestinationIP
+		) latest
+		ON l.SourceIP = latest.SourceIP
+			AND l.DestinationIP = latest.DestinationIP
+			AND l.Timestamp = latest.Timestamp
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var links []config.LinkInfo
+	for rows.Next() {
+		var link config.LinkInfo
+		if err := rows.Scan(&link.SourceIP, &link.DestinationIP, &link.Delay, &link.Loss, &link.Timestamp); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// 查询 src 到 dst 在 [from, to) 内的链路历史聚合，按区间开始时间升序排列，table 为 link_rollup_1m 或 link_rollup_1h
+func QueryLinkRollups(db *sql.DB, table string, src string, dst string, from time.Time, to time.Time) ([]config.LinkRollup, error) {
+	rows, err := db.Query(`
+		SELECT SourceIP, DestinationIP, Bucket, Samples, Sent, Received, MinDelay, AvgDelay, P95Delay, Loss
+		FROM `+table+`
+		WHERE SourceIP = ? AND DestinationIP = ? AND Bucket >= ? AND Bucket < ?
+		ORDER BY Bucket
+	`, src, dst, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return scanLinkRollups(rows)
+}
+
+// 查询全部链路在 [from, to) 内的链路历史聚合，按链路和区间开始时间排列
+func QueryRollups(db *sql.DB, table string, from time.Time, to time.Time) ([]config.LinkRollup, error) {
+	rows, err := db.Query(`
+		SELECT SourceIP, DestinationIP, Bucket, Samples, Sent, Received, MinDelay, AvgDelay, P95Delay, Loss
+		FROM `+table+`
+		WHERE Bucket >= ? AND Bucket < ?
+		ORDER BY SourceIP, DestinationIP, Bucket
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return scanLinkRollups(rows)
+}
+
+func scanLinkRollups(rows *sql.Rows) ([]config.LinkRollup, error) {
+	defer rows.Close()
+	var rollups []config.LinkRollup
+	for rows.Next() {
+		var r config.LinkRollup
+		if err := rows.Scan(&r.SourceIP, &r.DestinationIP, &r.Bucket, &r.Samples, &r.Sent, &r.Received, &r.MinDelay, &r.AvgDelay, &r.P95Delay, &r.Loss); err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rollups, nil
+}
+
+// 查询在线节点，即状态为 alive 且 deadline 之后有过心跳的节点
+func QueryAliveNodes(db *sql.DB, deadline time.Time) ([]config.NodeInfo, error) {
+	rows, err := db.Query(`
+		SELECT id, address, addresses, probe_port, labels, last_seen, state
+		FROM node_info
+		WHERE state = 'alive' AND last_seen >= ?
+		ORDER BY id
+	`, deadline)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var nodes []config.NodeInfo
+	for rows.Next() {
+		node, err := scanNodeInfo(rows)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// 按节点 ID 查询节点，节点不存在时返回 sql.ErrNoRows
+func QueryNodeInfo(db *sql.DB, id string) (config.NodeInfo, error) {
+	row := db.QueryRow(`
+		SELECT id, address, addresses, probe_port, labels, last_seen, state
+		FROM node_info
+		WHERE id = ?
+	`, id)
+	return scanNodeInfo(row)
+}
+
+// 解析 node_info 表中的一行
+func scanNodeInfo(row interface{ Scan(...any) error }) (config.NodeInfo, error) {
+	var node config.NodeInfo
+	var addresses, labels sql.NullString
+	if err := row.Scan(&node.ID, &node.Address, &addresses, &node.ProbePort, &labels, &node.LastSeen, &node.State); err != nil {
+		return node, err
+	}
+	if addresses.Valid && addresses.String != "" {
+		if err := json.Unmarshal([]byte(addresses.String), &node.Addresses); err != nil {
+			return node, err
+		}
+	}
+	if labels.Valid && labels.String != "" {
+		if err := json.Unmarshal([]byte(labels.String), &node.Labels); err != nil {
+			return node, err
+		}
+	}
+	return node, nil
+}
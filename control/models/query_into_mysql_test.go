@@ -0,0 +1,79 @@
+package models_test
+
+import (
+	"control/config"
+	"control/dao"
+	"control/models"
+	pb "control/proto"
+	"control/storage"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// 设置该环境变量后 TestQueryIp 同时在 mysql 上运行，取值为测试库的连接串
+const mysqlDSNEnv = "SIRIUS_TEST_MYSQL_DSN"
+
+// 测试查询节点 IP 列表，默认使用临时的 sqlite 数据库，设置 SIRIUS_TEST_MYSQL_DSN 后同时测试 mysql
+func TestQueryIp(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testQueryIp(t, "sqlite", filepath.Join(t.TempDir(), "sirius.db"))
+	})
+	t.Run("mysql", func(t *testing.T) {
+		dsn := os.Getenv(mysqlDSNEnv)
+		if dsn == "" {
+			t.Skipf("%s is not set", mysqlDSNEnv)
+		}
+		testQueryIp(t, "mysql", dsn)
+	})
+}
+
+func testQueryIp(t *testing.T, driver, dsn string) {
+	c := config.Default()
+	c.Driver = driver
+	c.DSN = dsn
+	c.SampleStore = "memory"
+	store, err := storage.Open(c)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer store.Close()
+	if _, err := store.MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+	for _, node := range [][2]string{{"query-ip-1", "10.0.0.1"}, {"query-ip-2", "10.0.0.2"}, {"query-ip-1", "10.0.0.1"}} {
+		m := &pb.Metrics{
+			NodeId:      node[0],
+			Ip:          node[1],
+			CpuInfo:     &pb.CPUInfo{},
+			MemoryInfo:  &pb.MemoryInfo{},
+			DiskInfo:    &pb.DiskInfo{},
+			NetworkInfo: &pb.NetworkInfo{},
+			HostInfo:    &pb.HostInfo{Hostname: node[0]},
+			LoadInfo:    &pb.LoadInfo{},
+		}
+		if err := store.Metrics.InsertMetrics(m); err != nil {
+			t.Fatalf("InsertMetrics failed: %v", err)
+		}
+	}
+
+	db, err := dao.OpenDB(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ips, err := models.QueryIp(db)
+	if err != nil {
+		t.Fatalf("QueryIp failed: %v", err)
+	}
+	// 同一 IP 只返回一次，mysql 测试库中可能还有其他节点
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		if !slices.Contains(ips, ip) {
+			t.Errorf("expected %s in %v", ip, ips)
+		}
+	}
+	if driver == "sqlite" && len(ips) != 2 {
+		t.Errorf("expected 2 distinct IPs, got %v", ips)
+	}
+}
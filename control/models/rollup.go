@@ -0,0 +1,87 @@
+package models
+
+import (
+	"control/config"
+	"math"
+	"time"
+)
+
+// 把 [bucket, bucket+width) 内收到的探测样本汇总为一个聚合，区间内没有样本时返回 false
+// 延迟统计只使用成功的探测，95 分位按各次探测的平均延迟计算
+func RollupSamples(src, dst string, bucket time.Time, width time.Duration, results []config.ProbeResult) (config.LinkRollup, bool) {
+	rollup := config.LinkRollup{SourceIP: src, DestinationIP: dst, Bucket: bucket}
+	end := bucket.Add(width)
+	var delays []float64
+	for _, result := range results {
+		at := sampleTime(result)
+		if at.Before(bucket) || !at.Before(end) {
+			continue
+		}
+		sent, received := result.Sent, result.Received
+		// 旧版本数据面每次只上报一个成功的样本
+		if sent == 0 {
+			sent, received = 1, 1
+		}
+		rollup.Sent += sent
+		if result.Failed {
+			continue
+		}
+		rollup.Received += received
+		delays = append(delays, delayMillis(result))
+		minDelay := delayMillis(result)
+		if result.Received > 0 && result.MinDelay > 0 {
+			minDelay = float64(result.MinDelay) / 1000
+		}
+		if len(delays) == 1 || minDelay < rollup.MinDelay {
+			rollup.MinDelay = minDelay
+		}
+	}
+	if rollup.Sent == 0 {
+		return rollup, false
+	}
+	rollup.Samples = len(delays)
+	rollup.Loss = float64(rollup.Sent-rollup.Received) / float64(rollup.Sent)
+	if len(delays) > 0 {
+		rollup.AvgDelay = mean(delays)
+		rollup.P95Delay = percentile(delays, 95)
+	}
+	return rollup, true
+}
+
+// 把同一链路的多个聚合合并为从 bucket 开始的一个聚合
+// 平均延迟按成功的探测数加权，95 分位近似为各聚合 95 分位的 95 分位
+func MergeRollups(bucket time.Time, rollups []config.LinkRollup) config.LinkRollup {
+	merged := config.LinkRollup{Bucket: bucket, MinDelay: math.Inf(1)}
+	var totalDelay float64
+	var p95s []float64
+	for _, rollup := range rollups {
+		merged.SourceIP, merged.DestinationIP = rollup.SourceIP, rollup.DestinationIP
+		merged.Sent += rollup.Sent
+		merged.Received += rollup.Received
+		if rollup.Samples == 0 {
+			continue
+		}
+		merged.Samples += rollup.Samples
+		totalDelay += rollup.AvgDelay * float64(rollup.Samples)
+		merged.MinDelay = math.Min(merged.MinDelay, rollup.MinDelay)
+		p95s = append(p95s, rollup.P95Delay)
+	}
+	if merged.Samples == 0 {
+		merged.MinDelay = 0
+	} else {
+		merged.AvgDelay = totalDelay / float64(merged.Samples)
+		merged.P95Delay = percentile(p95s, 95)
+	}
+	if merged.Sent > 0 {
+		merged.Loss = float64(merged.Sent-merged.Received) / float64(merged.Sent)
+	}
+	return merged
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,165 @@
+package pki
+
+import (
+	"context"
+	pb "control/proto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// 记录请求方节点 ID 的 NodeService
+type peerNodeServer struct {
+	pb.UnimplementedNodeServiceServer
+}
+
+func (s *peerNodeServer) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	id, ok := PeerNodeID(ctx)
+	if !ok {
+		return &pb.HeartbeatResponse{Status: "anonymous"}, nil
+	}
+	return &pb.HeartbeatResponse{Status: id}, nil
+}
+
+func (s *peerNodeServer) Join(ctx context.Context, req *pb.JoinRequest) (*pb.JoinResponse, error) {
+	return &pb.JoinResponse{NodeId: req.NodeId}, nil
+}
+
+// 测试 CA 的生成和重新加载
+func TestLoadOrCreateCA(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pki")
+	ca, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	loaded, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("Failed to load CA: %v", err)
+	}
+	if !ca.Cert.Equal(loaded.Cert) {
+		t.Fatal("reloaded CA differs from the created one")
+	}
+	if _, err := ca.IssueNodeCert(ControlCommonName, &ca.key.PublicKey); err == nil {
+		t.Fatal("expected node ID reserved for the control plane to be rejected")
+	}
+}
+
+// 测试双向 TLS：节点证书绑定节点 ID，无证书或其他 CA 签发的证书被拒绝
+func TestMutualTLS(t *testing.T) {
+	if err := Init(t.TempDir()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer func() { ca = nil }()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := grpc.NewServer(ServerOptions()...)
+	pb.RegisterNodeServiceServer(server, &peerNodeServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	heartbeat := func(cfg *tls.Config) (string, error) {
+		conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		resp, err := pb.NewNodeServiceClient(conn).Heartbeat(ctx, &pb.HeartbeatRequest{})
+		if err != nil {
+			return "", err
+		}
+		return resp.Status, nil
+	}
+	clientConfig := func(issuer *CA, nodeID string) *tls.Config {
+		certPEM, keyPEM, err := issuer.IssueKeyPair(nodeID)
+		if err != nil {
+			t.Fatalf("Failed to issue certificate: %v", err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			t.Fatalf("Invalid key pair: %v", err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: GetCA().Pool(), ServerName: ControlCommonName}
+	}
+
+	id, err := heartbeat(clientConfig(GetCA(), "node-1"))
+	if err != nil || id != "node-1" {
+		t.Fatalf("expected authenticated node-1, got %q, %v", id, err)
+	}
+	// 无证书的客户端只能调用 Join
+	anonymous := &tls.Config{RootCAs: GetCA().Pool(), ServerName: ControlCommonName}
+	if _, err := heartbeat(anonymous); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected client without certificate to be rejected, got %v", err)
+	}
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(anonymous)))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := pb.NewNodeServiceClient(conn).Join(ctx, &pb.JoinRequest{NodeId: "node-1"}); err != nil {
+		t.Fatalf("expected Join without certificate to pass, got %v", err)
+	}
+	other, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	if _, err := heartbeat(clientConfig(other, "node-1")); err == nil {
+		t.Fatal("expected certificate from another CA to be rejected")
+	}
+}
+
+// 测试按证书签名请求签发证书：证书绑定指定的节点 ID 而非请求中的主题
+func TestSignCSR(t *testing.T) {
+	ca, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: ControlCommonName}}, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate request: %v", err)
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+
+	certPEM, err := ca.SignCSR("node-1", csrPEM)
+	if err != nil {
+		t.Fatalf("SignCSR failed: %v", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Invalid certificate: %v", err)
+	}
+	if cert.Subject.CommonName != "node-1" || !cert.PublicKey.(*ecdsa.PublicKey).Equal(&key.PublicKey) {
+		t.Fatalf("certificate not bound to node-1 and the requested key: %v", cert.Subject)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: ca.Pool(), DNSName: "node-1"}); err != nil {
+		t.Fatalf("certificate does not verify against CA: %v", err)
+	}
+	if _, err := ca.SignCSR("node-1", []byte("garbage")); err == nil {
+		t.Fatal("expected malformed request to be rejected")
+	}
+	if !strings.HasPrefix(ca.Fingerprint(), "sha256:") || len(ca.Fingerprint()) != len("sha256:")+64 {
+		t.Fatalf("unexpected fingerprint %q", ca.Fingerprint())
+	}
+}
@@ -0,0 +1,137 @@
+package pki
+
+import (
+	"context"
+	pb "control/proto"
+	"crypto/tls"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// 无需客户端证书即可调用的方法，节点加入时还没有证书，凭加入令牌认证
+var AnonymousMethods = map[string]bool{
+	pb.NodeService_Join_FullMethodName: true,
+}
+
+var (
+	ca      *CA
+	tlsCert tls.Certificate
+	mu      sync.RWMutex
+)
+
+// 初始化全局 CA，并为控制面签发自身的证书，未初始化时所有 gRPC 连接均不加密
+func Init(dir string) error {
+	loaded, err := LoadOrCreateCA(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load CA from %s: %v", dir, err)
+	}
+	certPEM, keyPEM, err := loaded.IssueKeyPair(ControlCommonName)
+	if err != nil {
+		return fmt.Errorf("failed to issue control plane certificate: %v", err)
+	}
+	cert, err := tls.X509KeyPair(append(certPEM, loaded.CertPEM...), keyPEM)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	ca = loaded
+	tlsCert = cert
+	return nil
+}
+
+// 获取全局 CA，未初始化时返回 nil
+func GetCA() *CA {
+	mu.RLock()
+	defer mu.RUnlock()
+	return ca
+}
+
+// 是否已初始化全局 CA，即配置启用了 TLS
+func Enabled() bool {
+	return GetCA() != nil
+}
+
+// 清除全局 CA，之后所有 gRPC 连接均不加密，用于测试恢复初始状态
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	ca = nil
+	tlsCert = tls.Certificate{}
+}
+
+// gRPC 服务端的选项，要求客户端出示本 CA 签发的证书
+// 握手时证书可选，以便未加入的节点调用 AnonymousMethods，其余方法由拦截器拒绝无证书的请求
+func ServerOptions() []grpc.ServerOption {
+	mu.RLock()
+	defer mu.RUnlock()
+	if ca == nil {
+		return []grpc.ServerOption{grpc.Creds(insecure.NewCredentials())}
+	}
+	return []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{tlsCert},
+			ClientCAs:    ca.Pool(),
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			MinVersion:   tls.VersionTLS12,
+		})),
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := requireClientCert(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := requireClientCert(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+func requireClientCert(ctx context.Context, method string) error {
+	if AnonymousMethods[method] {
+		return nil
+	}
+	if _, ok := PeerNodeID(ctx); !ok {
+		return status.Errorf(codes.Unauthenticated, "client certificate required for %s", method)
+	}
+	return nil
+}
+
+// 连接节点时的传输凭证，校验对端证书属于 nodeID
+func DialOption(nodeID string) grpc.DialOption {
+	mu.RLock()
+	defer mu.RUnlock()
+	if ca == nil {
+		return grpc.WithTransportCredentials(insecure.NewCredentials())
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{tlsCert},
+		RootCAs:      ca.Pool(),
+		ServerName:   nodeID,
+		MinVersion:   tls.VersionTLS12,
+	}))
+}
+
+// 从请求上下文中取出已认证的节点 ID，即客户端证书的通用名
+// 连接没有经过校验的客户端证书时 ok 为 false
+func PeerNodeID(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName, true
+}
@@ -0,0 +1,405 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.5
+// 	protoc        v6.30.0
+// source: proto/channel.proto
+
+package protocol
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// 数据面发往控制面的消息
+type AgentMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*AgentMessage_Hello
+	//	*AgentMessage_Metrics
+	//	*AgentMessage_ProbeResults
+	//	*AgentMessage_RouteAck
+	//	*AgentMessage_TaskAck
+	Payload       isAgentMessage_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentMessage) Reset() {
+	*x = AgentMessage{}
+	mi := &file_proto_channel_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMessage) ProtoMessage() {}
+
+func (x *AgentMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_channel_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMessage.ProtoReflect.Descriptor instead.
+func (*AgentMessage) Descriptor() ([]byte, []int) {
+	return file_proto_channel_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AgentMessage) GetPayload() isAgentMessage_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetHello() *Hello {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentMessage_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetMetrics() *Metrics {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentMessage_Metrics); ok {
+			return x.Metrics
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetProbeResults() *ProbeResultRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentMessage_ProbeResults); ok {
+			return x.ProbeResults
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetRouteAck() *RouteTableResponse {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentMessage_RouteAck); ok {
+			return x.RouteAck
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetTaskAck() *ProbeTaskResponse {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentMessage_TaskAck); ok {
+			return x.TaskAck
+		}
+	}
+	return nil
+}
+
+type isAgentMessage_Payload interface {
+	isAgentMessage_Payload()
+}
+
+type AgentMessage_Hello struct {
+	Hello *Hello `protobuf:"bytes,1,opt,name=hello,proto3,oneof"` // 建立连接后的第一条消息
+}
+
+type AgentMessage_Metrics struct {
+	Metrics *Metrics `protobuf:"bytes,2,opt,name=metrics,proto3,oneof"` // 节点指标
+}
+
+type AgentMessage_ProbeResults struct {
+	ProbeResults *ProbeResultRequest `protobuf:"bytes,3,opt,name=probe_results,json=probeResults,proto3,oneof"` // 探测结果
+}
+
+type AgentMessage_RouteAck struct {
+	RouteAck *RouteTableResponse `protobuf:"bytes,4,opt,name=route_ack,json=routeAck,proto3,oneof"` // 路由表的应用结果
+}
+
+type AgentMessage_TaskAck struct {
+	TaskAck *ProbeTaskResponse `protobuf:"bytes,5,opt,name=task_ack,json=taskAck,proto3,oneof"` // 探测任务的应用结果
+}
+
+func (*AgentMessage_Hello) isAgentMessage_Payload() {}
+
+func (*AgentMessage_Metrics) isAgentMessage_Payload() {}
+
+func (*AgentMessage_ProbeResults) isAgentMessage_Payload() {}
+
+func (*AgentMessage_RouteAck) isAgentMessage_Payload() {}
+
+func (*AgentMessage_TaskAck) isAgentMessage_Payload() {}
+
+// 建立连接后数据面发送的第一条消息，表明节点身份
+type Hello struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"` // 节点 ID，须已通过 NodeService 注册
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	mi := &file_proto_channel_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_channel_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_proto_channel_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Hello) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+// 控制面发往数据面的消息
+type ControlMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ControlMessage_ProbeTasks
+	//	*ControlMessage_Routes
+	Payload       isControlMessage_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlMessage) Reset() {
+	*x = ControlMessage{}
+	mi := &file_proto_channel_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlMessage) ProtoMessage() {}
+
+func (x *ControlMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_channel_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlMessage.ProtoReflect.Descriptor instead.
+func (*ControlMessage) Descriptor() ([]byte, []int) {
+	return file_proto_channel_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ControlMessage) GetPayload() isControlMessage_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ControlMessage) GetProbeTasks() *ProbeTaskRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*ControlMessage_ProbeTasks); ok {
+			return x.ProbeTasks
+		}
+	}
+	return nil
+}
+
+func (x *ControlMessage) GetRoutes() *RouteTableRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*ControlMessage_Routes); ok {
+			return x.Routes
+		}
+	}
+	return nil
+}
+
+type isControlMessage_Payload interface {
+	isControlMessage_Payload()
+}
+
+type ControlMessage_ProbeTasks struct {
+	ProbeTasks *ProbeTaskRequest `protobuf:"bytes,1,opt,name=probe_tasks,json=probeTasks,proto3,oneof"` // 探测任务
+}
+
+type ControlMessage_Routes struct {
+	Routes *RouteTableRequest `protobuf:"bytes,2,opt,name=routes,proto3,oneof"` // 路由表
+}
+
+func (*ControlMessage_ProbeTasks) isControlMessage_Payload() {}
+
+func (*ControlMessage_Routes) isControlMessage_Payload() {}
+
+var File_proto_channel_proto protoreflect.FileDescriptor
+
+var file_proto_channel_proto_rawDesc = string([]byte{
+	0x0a, 0x13, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x1a, 0x11,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x1a, 0x13, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xa2, 0x02, 0x0a, 0x0c, 0x41, 0x67, 0x65, 0x6e, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x2e, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x48, 0x00, 0x52, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x12,
+	0x2c, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x10, 0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x48, 0x00, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x40, 0x0a,
+	0x0d, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f,
+	0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48,
+	0x00, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12,
+	0x38, 0x0a, 0x09, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x5f, 0x61, 0x63, 0x6b, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65,
+	0x54, 0x61, 0x62, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52,
+	0x08, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x41, 0x63, 0x6b, 0x12, 0x35, 0x0a, 0x08, 0x74, 0x61, 0x73,
+	0x6b, 0x5f, 0x61, 0x63, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x72,
+	0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x07, 0x74, 0x61, 0x73, 0x6b, 0x41, 0x63, 0x6b,
+	0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x20, 0x0a, 0x05, 0x48,
+	0x65, 0x6c, 0x6c, 0x6f, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x22, 0x8b, 0x01,
+	0x0a, 0x0e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x3a, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x5f, 0x74, 0x61, 0x73, 0x6b, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72,
+	0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00,
+	0x52, 0x0a, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x32, 0x0a, 0x06,
+	0x72, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70,
+	0x72, 0x6f, 0x62, 0x65, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x06, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x73,
+	0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x32, 0x4f, 0x0a, 0x0e, 0x43,
+	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3d, 0x0a,
+	0x07, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x12, 0x15, 0x2e, 0x63, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a,
+	0x17, 0x2e, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f,
+	0x6c, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42, 0x0c, 0x5a, 0x0a,
+	0x2e, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+})
+
+var (
+	file_proto_channel_proto_rawDescOnce sync.Once
+	file_proto_channel_proto_rawDescData []byte
+)
+
+func file_proto_channel_proto_rawDescGZIP() []byte {
+	file_proto_channel_proto_rawDescOnce.Do(func() {
+		file_proto_channel_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_channel_proto_rawDesc), len(file_proto_channel_proto_rawDesc)))
+	})
+	return file_proto_channel_proto_rawDescData
+}
+
+var file_proto_channel_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_channel_proto_goTypes = []any{
+	(*AgentMessage)(nil),       // 0: channel.AgentMessage
+	(*Hello)(nil),              // 1: channel.Hello
+	(*ControlMessage)(nil),     // 2: channel.ControlMessage
+	(*Metrics)(nil),            // 3: metrics.Metrics
+	(*ProbeResultRequest)(nil), // 4: probe.ProbeResultRequest
+	(*RouteTableResponse)(nil), // 5: probe.RouteTableResponse
+	(*ProbeTaskResponse)(nil),  // 6: probe.ProbeTaskResponse
+	(*ProbeTaskRequest)(nil),   // 7: probe.ProbeTaskRequest
+	(*RouteTableRequest)(nil),  // 8: probe.RouteTableRequest
+}
+var file_proto_channel_proto_depIdxs = []int32{
+	1, // 0: channel.AgentMessage.hello:type_name -> channel.Hello
+	3, // 1: channel.AgentMessage.metrics:type_name -> metrics.Metrics
+	4, // 2: channel.AgentMessage.probe_results:type_name -> probe.ProbeResultRequest
+	5, // 3: channel.AgentMessage.route_ack:type_name -> probe.RouteTableResponse
+	6, // 4: channel.AgentMessage.task_ack:type_name -> probe.ProbeTaskResponse
+	7, // 5: channel.ControlMessage.probe_tasks:type_name -> probe.ProbeTaskRequest
+	8, // 6: channel.ControlMessage.routes:type_name -> probe.RouteTableRequest
+	0, // 7: channel.ChannelService.Connect:input_type -> channel.AgentMessage
+	2, // 8: channel.ChannelService.Connect:output_type -> channel.ControlMessage
+	8, // [8:9] is the sub-list for method output_type
+	7, // [7:8] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_proto_channel_proto_init() }
+func file_proto_channel_proto_init() {
+	if File_proto_channel_proto != nil {
+		return
+	}
+	file_proto_probe_proto_init()
+	file_proto_service_proto_init()
+	file_proto_channel_proto_msgTypes[0].OneofWrappers = []any{
+		(*AgentMessage_Hello)(nil),
+		(*AgentMessage_Metrics)(nil),
+		(*AgentMessage_ProbeResults)(nil),
+		(*AgentMessage_RouteAck)(nil),
+		(*AgentMessage_TaskAck)(nil),
+	}
+	file_proto_channel_proto_msgTypes[2].OneofWrappers = []any{
+		(*ControlMessage_ProbeTasks)(nil),
+		(*ControlMessage_Routes)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_channel_proto_rawDesc), len(file_proto_channel_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_channel_proto_goTypes,
+		DependencyIndexes: file_proto_channel_proto_depIdxs,
+		MessageInfos:      file_proto_channel_proto_msgTypes,
+	}.Build()
+	File_proto_channel_proto = out.File
+	file_proto_channel_proto_goTypes = nil
+	file_proto_channel_proto_depIdxs = nil
+}
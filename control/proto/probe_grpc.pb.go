@@ -20,6 +20,7 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	ProbeTaskService_SendProbeTasks_FullMethodName = "/probe.ProbeTaskService/SendProbeTasks"
+	ProbeTaskService_ListProbeTasks_FullMethodName = "/probe.ProbeTaskService/ListProbeTasks"
 )
 
 // ProbeTaskServiceClient is the client API for ProbeTaskService service.
@@ -30,6 +31,8 @@ const (
 type ProbeTaskServiceClient interface {
 	// 发起探测任务，返回任务执行的状态
 	SendProbeTasks(ctx context.Context, in *ProbeTaskRequest, opts ...grpc.CallOption) (*ProbeTaskResponse, error)
+	// 列出数据面当前的探测任务，用于排查
+	ListProbeTasks(ctx context.Context, in *ListProbeTasksRequest, opts ...grpc.CallOption) (*ListProbeTasksResponse, error)
 }
 
 type probeTaskServiceClient struct {
@@ -50,6 +53,16 @@ func (c *probeTaskServiceClient) SendProbeTasks(ctx context.Context, in *ProbeTa
 	return out, nil
 }
 
+func (c *probeTaskServiceClient) ListProbeTasks(ctx context.Context, in *ListProbeTasksRequest, opts ...grpc.CallOption) (*ListProbeTasksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProbeTasksResponse)
+	err := c.cc.Invoke(ctx, ProbeTaskService_ListProbeTasks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProbeTaskServiceServer is the server API for ProbeTaskService service.
 // All implementations must embed UnimplementedProbeTaskServiceServer
 // for forward compatibility.
@@ -58,6 +71,8 @@ func (c *probeTaskServiceClient) SendProbeTasks(ctx context.Context, in *ProbeTa
 type ProbeTaskServiceServer interface {
 	// 发起探测任务，返回任务执行的状态
 	SendProbeTasks(context.Context, *ProbeTaskRequest) (*ProbeTaskResponse, error)
+	// 列出数据面当前的探测任务，用于排查
+	ListProbeTasks(context.Context, *ListProbeTasksRequest) (*ListProbeTasksResponse, error)
 	mustEmbedUnimplementedProbeTaskServiceServer()
 }
 
@@ -71,6 +86,9 @@ type UnimplementedProbeTaskServiceServer struct{}
 func (UnimplementedProbeTaskServiceServer) SendProbeTasks(context.Context, *ProbeTaskRequest) (*ProbeTaskResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendProbeTasks not implemented")
 }
+func (UnimplementedProbeTaskServiceServer) ListProbeTasks(context.Context, *ListProbeTasksRequest) (*ListProbeTasksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProbeTasks not implemented")
+}
 func (UnimplementedProbeTaskServiceServer) mustEmbedUnimplementedProbeTaskServiceServer() {}
 func (UnimplementedProbeTaskServiceServer) testEmbeddedByValue()                          {}
 
@@ -110,6 +128,24 @@ func _ProbeTaskService_SendProbeTasks_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProbeTaskService_ListProbeTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProbeTasksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProbeTaskServiceServer).ListProbeTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProbeTaskService_ListProbeTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProbeTaskServiceServer).ListProbeTasks(ctx, req.(*ListProbeTasksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ProbeTaskService_ServiceDesc is the grpc.ServiceDesc for ProbeTaskService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -121,6 +157,10 @@ var ProbeTaskService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SendProbeTasks",
 			Handler:    _ProbeTaskService_SendProbeTasks_Handler,
 		},
+		{
+			MethodName: "ListProbeTasks",
+			Handler:    _ProbeTaskService_ListProbeTasks_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/probe.proto",
@@ -233,3 +273,111 @@ var ProbeResultService_ServiceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/probe.proto",
 }
+
+const (
+	RouteService_PushRoutes_FullMethodName = "/probe.RouteService/PushRoutes"
+)
+
+// RouteServiceClient is the client API for RouteService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 控制面向数据面下发路由表
+type RouteServiceClient interface {
+	// 下发路由表，数据面拒绝过期版本并返回实际生效的版本
+	PushRoutes(ctx context.Context, in *RouteTableRequest, opts ...grpc.CallOption) (*RouteTableResponse, error)
+}
+
+type routeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRouteServiceClient(cc grpc.ClientConnInterface) RouteServiceClient {
+	return &routeServiceClient{cc}
+}
+
+func (c *routeServiceClient) PushRoutes(ctx context.Context, in *RouteTableRequest, opts ...grpc.CallOption) (*RouteTableResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RouteTableResponse)
+	err := c.cc.Invoke(ctx, RouteService_PushRoutes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RouteServiceServer is the server API for RouteService service.
+// All implementations must embed UnimplementedRouteServiceServer
+// for forward compatibility.
+//
+// 控制面向数据面下发路由表
+type RouteServiceServer interface {
+	// 下发路由表，数据面拒绝过期版本并返回实际生效的版本
+	PushRoutes(context.Context, *RouteTableRequest) (*RouteTableResponse, error)
+	mustEmbedUnimplementedRouteServiceServer()
+}
+
+// UnimplementedRouteServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRouteServiceServer struct{}
+
+func (UnimplementedRouteServiceServer) PushRoutes(context.Context, *RouteTableRequest) (*RouteTableResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushRoutes not implemented")
+}
+func (UnimplementedRouteServiceServer) mustEmbedUnimplementedRouteServiceServer() {}
+func (UnimplementedRouteServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeRouteServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RouteServiceServer will
+// result in compilation errors.
+type UnsafeRouteServiceServer interface {
+	mustEmbedUnimplementedRouteServiceServer()
+}
+
+func RegisterRouteServiceServer(s grpc.ServiceRegistrar, srv RouteServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRouteServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RouteService_ServiceDesc, srv)
+}
+
+func _RouteService_PushRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RouteTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteServiceServer).PushRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteService_PushRoutes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteServiceServer).PushRoutes(ctx, req.(*RouteTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RouteService_ServiceDesc is the grpc.ServiceDesc for RouteService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RouteService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "probe.RouteService",
+	HandlerType: (*RouteServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PushRoutes",
+			Handler:    _RouteService_PushRoutes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/probe.proto",
+}
@@ -0,0 +1,82 @@
+package routing
+
+import (
+	"control/config"
+	"math"
+	"sort"
+)
+
+// 有向带权图，节点为 IP，边权为链路平均延迟
+type Graph struct {
+	index map[string]int    //IP 到节点下标的映射
+	nodes []string          //节点下标到 IP 的映射
+	edges []map[int]float64 //邻接表，edges[u][v] 为 u->v 的延迟
+}
+
+// 创建空图
+func NewGraph() *Graph {
+	return &Graph{index: make(map[string]int)}
+}
+
+// 丢包率达到该值的链路视为不通，不参与路由计算
+var MaxLinkLoss = 0.5
+
+// 根据 link_info 中的平均延迟和丢包率构建图
+// 丢包率达到 MaxLinkLoss 的链路直接丢弃，其余链路的边权为 延迟/(1-丢包率)，即考虑重传后的期望延迟
+func BuildGraph(links []config.LinkInfo) *Graph {
+	g := NewGraph()
+	for _, link := range links {
+		if link.Loss >= MaxLinkLoss || link.Loss >= 1 {
+			continue
+		}
+		delay := link.Delay
+		if link.Loss > 0 {
+			delay /= 1 - link.Loss
+		}
+		g.AddLink(link.SourceIP, link.DestinationIP, delay)
+	}
+	return g
+}
+
+// 添加节点，返回节点下标
+func (g *Graph) AddNode(ip string) int {
+	if i, ok := g.index[ip]; ok {
+		return i
+	}
+	g.index[ip] = len(g.nodes)
+	g.nodes = append(g.nodes, ip)
+	g.edges = append(g.edges, make(map[int]float64))
+	return len(g.nodes) - 1
+}
+
+// 添加一条有向链路，延迟非法（NaN、负数、无穷）的链路直接丢弃
+func (g *Graph) AddLink(src, dst string, delay float64) {
+	if src == dst || math.IsNaN(delay) || math.IsInf(delay, 0) || delay < 0 {
+		return
+	}
+	u := g.AddNode(src)
+	v := g.AddNode(dst)
+	g.edges[u][v] = delay
+}
+
+// 返回图中所有节点，按 IP 排序
+func (g *Graph) Nodes() []string {
+	nodes := make([]string, len(g.nodes))
+	copy(nodes, g.nodes)
+	sort.Strings(nodes)
+	return nodes
+}
+
+// 查询 src->dst 链路延迟
+func (g *Graph) Delay(src, dst string) (float64, bool) {
+	u, ok := g.index[src]
+	if !ok {
+		return 0, false
+	}
+	v, ok := g.index[dst]
+	if !ok {
+		return 0, false
+	}
+	delay, ok := g.edges[u][v]
+	return delay, ok
+}
@@ -0,0 +1,229 @@
+package routing
+
+import (
+	"math"
+)
+
+// 一条覆盖网络路径
+type Path struct {
+	Nodes []string //依次经过的节点，包含源节点和目的节点
+	Delay float64  //路径总延迟（不含惩罚），单位ms
+}
+
+// 路径跳数
+func (p Path) Hops() int {
+	return len(p.Nodes) - 1
+}
+
+// 有向边
+type edge struct {
+	u, v int
+}
+
+// 候选路径
+type candidate struct {
+	nodes []int
+	delay float64
+}
+
+// 按跳数分层松弛的 Bellman-Ford，求 src 到 dst 不超过 maxHops 跳的最短无环路径
+// bannedNodes、bannedEdges 为 Yen 算法中需要临时删除的节点和边
+func (g *Graph) shortestPath(src, dst, maxHops int, bannedNodes map[int]bool, bannedEdges map[edge]bool) ([]int, float64, bool) {
+	n := len(g.nodes)
+	dist := make([]float64, n)
+	paths := make([][]int, n)
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	dist[src] = 0
+	paths[src] = []int{src}
+
+	for h := 0; h < maxHops; h++ {
+		nextDist := make([]float64, n)
+		nextPaths := make([][]int, n)
+		copy(nextDist, dist)
+		copy(nextPaths, paths)
+		changed := false
+		for u := 0; u < n; u++ {
+			if math.IsInf(dist[u], 1) || bannedNodes[u] {
+				continue
+			}
+			for v := 0; v < n; v++ {
+				w, ok := g.edges[u][v]
+				if !ok || bannedNodes[v] || bannedEdges[edge{u, v}] || contains(paths[u], v) {
+					continue
+				}
+				if d := dist[u] + w; d < nextDist[v] {
+					nextDist[v] = d
+					nextPaths[v] = append(append(make([]int, 0, len(paths[u])+1), paths[u]...), v)
+					changed = true
+				}
+			}
+		}
+		dist, paths = nextDist, nextPaths
+		if !changed {
+			break
+		}
+	}
+	if math.IsInf(dist[dst], 1) {
+		return nil, 0, false
+	}
+	return paths[dst], dist[dst], true
+}
+
+// 基于 Yen 算法计算 src 到 dst 的至多 k 条无环路径
+// skip 为跳数限制（<=0 表示不限制），theta 为惩罚系数：
+// 每条链路每被已选路径使用一次，其权重在候选排序时放大 (1+theta) 倍，
+// 从而让后续路径尽量避开已选路径的公共链路
+func (g *Graph) KShortestPaths(src, dst string, k, skip int, theta float64) []Path {
+	s, ok := g.index[src]
+	if !ok {
+		return nil
+	}
+	t, ok := g.index[dst]
+	if !ok || s == t || k <= 0 {
+		return nil
+	}
+	maxHops := skip
+	if maxHops <= 0 || maxHops > len(g.nodes)-1 {
+		maxHops = len(g.nodes) - 1
+	}
+
+	first, delay, ok := g.shortestPath(s, t, maxHops, nil, nil)
+	if !ok {
+		return nil
+	}
+	accepted := []candidate{{nodes: first, delay: delay}}
+	usage := make(map[edge]int)
+	g.addUsage(usage, first)
+	var candidates []candidate
+
+	for len(accepted) < k {
+		last := accepted[len(accepted)-1].nodes
+		for i := 0; i < len(last)-1; i++ {
+			spurNode := last[i]
+			root := last[:i+1]
+
+			// 删除与已选路径共享同一前缀的下一条边
+			bannedEdges := make(map[edge]bool)
+			for _, p := range accepted {
+				if len(p.nodes) > i+1 && equalPrefix(p.nodes, root) {
+					bannedEdges[edge{p.nodes[i], p.nodes[i+1]}] = true
+				}
+			}
+			// 删除前缀上除偏离节点外的所有节点，保证无环
+			bannedNodes := make(map[int]bool)
+			for _, node := range root[:i] {
+				bannedNodes[node] = true
+			}
+
+			spur, spurDelay, ok := g.shortestPath(spurNode, t, maxHops-i, bannedNodes, bannedEdges)
+			if !ok {
+				continue
+			}
+			nodes := append(append(make([]int, 0, len(root)+len(spur)), root[:i]...), spur...)
+			c := candidate{nodes: nodes, delay: g.pathDelay(root) + spurDelay}
+			if !containsPath(accepted, c.nodes) && !containsPath(candidates, c.nodes) {
+				candidates = append(candidates, c)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		// 选出惩罚后代价最小的候选路径
+		best := 0
+		bestScore := g.penalizedDelay(candidates[0].nodes, usage, theta)
+		for i := 1; i < len(candidates); i++ {
+			score := g.penalizedDelay(candidates[i].nodes, usage, theta)
+			if score < bestScore || (score == bestScore && g.less(candidates[i], candidates[best])) {
+				best, bestScore = i, score
+			}
+		}
+		accepted = append(accepted, candidates[best])
+		g.addUsage(usage, candidates[best].nodes)
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+
+	paths := make([]Path, 0, len(accepted))
+	for _, c := range accepted {
+		names := make([]string, len(c.nodes))
+		for i, node := range c.nodes {
+			names[i] = g.nodes[node]
+		}
+		paths = append(paths, Path{Nodes: names, Delay: c.delay})
+	}
+	return paths
+}
+
+// 路径实际延迟
+func (g *Graph) pathDelay(nodes []int) float64 {
+	var delay float64
+	for i := 0; i < len(nodes)-1; i++ {
+		delay += g.edges[nodes[i]][nodes[i+1]]
+	}
+	return delay
+}
+
+// 路径惩罚后的延迟
+func (g *Graph) penalizedDelay(nodes []int, usage map[edge]int, theta float64) float64 {
+	var delay float64
+	for i := 0; i < len(nodes)-1; i++ {
+		e := edge{nodes[i], nodes[i+1]}
+		delay += g.edges[e.u][e.v] * math.Pow(1+theta, float64(usage[e]))
+	}
+	return delay
+}
+
+// 记录路径使用过的链路
+func (g *Graph) addUsage(usage map[edge]int, nodes []int) {
+	for i := 0; i < len(nodes)-1; i++ {
+		usage[edge{nodes[i], nodes[i+1]}]++
+	}
+}
+
+// 惩罚代价相同时，依次按实际延迟、跳数、节点 IP 排序，保证结果稳定
+func (g *Graph) less(a, b candidate) bool {
+	if a.delay != b.delay {
+		return a.delay < b.delay
+	}
+	if len(a.nodes) != len(b.nodes) {
+		return len(a.nodes) < len(b.nodes)
+	}
+	for i := range a.nodes {
+		if a.nodes[i] != b.nodes[i] {
+			return g.nodes[a.nodes[i]] < g.nodes[b.nodes[i]]
+		}
+	}
+	return false
+}
+
+func contains(nodes []int, node int) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+func equalPrefix(nodes, prefix []int) bool {
+	if len(nodes) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if nodes[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPath(list []candidate, nodes []int) bool {
+	for _, c := range list {
+		if len(c.nodes) == len(nodes) && equalPrefix(c.nodes, nodes) {
+			return true
+		}
+	}
+	return false
+}
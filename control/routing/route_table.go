@@ -0,0 +1,112 @@
+package routing
+
+import (
+	"control/storage"
+	"log"
+	"sync"
+	"time"
+)
+
+// 路由表：源 IP -> 目的 IP -> 至多 K 条路径（按选择顺序排列，第一条为最优路径）
+type RouteTable map[string]map[string][]Path
+
+// 为图中每一对节点计算 K 条路径
+func ComputeRoutes(g *Graph, k, skip int, theta float64) RouteTable {
+	table := make(RouteTable)
+	nodes := g.Nodes()
+	for _, src := range nodes {
+		for _, dst := range nodes {
+			if src == dst {
+				continue
+			}
+			paths := g.KShortestPaths(src, dst, k, skip, theta)
+			if len(paths) == 0 {
+				continue
+			}
+			if table[src] == nil {
+				table[src] = make(map[string][]Path)
+			}
+			table[src][dst] = paths
+		}
+	}
+	return table
+}
+
+// 路由计算引擎，保存最近一次的计算结果
+type Engine struct {
+	mu      sync.RWMutex
+	k       int     //路径数量
+	theta   float64 //惩罚系数
+	skip    int     //跳数限制
+	table   RouteTable
+	updated time.Time
+	version uint64 //路由表版本号，每次重新计算后递增
+}
+
+var (
+	engine *Engine
+	once   sync.Once
+)
+
+// 创建路由计算引擎
+func NewEngine(k int, theta float64, skip int) *Engine {
+	return &Engine{k: k, theta: theta, skip: skip, table: make(RouteTable)}
+}
+
+// 初始化全局路由计算引擎
+func InitEngine(k int, theta float64, skip int) {
+	once.Do(func() {
+		engine = NewEngine(k, theta, skip)
+	})
+}
+
+// 获取全局路由计算引擎，未初始化时返回 nil
+func GetEngine() *Engine {
+	return engine
+}
+
+// 更新路由计算参数，下一次重新计算时生效
+func (e *Engine) SetParams(k int, theta float64, skip int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.k, e.theta, e.skip = k, theta, skip
+}
+
+// 从链路统计存储读取最新的链路延迟，重新计算路由表
+func (e *Engine) Recompute(stats storage.LinkStatsStore) (RouteTable, error) {
+	links, err := stats.LatestLinkStats()
+	if err != nil {
+		return nil, err
+	}
+	e.mu.RLock()
+	k, theta, skip := e.k, e.theta, e.skip
+	e.mu.RUnlock()
+	table := ComputeRoutes(BuildGraph(links), k, skip, theta)
+
+	e.mu.Lock()
+	e.table = table
+	e.updated = time.Now()
+	// 以计算时间作为版本号，控制面重启后版本号依然单调递增
+	version := uint64(e.updated.UnixNano())
+	if version <= e.version {
+		version = e.version + 1
+	}
+	e.version = version
+	e.mu.Unlock()
+	log.Printf("Routes recomputed from %d links, %d sources", len(links), len(table))
+	return table, nil
+}
+
+// 查询 src 到 dst 的路径
+func (e *Engine) Routes(src, dst string) []Path {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.table[src][dst]
+}
+
+// 获取当前路由表及其版本号
+func (e *Engine) Table() (RouteTable, uint64) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.table, e.version
+}
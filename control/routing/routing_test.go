@@ -0,0 +1,119 @@
+package routing
+
+import (
+	"control/config"
+	"reflect"
+	"testing"
+)
+
+// 测试拓扑：
+//
+//	A -> B -> D  延迟 1 + 1
+//	A -> C -> D  延迟 2 + 2
+//	A -> D       延迟 10
+//	B -> C       延迟 0.5
+func testLinks() []config.LinkInfo {
+	return []config.LinkInfo{
+		{SourceIP: "A", DestinationIP: "B", Delay: 1},
+		{SourceIP: "B", DestinationIP: "D", Delay: 1},
+		{SourceIP: "A", DestinationIP: "C", Delay: 2},
+		{SourceIP: "C", DestinationIP: "D", Delay: 2},
+		{SourceIP: "A", DestinationIP: "D", Delay: 10},
+		{SourceIP: "B", DestinationIP: "C", Delay: 0.5},
+	}
+}
+
+func nodesOf(paths []Path) [][]string {
+	var nodes [][]string
+	for _, p := range paths {
+		nodes = append(nodes, p.Nodes)
+	}
+	return nodes
+}
+
+// 测试 K 条最短路径按延迟排序
+func TestKShortestPaths(t *testing.T) {
+	g := BuildGraph(testLinks())
+	paths := g.KShortestPaths("A", "D", 4, 0, 0)
+	want := [][]string{
+		{"A", "B", "D"},
+		{"A", "B", "C", "D"},
+		{"A", "C", "D"},
+		{"A", "D"},
+	}
+	if got := nodesOf(paths); !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	if paths[0].Delay != 2 || paths[1].Delay != 3.5 || paths[3].Delay != 10 {
+		t.Errorf("unexpected delays: %v", paths)
+	}
+}
+
+// 测试跳数限制
+func TestKShortestPathsSkip(t *testing.T) {
+	g := BuildGraph(testLinks())
+	for _, p := range g.KShortestPaths("A", "D", 4, 2, 0) {
+		if p.Hops() > 2 {
+			t.Errorf("path %v exceeds hop limit", p.Nodes)
+		}
+	}
+	paths := g.KShortestPaths("A", "D", 1, 1, 0)
+	if got := nodesOf(paths); !reflect.DeepEqual(got, [][]string{{"A", "D"}}) {
+		t.Errorf("paths = %v, want direct link", got)
+	}
+}
+
+// 测试惩罚系数让第二条路径避开已选路径的公共链路
+func TestKShortestPathsTheta(t *testing.T) {
+	g := BuildGraph(testLinks())
+	// 不惩罚时第二条路径复用 A->B
+	if got := g.KShortestPaths("A", "D", 2, 0, 0)[1].Nodes; !reflect.DeepEqual(got, []string{"A", "B", "C", "D"}) {
+		t.Fatalf("second path without penalty = %v", got)
+	}
+	// A->B 被惩罚后，A->B->C->D 代价为 1*3+0.5+2=5.5，A->C->D 为 4
+	if got := g.KShortestPaths("A", "D", 2, 0, 2)[1].Nodes; !reflect.DeepEqual(got, []string{"A", "C", "D"}) {
+		t.Fatalf("second path with penalty = %v", got)
+	}
+}
+
+// 测试非法延迟与不可达节点
+func TestKShortestPathsUnreachable(t *testing.T) {
+	g := NewGraph()
+	g.AddLink("A", "B", 1)
+	g.AddLink("B", "A", -1)
+	if paths := g.KShortestPaths("B", "A", 3, 0, 0); paths != nil {
+		t.Errorf("expected no path, got %v", paths)
+	}
+	if paths := g.KShortestPaths("A", "X", 3, 0, 0); paths != nil {
+		t.Errorf("expected no path to unknown node, got %v", paths)
+	}
+}
+
+// 测试整张路由表
+func TestComputeRoutes(t *testing.T) {
+	table := ComputeRoutes(BuildGraph(testLinks()), 2, 3, 0.1)
+	if len(table["A"]["D"]) != 2 {
+		t.Errorf("expected 2 paths A->D, got %v", table["A"]["D"])
+	}
+	if _, ok := table["D"]; ok {
+		t.Errorf("D has no outgoing links, got %v", table["D"])
+	}
+}
+
+// 测试丢包链路的边权加权，以及不通的链路被排除在路由之外
+func TestBuildGraphLoss(t *testing.T) {
+	links := testLinks()
+	links[0].Loss = 0.2 // A->B 延迟按 1/(1-0.2) 计
+	links[1].Loss = 1   // B->D 不通
+	g := BuildGraph(links)
+	if d, ok := g.Delay("A", "B"); !ok || d != 1.25 {
+		t.Fatalf("unexpected A->B delay: %v %v", d, ok)
+	}
+	if _, ok := g.Delay("B", "D"); ok {
+		t.Fatal("dead link B->D should be excluded")
+	}
+	paths := g.KShortestPaths("A", "D", 1, 3, 0)
+	if len(paths) != 1 || !reflect.DeepEqual(paths[0].Nodes, []string{"A", "B", "C", "D"}) {
+		t.Fatalf("unexpected shortest path: %v", nodesOf(paths))
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"control/pki"
+	pb "control/proto"
+	"control/storage"
+	"errors"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 请求没有客户端证书时，只有配置未启用 TLS 才放行
+func checkNoPeer() error {
+	if pki.Enabled() {
+		return status.Error(codes.Unauthenticated, "client certificate required")
+	}
+	return nil
+}
+
+// 校验请求中的节点 ID 与客户端证书绑定的节点一致，未启用 TLS 时不校验
+func checkNodeID(ctx context.Context, nodeID string) error {
+	id, ok := pki.PeerNodeID(ctx)
+	if !ok {
+		return checkNoPeer()
+	}
+	if nodeID != id {
+		return status.Errorf(codes.PermissionDenied, "node %s is not allowed to act as node %q", id, nodeID)
+	}
+	return nil
+}
+
+// 校验探测结果的源节点和源地址属于客户端证书绑定的节点，未启用 TLS 时不校验
+func checkProbeResults(ctx context.Context, nodes storage.NodeStore, results []*pb.ProbeResult) error {
+	id, ok := pki.PeerNodeID(ctx)
+	if !ok {
+		return checkNoPeer()
+	}
+	node, err := nodes.Node(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return status.Errorf(codes.PermissionDenied, "node %s is not registered", id)
+	}
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if result.NodeId1 != id {
+			return status.Errorf(codes.PermissionDenied, "node %s is not allowed to report results of node %q", id, result.NodeId1)
+		}
+		if result.Ip1 != node.Address && !slices.Contains(node.Addresses, result.Ip1) {
+			return status.Errorf(codes.PermissionDenied, "source IP %s of probe result does not belong to node %s", result.Ip1, id)
+		}
+	}
+	return nil
+}
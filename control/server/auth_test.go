@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"control/pki"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// 测试请求中的节点 ID 必须与客户端证书一致，未启用 TLS 时不校验，启用后拒绝没有证书的请求
+func TestCheckNodeID(t *testing.T) {
+	if err := checkNodeID(context.Background(), "node-1"); err != nil {
+		t.Fatalf("expected plaintext request to pass, got %v", err)
+	}
+	if err := checkProbeResults(context.Background(), memNodeStore{}, nil); err != nil {
+		t.Fatalf("expected plaintext probe results to pass, got %v", err)
+	}
+	if err := pki.Init(t.TempDir()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Cleanup(pki.Reset)
+	if err := checkNodeID(context.Background(), "node-1"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected request without certificate to be rejected, got %v", err)
+	}
+	if err := checkProbeResults(context.Background(), memNodeStore{}, nil); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected probe results without certificate to be rejected, got %v", err)
+	}
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "node-1"}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+	if err := checkNodeID(ctx, "node-1"); err != nil {
+		t.Fatalf("expected node-1 to pass, got %v", err)
+	}
+	if err := checkNodeID(ctx, "node-2"); err == nil {
+		t.Fatal("expected node-1 acting as node-2 to be rejected")
+	}
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	pb "control/proto"
+	"control/storage"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 每个长连接待下发消息的队列长度
+const sessionQueueSize = 64
+
+// 数据面长连接会话
+type session struct {
+	nodeID string
+	send   chan *pb.ControlMessage
+	cancel context.CancelFunc
+}
+
+// 当前在线的长连接会话，按节点 ID 索引
+var (
+	sessions     = make(map[string]*session)
+	sessionMutex sync.RWMutex
+)
+
+// 长连接服务结构体重写，数据面上报的指标和探测结果交给对应的服务处理
+type Channel struct {
+	pb.UnimplementedChannelServiceServer
+	metrics     *Server
+	probe       *Probe
+	nodes       storage.NodeStore           //节点存储，只有已注册的节点可以建立长连接
+	generations storage.TaskGenerationStore //任务表版本号存储，数据面回复 stale 时分配新的版本号
+	done        <-chan struct{}             //关闭后断开所有长连接，否则 GracefulStop 会一直等待
+}
+
+// 长连接方法实现，第一条消息必须是 hello，之后持续收发直到任一方断开
+func (c *Channel) Connect(stream pb.ChannelService_ConnectServer) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	hello := msg.GetHello()
+	if hello == nil || hello.NodeId == "" {
+		return fmt.Errorf("first message must be hello with node ID")
+	}
+	if err := checkNodeID(stream.Context(), hello.NodeId); err != nil {
+		return err
+	}
+	// 未注册或已删除的节点不能接收探测任务和路由表
+	if _, err := c.nodes.Node(hello.NodeId); errors.Is(err, storage.ErrNotFound) {
+		return status.Errorf(codes.PermissionDenied, "node %s is not registered", hello.NodeId)
+	} else if err != nil {
+		return err
+	}
+	// 发送响应头告知数据面已接受 hello，数据面收到后才开始发送队列中的消息
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	s := &session{nodeID: hello.NodeId, send: make(chan *pb.ControlMessage, sessionQueueSize), cancel: cancel}
+	addSession(s)
+	defer removeSession(s)
+	log.Printf("Channel established with node %s", s.nodeID)
+
+	// 单独的协程负责下发，gRPC 流不允许并发发送
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-s.send:
+				if err := stream.Send(msg); err != nil {
+					log.Printf("Failed to send message to node %s: %v", s.nodeID, err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	// 处理数据面上报的消息，流断开或会话被新连接替换时返回
+	recv := make(chan *pb.AgentMessage)
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case recv <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Channel with node %s closed", s.nodeID)
+			return nil
+		case <-c.done:
+			log.Printf("Control plane shutting down, closing channel with node %s", s.nodeID)
+			return nil
+		case err := <-errc:
+			log.Printf("Channel with node %s closed: %v", s.nodeID, err)
+			return nil
+		case msg := <-recv:
+			c.handle(ctx, s.nodeID, msg)
+		}
+	}
+}
+
+// 处理数据面经由长连接上报的一条消息
+func (c *Channel) handle(ctx context.Context, nodeID string, msg *pb.AgentMessage) {
+	switch payload := msg.Payload.(type) {
+	case *pb.AgentMessage_Metrics:
+		if _, err := c.metrics.SendMetrics(ctx, payload.Metrics); err != nil {
+			log.Printf("Failed to store metrics from node %s: %v", nodeID, err)
+		}
+	case *pb.AgentMessage_ProbeResults:
+		if _, err := c.probe.SendProbeResults(ctx, payload.ProbeResults); err != nil {
+			log.Printf("Failed to store probe results from node %s: %v", nodeID, err)
+		}
+	case *pb.AgentMessage_RouteAck:
+		if payload.RouteAck.Status != "ok" {
+			log.Printf("Route table rejected by %s, applied version: %d", nodeID, payload.RouteAck.AppliedVersion)
+		} else {
+			log.Printf("Route table version %d applied by %s", payload.RouteAck.AppliedVersion, nodeID)
+		}
+	case *pb.AgentMessage_TaskAck:
+		logTaskAck(nodeID, payload.TaskAck)
+		if retry := resendStaleTasks(c.generations, nodeID, payload.TaskAck); retry != nil {
+			sendToNode(nodeID, &pb.ControlMessage{Payload: &pb.ControlMessage_ProbeTasks{ProbeTasks: retry}})
+		}
+	default:
+		log.Printf("Unexpected message from node %s: %T", nodeID, msg.Payload)
+	}
+}
+
+// 登记会话，同一节点重复连接时断开旧的会话
+func addSession(s *session) {
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+	if old, ok := sessions[s.nodeID]; ok {
+		old.cancel()
+	}
+	sessions[s.nodeID] = s
+}
+
+// 注销会话，会话已被新连接替换时不做处理
+func removeSession(s *session) {
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+	if sessions[s.nodeID] == s {
+		delete(sessions, s.nodeID)
+	}
+}
+
+// 节点当前是否通过长连接在线
+func hasSession(nodeID string) bool {
+	sessionMutex.RLock()
+	defer sessionMutex.RUnlock()
+	_, ok := sessions[nodeID]
+	return ok
+}
+
+// 经由长连接向节点下发消息，节点不在线或队列已满时返回 false
+func sendToNode(nodeID string, msg *pb.ControlMessage) bool {
+	sessionMutex.RLock()
+	s, ok := sessions[nodeID]
+	sessionMutex.RUnlock()
+	if !ok {
+		return false
+	}
+	select {
+	case s.send <- msg:
+		return true
+	default:
+		log.Printf("Send queue of node %s is full, message dropped", nodeID)
+		return false
+	}
+}
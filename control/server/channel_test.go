@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	pb "control/proto"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// 测试长连接的握手、经由长连接下发消息，以及控制面关闭时断开长连接
+func TestChannel(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	done := make(chan struct{})
+	server := grpc.NewServer()
+	nodes := memNodeStore{"node-1": {ID: "node-1"}}
+	pb.RegisterChannelServiceServer(server, &Channel{nodes: nodes, done: done})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 第一条消息不是 hello 时拒绝连接
+	stream, err := pb.NewChannelServiceClient(conn).Connect(ctx)
+	if err != nil {
+		t.Fatalf("Failed to open channel: %v", err)
+	}
+	stream.Send(&pb.AgentMessage{Payload: &pb.AgentMessage_Metrics{Metrics: &pb.Metrics{}}})
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("expected channel without hello to be rejected")
+	}
+
+	// 未注册的节点不能建立长连接
+	stream, err = pb.NewChannelServiceClient(conn).Connect(ctx)
+	if err != nil {
+		t.Fatalf("Failed to open channel: %v", err)
+	}
+	stream.Send(&pb.AgentMessage{Payload: &pb.AgentMessage_Hello{Hello: &pb.Hello{NodeId: "node-2"}}})
+	if _, err := stream.Recv(); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected unregistered node to be rejected, got %v", err)
+	}
+
+	stream, err = pb.NewChannelServiceClient(conn).Connect(ctx)
+	if err != nil {
+		t.Fatalf("Failed to open channel: %v", err)
+	}
+	if err := stream.Send(&pb.AgentMessage{Payload: &pb.AgentMessage_Hello{Hello: &pb.Hello{NodeId: "node-1"}}}); err != nil {
+		t.Fatalf("Failed to send hello: %v", err)
+	}
+	for !hasSession("node-1") {
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for session")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if sendToNode("node-2", &pb.ControlMessage{}) {
+		t.Fatal("sending to a node without channel should fail")
+	}
+	msg := &pb.ControlMessage{Payload: &pb.ControlMessage_Routes{Routes: &pb.RouteTableRequest{Version: 3}}}
+	if !sendToNode("node-1", msg) {
+		t.Fatal("failed to queue message for node-1")
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive: %v", err)
+	}
+	if resp.GetRoutes().GetVersion() != 3 {
+		t.Fatalf("unexpected message: %v", resp)
+	}
+
+	// 控制面关闭时断开长连接并注销会话
+	close(done)
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("expected channel to be closed on shutdown")
+	}
+	for hasSession("node-1") {
+		select {
+		case <-ctx.Done():
+			t.Fatal("session not removed after shutdown")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"control/config"
+	"control/models"
+	"control/storage"
+	"log"
+	"time"
+)
+
+// 链路历史各层的保留时长，为 0 时不清理
+type historyRetention struct {
+	raw, minute, hour time.Duration
+}
+
+func newHistoryRetention(c config.ConfigInfo) historyRetention {
+	return historyRetention{raw: c.RawRetention, minute: c.MinuteRetention, hour: c.HourRetention}
+}
+
+// 把在线节点之间每条链路在 [bucket, bucket+1m) 内收到的探测样本汇总为 1 分钟聚合并保存
+// 样本存储中每条链路最多保留 capacity 个样本
+func rollupMinute(store *storage.Store, nodes []config.NodeInfo, bucket time.Time, capacity int) error {
+	var rollups []config.LinkRollup
+	for _, src := range nodes {
+		for _, dst := range nodes {
+			if src.ID == dst.ID {
+				continue
+			}
+			results, err := store.LinkSamples.Samples(src.ID, dst.ID, capacity)
+			if err != nil {
+				log.Printf("Failed to read samples of link %s:%s: %v", src.ID, dst.ID, err)
+				continue
+			}
+			if rollup, ok := models.RollupSamples(src.ID, dst.ID, bucket, time.Minute, results); ok {
+				rollups = append(rollups, rollup)
+			}
+		}
+	}
+	if len(rollups) == 0 {
+		return nil
+	}
+	return store.History.SaveRollups(storage.TierMinute, rollups)
+}
+
+// 把 [bucket, bucket+1h) 内的 1 分钟聚合按链路合并为 1 小时聚合并保存
+func rollupHour(history storage.LinkHistoryStore, bucket time.Time) error {
+	minutes, err := history.Rollups(storage.TierMinute, bucket, bucket.Add(time.Hour))
+	if err != nil {
+		return err
+	}
+	byLink := map[string][]config.LinkRollup{}
+	var links []string
+	for _, r := range minutes {
+		key := r.SourceIP + ":" + r.DestinationIP
+		if _, ok := byLink[key]; !ok {
+			links = append(links, key)
+		}
+		byLink[key] = append(byLink[key], r)
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	rollups := make([]config.LinkRollup, 0, len(links))
+	for _, key := range links {
+		rollups = append(rollups, models.MergeRollups(bucket, byLink[key]))
+	}
+	return history.SaveRollups(storage.TierHour, rollups)
+}
+
+// 按保留时长清理链路历史各层的过期数据
+func pruneHistory(history storage.LinkHistoryStore, retention historyRetention, now time.Time) {
+	tiers := []struct {
+		tier      string
+		retention time.Duration
+	}{
+		{storage.TierRaw, retention.raw},
+		{storage.TierMinute, retention.minute},
+		{storage.TierHour, retention.hour},
+	}
+	for _, t := range tiers {
+		if t.retention == 0 {
+			continue
+		}
+		n, err := history.Prune(t.tier, now.Add(-t.retention))
+		if err != nil {
+			log.Printf("Failed to prune %s link history: %v", t.tier, err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("Pruned %d %s link history rows older than %v", n, t.tier, t.retention)
+		}
+	}
+}
+
+// 维护链路历史：每分钟把上一分钟的探测样本汇总为 1 分钟聚合，每小时把上一小时的 1 分钟聚合合并为 1 小时聚合，
+// 并按保留时长清理各层数据。updates 收到重新加载的配置后调整保留时长、节点超时和汇总的样本数，阻塞直到 ctx 取消
+func StartLinkHistory(ctx context.Context, store *storage.Store, c config.ConfigInfo, updates <-chan config.ConfigInfo) {
+	retention := newHistoryRetention(c)
+	nodeTimeout := c.NodeTimeout
+	sampleCapacity := c.SampleCapacity
+
+	// 启动时补齐上一小时的聚合并清理一次
+	now := time.Now().UTC()
+	if err := rollupHour(store.History, now.Truncate(time.Hour).Add(-time.Hour)); err != nil {
+		log.Printf("Failed to roll up link history: %v", err)
+	}
+	pruneHistory(store.History, retention, now)
+
+	// 对齐到整分钟
+	next := now.Truncate(time.Minute).Add(time.Minute)
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping link history...")
+			return
+		case c := <-updates:
+			retention = newHistoryRetention(c)
+			nodeTimeout = c.NodeTimeout
+			sampleCapacity = c.SampleCapacity
+		case <-timer.C:
+			bucket := next.Add(-time.Minute)
+			nodes, err := aliveNodes(store.Nodes, nodeTimeout)
+			if err != nil {
+				log.Printf("Failed to query alive nodes: %v", err)
+			} else if err := rollupMinute(store, nodes, bucket, sampleCapacity); err != nil {
+				log.Printf("Failed to roll up link history: %v", err)
+			}
+			// 整点时合并上一小时的聚合并清理过期数据
+			if next.Truncate(time.Hour).Equal(next) {
+				if err := rollupHour(store.History, next.Add(-time.Hour)); err != nil {
+					log.Printf("Failed to roll up link history: %v", err)
+				}
+				pruneHistory(store.History, retention, next)
+			}
+			next = next.Add(time.Minute)
+			// 处理耗时超过一分钟时跳过错过的区间
+			if now := time.Now(); !next.After(now) {
+				next = now.UTC().Truncate(time.Minute).Add(time.Minute)
+			}
+			timer.Reset(time.Until(next))
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"control/config"
+	"control/pki"
+	pb "control/proto"
+	"control/storage"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 生成加入令牌并保存到数据库，令牌格式为 <id>.<secret>，数据库中只保存 secret 的哈希
+// nodeID 非空时令牌绑定该节点 ID，只能供已加入的该节点重新申请证书，例如证书过期或重新安装后
+func NewJoinToken(tokens storage.JoinTokenStore, ttl time.Duration, nodeID string) (string, time.Time, error) {
+	id, err := randomHex(6)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	secret, err := randomHex(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(ttl)
+	if err := tokens.InsertJoinToken(id, hashSecret(secret), expiresAt, nodeID); err != nil {
+		return "", time.Time{}, err
+	}
+	return id + "." + secret, expiresAt, nil
+}
+
+// 拆分加入令牌，返回令牌 ID 和 secret 的哈希
+func parseJoinToken(token string) (string, string, error) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || id == "" || secret == "" {
+		return "", "", fmt.Errorf("malformed join token")
+	}
+	return id, hashSecret(secret), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// 下发给加入节点的配置
+func newAgentConfig(c config.ConfigInfo) *pb.AgentConfig {
+	return &pb.AgentConfig{
+		ControlAddr:       net.JoinHostPort(c.AdvertiseHost, c.DetectPort),
+		MetricsAddr:       net.JoinHostPort(c.AdvertiseHost, c.ReceivePort),
+		HeartbeatInterval: int64(c.HeartbeatInterval / time.Second),
+	}
+}
+
+// 校验加入的节点 ID 既未注册，也没有使用过加入令牌
+func (n *Node) checkNewNodeID(nodeID string) error {
+	_, err := n.nodes.Node(nodeID)
+	if err == nil {
+		return status.Errorf(codes.AlreadyExists, "node %s is already registered, rejoin with a token created by control token --node %s", nodeID, nodeID)
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Failed to look up node %s: %v", nodeID, err)
+		return status.Error(codes.Internal, "failed to look up node")
+	}
+	used, err := n.tokens.JoinTokenUsedBy(nodeID)
+	if err != nil {
+		log.Printf("Failed to look up join tokens of node %s: %v", nodeID, err)
+		return status.Error(codes.Internal, "failed to look up node")
+	}
+	if used {
+		return status.Errorf(codes.AlreadyExists, "node %s has already joined, rejoin with a token created by control token --node %s", nodeID, nodeID)
+	}
+	return nil
+}
+
+// 使用未绑定节点的加入令牌为新的节点 ID 加入
+func (n *Node) consumeJoinToken(id, secretHash, nodeID string, now time.Time) error {
+	// 已注册或已加入的节点 ID 不再签发证书，否则任一令牌都可以冒充已有节点
+	if err := n.checkNewNodeID(nodeID); err != nil {
+		return err
+	}
+	ok, err := n.tokens.ConsumeJoinToken(id, secretHash, nodeID, now)
+	if err != nil {
+		// 同一节点 ID 同时使用另一个令牌加入成功后，唯一索引拒绝本次标记
+		if err := n.checkNewNodeID(nodeID); err != nil {
+			return err
+		}
+		log.Printf("Failed to consume join token %s: %v", id, err)
+		return status.Error(codes.Internal, "failed to verify join token")
+	}
+	if !ok {
+		log.Printf("Node %s rejected: join token %s is invalid, expired or already used", nodeID, id)
+		return status.Error(codes.Unauthenticated, "join token is invalid, expired or already used")
+	}
+	return nil
+}
+
+// 节点加入方法实现，用一次性令牌换取绑定节点 ID 的证书和节点配置
+func (n *Node) Join(ctx context.Context, req *pb.JoinRequest) (*pb.JoinResponse, error) {
+	ca := pki.GetCA()
+	if ca == nil {
+		return nil, status.Error(codes.FailedPrecondition, "TLS is disabled on the control plane, nodes need no certificate")
+	}
+	if req.NodeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "node ID is required")
+	}
+	id, secretHash, err := parseJoinToken(req.Token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	// 先校验证书签名请求，格式错误的请求不消耗令牌
+	certPEM, err := ca.SignCSR(req.NodeId, req.Csr)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to sign certificate request: %v", err)
+	}
+	now := time.Now()
+	// 绑定节点 ID 的令牌供已加入的节点重新申请证书
+	rejoined, err := n.tokens.ConsumeRejoinToken(id, secretHash, req.NodeId, now)
+	if err != nil {
+		log.Printf("Failed to consume join token %s: %v", id, err)
+		return nil, status.Error(codes.Internal, "failed to verify join token")
+	}
+	if rejoined {
+		log.Printf("Node %s rejoined with token %s", req.NodeId, id)
+	} else {
+		if err := n.consumeJoinToken(id, secretHash, req.NodeId, now); err != nil {
+			return nil, err
+		}
+		log.Printf("Node %s joined with token %s", req.NodeId, id)
+	}
+	return &pb.JoinResponse{
+		NodeId:        req.NodeId,
+		Certificate:   certPEM,
+		CaCertificate: ca.CertPEM,
+		Config:        n.agentConfig,
+	}, nil
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"control/config"
+	"control/pki"
+	pb "control/proto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 测试加入令牌的解析，令牌由 ID 和 secret 两部分组成
+func TestParseJoinToken(t *testing.T) {
+	id, hash, err := parseJoinToken("abc.secret")
+	if err != nil || id != "abc" || hash != hashSecret("secret") {
+		t.Fatalf("unexpected parse result: %q, %q, %v", id, hash, err)
+	}
+	for _, token := range []string{"", "abc", "abc.", ".secret"} {
+		if _, _, err := parseJoinToken(token); err == nil {
+			t.Fatalf("expected %q to be rejected", token)
+		}
+	}
+}
+
+// 测试下发给加入节点的配置，未配置通告主机时地址只包含端口
+func TestNewAgentConfig(t *testing.T) {
+	c := config.ConfigInfo{ReceivePort: "8080", DetectPort: "8081", HeartbeatInterval: 10 * time.Second}
+	cfg := newAgentConfig(c)
+	if cfg.ControlAddr != ":8081" || cfg.MetricsAddr != ":8080" || cfg.HeartbeatInterval != 10 {
+		t.Fatalf("unexpected agent config: %v", cfg)
+	}
+	c.AdvertiseHost = "control.example.com"
+	if cfg := newAgentConfig(c); cfg.ControlAddr != "control.example.com:8081" {
+		t.Fatalf("unexpected control address: %s", cfg.ControlAddr)
+	}
+}
+
+// 测试加入请求在使用令牌之前被拒绝的情况，这些情况不访问数据库
+func TestJoinRejected(t *testing.T) {
+	n := &Node{}
+	req := &pb.JoinRequest{Token: "abc.secret", NodeId: "node-1", Csr: []byte("garbage")}
+	if _, err := n.Join(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected join to fail without CA, got %v", err)
+	}
+
+	if err := pki.Init(t.TempDir()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Cleanup(pki.Reset)
+	cases := []struct {
+		req  *pb.JoinRequest
+		code codes.Code
+	}{
+		{&pb.JoinRequest{Token: "abc.secret", Csr: req.Csr}, codes.InvalidArgument},
+		{&pb.JoinRequest{Token: "malformed", NodeId: "node-1", Csr: req.Csr}, codes.Unauthenticated},
+		{req, codes.InvalidArgument},
+		{&pb.JoinRequest{Token: "abc.secret", NodeId: pki.ControlCommonName, Csr: req.Csr}, codes.InvalidArgument},
+	}
+	for _, c := range cases {
+		if _, err := n.Join(context.Background(), c.req); status.Code(err) != c.code {
+			t.Fatalf("Join(%v): expected %v, got %v", c.req, c.code, err)
+		}
+	}
+}
+
+// 内存中的加入令牌
+type memToken struct {
+	secretHash string
+	bound      string //绑定的节点 ID
+	nodeID     string //使用未绑定令牌加入的节点 ID
+	used       bool
+}
+
+// 内存中的加入令牌存储，按令牌 ID 索引
+type memTokenStore map[string]*memToken
+
+func (m memTokenStore) InsertJoinToken(id, secretHash string, expiresAt time.Time, boundNodeID string) error {
+	m[id] = &memToken{secretHash: secretHash, bound: boundNodeID}
+	return nil
+}
+
+func (m memTokenStore) ConsumeJoinToken(id, secretHash, nodeID string, now time.Time) (bool, error) {
+	token, ok := m[id]
+	if !ok || token.secretHash != secretHash || token.used || token.bound != "" {
+		return false, nil
+	}
+	if used, _ := m.JoinTokenUsedBy(nodeID); used {
+		return false, fmt.Errorf("duplicate node ID %s", nodeID)
+	}
+	token.nodeID, token.used = nodeID, true
+	return true, nil
+}
+
+func (m memTokenStore) ConsumeRejoinToken(id, secretHash, nodeID string, now time.Time) (bool, error) {
+	token, ok := m[id]
+	if !ok || token.secretHash != secretHash || token.used || token.bound != nodeID {
+		return false, nil
+	}
+	token.used = true
+	return true, nil
+}
+
+func (m memTokenStore) JoinTokenUsedBy(nodeID string) (bool, error) {
+	for _, token := range m {
+		if token.nodeID == nodeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// 测试未绑定的加入令牌只能为新的节点 ID 签发证书，绑定节点 ID 的令牌只能供该节点重新加入
+func TestJoinNodeID(t *testing.T) {
+	if err := pki.Init(t.TempDir()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Cleanup(pki.Reset)
+	tokens := memTokenStore{}
+	n := &Node{nodes: memNodeStore{"node-1": {ID: "node-1"}}, tokens: tokens}
+	join := func(nodeID, bound string) error {
+		token, _, err := NewJoinToken(tokens, time.Hour, bound)
+		if err != nil {
+			t.Fatalf("NewJoinToken failed: %v", err)
+		}
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		der, _ := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+		csr := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+		_, err = n.Join(context.Background(), &pb.JoinRequest{Token: token, NodeId: nodeID, Csr: csr})
+		return err
+	}
+
+	if err := join("node-1", ""); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected registered node to be rejected, got %v", err)
+	}
+	if err := join("node-2", ""); err != nil {
+		t.Fatalf("expected new node to join, got %v", err)
+	}
+	if err := join("node-2", ""); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected joined node to be rejected, got %v", err)
+	}
+	for _, nodeID := range []string{"node-1", "node-2"} {
+		if err := join(nodeID, nodeID); err != nil {
+			t.Fatalf("expected %s to rejoin with a bound token, got %v", nodeID, err)
+		}
+	}
+	if err := join("node-3", "node-2"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected a token bound to another node to be rejected, got %v", err)
+	}
+}
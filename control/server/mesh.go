@@ -0,0 +1,151 @@
+package server
+
+import (
+	"control/config"
+	"control/pool"
+	"control/routing"
+	"control/storage"
+	"hash/fnv"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// 探测对端的选择策略
+const (
+	meshFull      = "full"      //探测全部在线节点
+	meshNearest   = "nearest"   //探测延迟最低的若干对端和随机抽取的对端
+	meshLandmarks = "landmarks" //地标节点探测全部节点，其余节点探测地标节点和随机抽取的对端
+)
+
+// 探测计划，决定每个周期每个节点探测哪些对端
+type meshPlan struct {
+	strategy  string
+	nearest   int             //nearest 策略中延迟最低的对端数
+	random    int             //每个周期额外随机抽取的对端数
+	landmarks map[string]bool //地标节点 ID
+	spread    bool            //把探测均匀分散到探测周期内
+}
+
+// 当前的探测计划，由 StartProbeScheduler 根据配置设置，配置重新加载后更新
+var probePlan atomic.Pointer[meshPlan]
+
+// 抽取随机对端使用的随机数，只在调度协程中使用
+var meshRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+func init() {
+	probePlan.Store(&meshPlan{strategy: meshFull})
+}
+
+func newMeshPlan(c config.ConfigInfo) *meshPlan {
+	plan := &meshPlan{
+		strategy:  c.ProbeStrategy,
+		nearest:   c.ProbeNearest,
+		random:    c.ProbeRandom,
+		landmarks: map[string]bool{},
+		spread:    c.ProbeSpread,
+	}
+	for _, id := range c.ProbeLandmarks {
+		plan.landmarks[id] = true
+	}
+	return plan
+}
+
+// 选出 node 本周期要探测的对端，delays 为可用链路的最新延迟，键为 src:dst
+func (p *meshPlan) peers(node config.NodeInfo, nodes []config.NodeInfo, delays map[string]float64, rng *rand.Rand) []config.NodeInfo {
+	var others []config.NodeInfo
+	for _, peer := range nodes {
+		if peer.ID != node.ID { // 避免自己探测自己
+			others = append(others, peer)
+		}
+	}
+	var chosen, rest []config.NodeInfo
+	switch p.strategy {
+	case meshNearest:
+		// 没有可用统计的对端随机排在最后，节点刚加入时同样能探测到 nearest 个对端
+		rng.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+		delay := func(peer config.NodeInfo) float64 {
+			if d, ok := delays[node.ID+":"+peer.ID]; ok {
+				return d
+			}
+			return math.Inf(1)
+		}
+		sort.SliceStable(others, func(i, j int) bool { return delay(others[i]) < delay(others[j]) })
+		n := min(p.nearest, len(others))
+		chosen, rest = others[:n], others[n:]
+	case meshLandmarks:
+		if p.landmarks[node.ID] {
+			return others
+		}
+		for _, peer := range others {
+			if p.landmarks[peer.ID] {
+				chosen = append(chosen, peer)
+			} else {
+				rest = append(rest, peer)
+			}
+		}
+	default:
+		return others
+	}
+	// 其余对端每个周期重新抽取，逐步覆盖全部链路
+	rest = append([]config.NodeInfo(nil), rest...)
+	rng.Shuffle(len(rest), func(i, j int) { rest[i], rest[j] = rest[j], rest[i] })
+	return append(chosen, rest[:min(p.random, len(rest))]...)
+}
+
+// 可用链路的最新延迟，键为 src:dst，丢包率达到 routing.MaxLinkLoss 的链路不计入
+func linkDelays(stats storage.LinkStatsStore) (map[string]float64, error) {
+	links, err := stats.LatestLinkStats()
+	if err != nil {
+		return nil, err
+	}
+	delays := make(map[string]float64, len(links))
+	for _, link := range links {
+		if link.Loss < routing.MaxLinkLoss {
+			delays[link.SourceIP+":"+link.DestinationIP] = link.Delay
+		}
+	}
+	return delays, nil
+}
+
+// node 的探测在周期内的起始位置，由节点 ID 决定，不同节点的探测相互错开
+func nodePhase(id string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return float64(h.Sum32()) / (1 << 32)
+}
+
+// 第 i 个（共 n 个）探测任务在周期内的开始位置，0 到 1 之间
+func taskPhase(id string, i, n int) float64 {
+	phase := nodePhase(id) + float64(i)/float64(n)
+	return phase - math.Floor(phase)
+}
+
+// 按探测计划为每个在线节点选出本周期的对端，每个节点提交一个任务到协程池，一次下发该节点的全部探测任务
+func dispatchProbeTasks(store *storage.Store, nodes []config.NodeInfo) {
+	plan := probePlan.Load()
+	var delays map[string]float64
+	if plan.strategy == meshNearest {
+		var err error
+		if delays, err = linkDelays(store.LinkStats); err != nil {
+			log.Printf("Failed to query link stats, choosing peers at random: %v", err)
+		}
+	}
+
+	total := 0
+	for _, node := range nodes {
+		peers := plan.peers(node, nodes, delays, meshRand)
+		if len(peers) == 0 {
+			continue
+		}
+		total += len(peers)
+		// 提交任务到协程池
+		if err := pool.GetPool().Invoke([]interface{}{node, peers, store.Generations}); err != nil {
+			log.Printf("Failed to submit task for node %s: %v", node.ID, err)
+		}
+	}
+	log.Printf("Dispatched %d probe tasks to %d nodes (%s)", total, len(nodes), plan.strategy)
+}
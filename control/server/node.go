@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"control/config"
+	"control/pki"
+	pb "control/proto"
+	"control/storage"
+	"fmt"
+	"log"
+	"time"
+)
+
+// 节点注册结构体重写
+type Node struct {
+	pb.UnimplementedNodeServiceServer
+	nodes             storage.NodeStore      //节点存储
+	tokens            storage.JoinTokenStore //加入令牌存储
+	heartbeatInterval time.Duration          //要求节点上报心跳的间隔
+	agentConfig       *pb.AgentConfig        //下发给加入节点的配置
+}
+
+// 节点注册方法实现
+func (n *Node) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	if req.Address == "" {
+		return &pb.RegisterResponse{Status: "error"}, fmt.Errorf("address is required")
+	}
+	id := req.NodeId
+	if id == "" {
+		id = req.Address
+	}
+	// 启用 TLS 时节点 ID 必须与客户端证书一致，未指定时使用证书中的节点 ID
+	if certID, ok := pki.PeerNodeID(ctx); ok && req.NodeId == "" {
+		id = certID
+	}
+	if err := checkNodeID(ctx, id); err != nil {
+		return nil, err
+	}
+	probePort := int(req.ProbePort)
+	if probePort == 0 {
+		probePort = 50051
+	}
+	addresses := req.Addresses
+	if len(addresses) == 0 {
+		addresses = []string{req.Address}
+	}
+	node := config.NodeInfo{
+		ID:        id,
+		Address:   req.Address,
+		Addresses: addresses,
+		ProbePort: probePort,
+		Labels:    req.Labels,
+		LastSeen:  time.Now(),
+		State:     "alive",
+	}
+	if err := n.nodes.UpsertNode(node); err != nil {
+		log.Printf("Failed to register node %s: %v", id, err)
+		return nil, err
+	}
+	log.Printf("Node registered: ID=%s, Addresses=%v, ProbePort=%d, Labels=%v", id, addresses, probePort, req.Labels)
+	return &pb.RegisterResponse{
+		Status:            "ok",
+		NodeId:            id,
+		HeartbeatInterval: int64(n.heartbeatInterval / time.Second),
+	}, nil
+}
+
+// 节点心跳方法实现，未注册的节点返回 unknown，要求其重新注册
+func (n *Node) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	if err := checkNodeID(ctx, req.NodeId); err != nil {
+		return nil, err
+	}
+	known, err := n.nodes.Heartbeat(req.NodeId, time.Now())
+	if err != nil {
+		log.Printf("Failed to update heartbeat for node %s: %v", req.NodeId, err)
+		return nil, err
+	}
+	if !known {
+		return &pb.HeartbeatResponse{Status: "unknown"}, nil
+	}
+	return &pb.HeartbeatResponse{Status: "ok"}, nil
+}
+
+// 查询当前在线的节点，顺带把超时未心跳的节点标记为下线
+func aliveNodes(nodes storage.NodeStore, timeout time.Duration) ([]config.NodeInfo, error) {
+	deadline := time.Now().Add(-timeout)
+	if dead, err := nodes.MarkDeadNodes(deadline); err != nil {
+		log.Printf("Failed to mark dead nodes: %v", err)
+	} else if dead > 0 {
+		log.Printf("%d nodes marked dead, no heartbeat since %s", dead, deadline.Format("2006-01-02 15:04:05"))
+	}
+	return nodes.AliveNodes(deadline)
+}
@@ -2,150 +2,372 @@ package server
 
 import (
 	"context"
+	"control/config"
 	"control/models"
+	"control/pki"
 	"control/pool"
 	pb "control/proto"
-	"database/sql"
+	"control/routing"
+	"control/storage"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
-	"github.com/gomodule/redigo/redis"
+
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
-// 探测任务下发函数
-func sendProbeTask(client pb.ProbeTaskServiceClient, ip1, ip2 string) {
-	// 填充探测任务
+// 配置文件中的探测类型名称
+var probeTypes = map[string]pb.ProbeType{
+	"":         pb.ProbeType_PROBE_TYPE_TCP,
+	"tcp":      pb.ProbeType_PROBE_TYPE_TCP,
+	"udp":      pb.ProbeType_PROBE_TYPE_UDP,
+	"http":     pb.ProbeType_PROBE_TYPE_HTTP,
+	"https":    pb.ProbeType_PROBE_TYPE_HTTPS,
+	"tls":      pb.ProbeType_PROBE_TYPE_TLS,
+	"icmp":     pb.ProbeType_PROBE_TYPE_ICMP,
+	"tcp_echo": pb.ProbeType_PROBE_TYPE_TCP_ECHO,
+}
+
+// 下发探测任务使用的探测类型、端口、超时、路径和有效期，由 StartProbeScheduler 根据配置设置，配置重新加载后更新
+var probeTemplate atomic.Pointer[pb.ProbeTask]
+
+// 探测任务的有效期，以下发周期 DetectCycle 为单位
+const probeTaskTTL = 3
+
+// 计算链路统计使用的样本范围和统计方法
+type linkStatsWindow struct {
+	samples  int                 //最多使用的最新样本数
+	duration time.Duration       //只使用该时长内的样本，0 表示不限
+	options  models.StatsOptions //统计方法和最少样本数
+	stale    time.Duration       //部分探测时最新样本早于该时长的链路按不通计入，0 表示不限
+}
+
+// 计算链路统计的参数，由 StartProbeScheduler 根据配置设置，配置重新加载后更新
+var statsWindow atomic.Pointer[linkStatsWindow]
+
+func init() {
+	probeTemplate.Store(&pb.ProbeTask{Type: pb.ProbeType_PROBE_TYPE_TCP})
+	statsWindow.Store(&linkStatsWindow{samples: 10, options: models.StatsOptions{Method: models.StatsMean, MinSamples: 1}})
+}
+
+// 根据配置生成探测任务模板
+func newProbeTemplate(c config.ConfigInfo) (*pb.ProbeTask, error) {
+	probeType, ok := probeTypes[c.ProbeType]
+	if !ok {
+		return nil, fmt.Errorf("unknown probe type %q", c.ProbeType)
+	}
+	return &pb.ProbeTask{
+		Type:       probeType,
+		Port:       int32(c.ProbeTargetPort),
+		TimeoutMs:  c.ProbeTimeout.Milliseconds(),
+		Path:       c.ProbePath,
+		Samples:    int32(c.ProbeSamples),
+		IntervalMs: c.SampleInterval.Milliseconds(),
+		// 控制面停止下发或节点离开探测计划后，数据面在三个下发周期后不再探测
+		TtlMs: probeTaskTTL * c.DetectCycle.Milliseconds(),
+	}, nil
+}
+
+// 最近一次下发给各节点的探测任务，数据面回复 stale 时据此重新下发，按节点 ID 索引
+var (
+	sentTasks      = make(map[string]*pb.ProbeTaskRequest)
+	sentTasksMutex sync.Mutex
+)
+
+// 填充 node 本周期探测 peers 的全部探测任务，开启分散探测时各任务的开始位置均匀分布在周期内
+// 每次下发替换节点的全部任务，generation 为任务表版本号，数据面据此丢弃晚到的旧任务
+func newProbeTaskRequest(node config.NodeInfo, peers []config.NodeInfo, generation uint64) *pb.ProbeTaskRequest {
+	template := probeTemplate.Load()
+	spread := probePlan.Load().spread
 	req := &pb.ProbeTaskRequest{
-		Tasks: []*pb.ProbeTask{
-			{
-				Ip1: ip1,
-				Ip2: ip2,
-			},
-		},
+		Tasks:      make([]*pb.ProbeTask, 0, len(peers)),
+		Update:     pb.ProbeTaskUpdate_PROBE_TASK_REPLACE,
+		Generation: generation,
 	}
+	for i, peer := range peers {
+		task := &pb.ProbeTask{
+			Ip1:        node.Address,
+			Ip2:        peer.Address,
+			NodeId1:    node.ID,
+			NodeId2:    peer.ID,
+			Type:       template.Type,
+			Port:       template.Port,
+			TimeoutMs:  template.TimeoutMs,
+			Path:       template.Path,
+			Samples:    template.Samples,
+			IntervalMs: template.IntervalMs,
+			TtlMs:      template.TtlMs,
+		}
+		if spread {
+			task.Phase = taskPhase(node.ID, i, len(peers))
+		}
+		req.Tasks = append(req.Tasks, task)
+	}
+	return req
+}
 
+// 探测任务下发函数，数据面回复 stale 时以更高的版本号重新下发一次
+func sendProbeTask(client pb.ProbeTaskServiceClient, gens storage.TaskGenerationStore, node config.NodeInfo, req *pb.ProbeTaskRequest) {
 	// 调用 gRPC 方法
 	resp, err := client.SendProbeTasks(context.Background(), req)
 	if err != nil {
-		log.Printf("Failed to send probe task from %s to %s: %v", ip1, ip2, err)
+		log.Printf("Failed to send %d probe tasks to %s: %v", len(req.Tasks), node.ID, err)
+		return
+	}
+	logTaskAck(node.ID, resp)
+	if retry := resendStaleTasks(gens, node.ID, resp); retry != nil {
+		if resp, err = client.SendProbeTasks(context.Background(), retry); err != nil {
+			log.Printf("Failed to resend %d probe tasks to %s: %v", len(retry.Tasks), node.ID, err)
+			return
+		}
+		logTaskAck(node.ID, resp)
+	}
+}
+
+// 记录下发给节点的探测任务
+func rememberTasks(nodeID string, req *pb.ProbeTaskRequest) {
+	sentTasksMutex.Lock()
+	sentTasks[nodeID] = req
+	sentTasksMutex.Unlock()
+}
+
+// 数据面的任务表版本号高于本次下发时（如升级前以下发时间作为版本号，或数据库恢复后版本号回退），返回以 resp.Generation+1 起的版本号重新下发的全部任务
+// 不需要重新下发时返回 nil
+func resendStaleTasks(gens storage.TaskGenerationStore, nodeID string, resp *pb.ProbeTaskResponse) *pb.ProbeTaskRequest {
+	if gens == nil || resp.Status != "stale" {
+		return nil
+	}
+	sentTasksMutex.Lock()
+	last := sentTasks[nodeID]
+	sentTasksMutex.Unlock()
+	if last == nil {
+		return nil
+	}
+	generation, err := gens.NextTaskGeneration(nodeID, resp.Generation+1)
+	if err != nil {
+		log.Printf("Failed to allocate task generation for %s: %v", nodeID, err)
+		return nil
+	}
+	req := &pb.ProbeTaskRequest{Tasks: last.Tasks, Update: pb.ProbeTaskUpdate_PROBE_TASK_REPLACE, Generation: generation}
+	rememberTasks(nodeID, req)
+	log.Printf("Resending %d probe tasks to %s with generation %d", len(req.Tasks), nodeID, generation)
+	return req
+}
+
+// 记录数据面应用探测任务的结果
+func logTaskAck(nodeID string, resp *pb.ProbeTaskResponse) {
+	if resp.Status != "ok" {
+		log.Printf("Probe tasks rejected by %s (%s), current generation: %d", nodeID, resp.Status, resp.Generation)
 		return
 	}
-	log.Printf("Probe task from %s to %s sent successfully. Status: %s", ip1, ip2, resp.Status)
+	log.Printf("Probe tasks applied by %s, generation %d, %d tasks", nodeID, resp.Generation, resp.TaskCount)
 }
-// 任务处理函数
+
+// 任务处理函数，一次下发节点本周期的全部探测任务
 func taskHandler(data interface{}) {
 	// 获取任务参数
 	params := data.([]interface{})
-	ip1 := params[0].(string)
-	ipaddrs := params[1].([]string)
+	node := params[0].(config.NodeInfo)
+	peers := params[1].([]config.NodeInfo)
+	gens := params[2].(storage.TaskGenerationStore)
+	// 版本号按节点持久化递增，不受控制面时钟回拨和重启影响
+	generation, err := gens.NextTaskGeneration(node.ID, 0)
+	if err != nil {
+		log.Printf("Failed to allocate task generation for %s: %v", node.ID, err)
+		return
+	}
+	req := newProbeTaskRequest(node, peers, generation)
+	rememberTasks(node.ID, req)
 
-	// 连接到 gRPC 服务器
-	conn, err := grpc.Dial(fmt.Sprintf("%s:50051", ip1), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// 节点已建立长连接时经由长连接下发
+	if hasSession(node.ID) {
+		msg := &pb.ControlMessage{Payload: &pb.ControlMessage_ProbeTasks{ProbeTasks: req}}
+		if !sendToNode(node.ID, msg) {
+			log.Printf("Failed to send %d probe tasks to %s over channel", len(req.Tasks), node.ID)
+		}
+		return
+	}
+
+	// 未建立长连接的节点，连接到节点注册时通告的探测任务端口
+	conn, err := grpc.Dial(fmt.Sprintf("%s:%d", node.Address, node.ProbePort), pki.DialOption(node.ID))
 	if err != nil {
-		log.Printf("Failed to connect to gRPC server at %s: %v", ip1, err)
+		log.Printf("Failed to connect to gRPC server at %s: %v", node.Address, err)
 		return
 	}
 	defer conn.Close()
 
 	// 创建 gRPC 客户端
 	client := pb.NewProbeTaskServiceClient(conn)
-
-	// 将当前 IP 与其他 IP 组合，发送探测任务
-	for _, ip2 := range ipaddrs {
-		if ip1 != ip2 { // 避免自己探测自己
-			sendProbeTask(client, ip1, ip2)
-		}
-	}
+	sendProbeTask(client, gens, node, req)
 }
+
 // 立即下发一次探测任务
-func SendProbeTasksOnce(db *sql.DB) {
-	// 查询 IP 列表
-	ipaddrs, err := models.QueryIp(db)
+func SendProbeTasksOnce(store *storage.Store, nodeTimeout time.Duration) {
+	// 查询在线节点列表
+	nodes, err := aliveNodes(store.Nodes, nodeTimeout)
 	if err != nil {
-		log.Fatalf("Failed to query IPs: %v", err)
-	}
-
-	// 使用 WaitGroup 等待所有任务完成
-	var wg sync.WaitGroup
-
-	// 遍历 IP 列表，提交任务到协程池
-	for _, ip1 := range ipaddrs {
-		wg.Add(1)
-		go func(ip1 string) {
-			defer wg.Done()
-			// 提交任务到协程池
-			err := pool.GetPool().Invoke([]interface{}{ip1, ipaddrs})
-			if err != nil {
-				log.Printf("Failed to submit task for IP %s: %v", ip1, err)
-			}
-		}(ip1)
+		log.Printf("Failed to query alive nodes: %v", err)
+		return
 	}
-
-	// 等待当前批次任务完成
-	wg.Wait()
+	dispatchProbeTasks(store, nodes)
 	log.Println("Initial batch of probe tasks completed")
 }
-// 定时下发探测任务
-func createProbeTasksWithTimer(ctx context.Context, db *sql.DB, conn redis.Conn , interval time.Duration ,computerInterval time.Duration) {
+
+// 定时下发探测任务，updates 收到新配置时调整两个定时器的周期、节点超时以及探测和路由参数
+func createProbeTasksWithTimer(ctx context.Context, store *storage.Store, interval time.Duration, computerInterval time.Duration, nodeTimeout time.Duration, updates <-chan config.ConfigInfo) {
 	// 创建定时器
 	ticker := time.NewTicker(interval)
 	tickerComputer := time.NewTicker(computerInterval)
 	defer ticker.Stop()
 	defer tickerComputer.Stop()
 
-	// 使用 WaitGroup 等待所有任务完成
-	var wg sync.WaitGroup
-
 	// 定时任务循环
 	for {
 		select {
-			// 创建通道，模拟手动停止
+		// 创建通道，模拟手动停止
 		case <-ctx.Done():
 			log.Println("Stopping probe task scheduler...")
 			return
+		case c := <-updates:
+			// 配置在重新加载时已经校验，周期、节点超时与探测和路由参数一起生效，不能应用时全部保持原值
+			if err := applyScheduleConfig(c); err != nil {
+				log.Printf("Failed to apply reloaded config, keeping the current schedule: %v", err)
+				continue
+			}
+			if c.DetectCycle != interval {
+				interval = c.DetectCycle
+				ticker.Reset(interval)
+			}
+			if c.CalculateCycle != computerInterval {
+				computerInterval = c.CalculateCycle
+				tickerComputer.Reset(computerInterval)
+			}
+			nodeTimeout = c.NodeTimeout
+			log.Printf("Probe scheduler updated: probe every %v, compute every %v, node timeout %v", interval, computerInterval, nodeTimeout)
 		case <-ticker.C:
-			// 查询 IP 列表
-			ipaddrs, err := models.QueryIp(db)
+			// 查询在线节点列表
+			nodes, err := aliveNodes(store.Nodes, nodeTimeout)
 			if err != nil {
-				log.Printf("Failed to query IPs: %v", err)
+				log.Printf("Failed to query alive nodes: %v", err)
 				continue
 			}
 
-			// 遍历 IP 列表，提交任务到协程池
-			for _, ip1 := range ipaddrs {
-				wg.Add(1)
-				go func(ip1 string) {
-					defer wg.Done()
-
-					// 提交任务到协程池
-					err := pool.GetPool().Invoke([]interface{}{ip1, ipaddrs})
-					if err != nil {
-						log.Printf("Failed to submit task for IP %s: %v", ip1, err)
-					}
-				}(ip1)
-			}
-
-			// 等待当前批次任务完成
-			wg.Wait()
+			dispatchProbeTasks(store, nodes)
 			log.Println("Current batch of probe tasks completed")
 		case <-tickerComputer.C:
 			//定时拿到数据并计算存到mysql里面去
-			ipAddresses, err := models.QueryIp(db)
+			nodes, err := aliveNodes(store.Nodes, nodeTimeout)
 			if err != nil {
-				log.Printf("Failed to query IPs: %v", err)
+				log.Printf("Failed to query alive nodes: %v", err)
 				continue
 			}
-			for i := 0; i < len(ipAddresses); i++ {
-				for j := 0; j < len(ipAddresses); j++ {
+			for i := 0; i < len(nodes); i++ {
+				for j := 0; j < len(nodes); j++ {
 					if i != j {
-						// 计算并存储
-						models.CalculateAvgDelay(conn,db,ipAddresses[i],ipAddresses[j])
+						// 按节点 ID 计算并存储
+						if err := updateLinkStats(store, nodes[i].ID, nodes[j].ID); err != nil {
+							log.Printf("Failed to update link %s:%s: %v", nodes[i].ID, nodes[j].ID, err)
+						}
 					}
 				}
 			}
+			// 根据最新链路延迟重新计算 K 条路径，并下发到各节点
+			if engine := routing.GetEngine(); engine != nil {
+				if _, err := engine.Recompute(store.LinkStats); err != nil {
+					log.Printf("Failed to recompute routes: %v", err)
+				} else {
+					PushRoutesToAll(engine, nodes)
+				}
+			}
 		}
 	}
-}
\ No newline at end of file
+}
+
+// 由 src 到 dst 最新的探测样本计算延迟和丢包率，保存为链路统计
+// 样本数不足时保留上一次的统计，不写入新的统计
+func updateLinkStats(store *storage.Store, src, dst string) error {
+	window := statsWindow.Load()
+	results, err := store.LinkSamples.Samples(src, dst, window.samples)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	partial := probePlan.Load().strategy != meshFull
+	// 部分探测时离开探测计划的链路不再有新样本，最新样本过时后按不通计入，避免路由和 nearest 策略一直使用过时的延迟
+	stale := partial && window.stale > 0 && len(models.SamplesSince(results, now.Add(-window.stale))) == 0
+	if window.duration > 0 {
+		results = models.SamplesSince(results, now.Add(-window.duration))
+	}
+	switch {
+	case stale:
+		log.Printf("No samples of link %s:%s in the last %v, marking it as dead", src, dst, window.stale)
+		results = nil
+	case len(results) == 0 && partial:
+		// 暂时不在探测计划中的链路保留上一次的统计
+		return nil
+	case len(results) == 0:
+		log.Printf("No data found for link %s:%s", src, dst)
+	}
+	delay, loss, err := models.CalculateLinkStats(results, window.options)
+	if errors.Is(err, models.ErrTooFewSamples) {
+		log.Printf("Keeping previous stats of link %s:%s: %v", src, dst, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return store.LinkStats.InsertLinkStats(config.LinkInfo{
+		SourceIP:      src,
+		DestinationIP: dst,
+		Delay:         delay,
+		Loss:          loss,
+		Timestamp:     time.Now().Format("2006-01-02 15:04:05"),
+	})
+}
+
+// 应用探测任务模板和路由计算参数，启动时和配置重新加载后调用
+// 先生成全部参数再一起替换，失败时不修改任何参数
+func applyScheduleConfig(c config.ConfigInfo) error {
+	template, err := newProbeTemplate(c)
+	if err != nil {
+		return err
+	}
+	probeTemplate.Store(template)
+	probePlan.Store(newMeshPlan(c))
+	statsWindow.Store(&linkStatsWindow{
+		samples:  c.StatsSamples,
+		duration: c.StatsWindow,
+		// 与探测任务的有效期一致，数据面停止探测后的链路不再保留统计
+		stale: probeTaskTTL * c.DetectCycle,
+		options: models.StatsOptions{
+			Method:     c.StatsMethod,
+			Percentile: c.StatsPercentile,
+			Alpha:      c.StatsEWMAAlpha,
+			MinSamples: c.StatsMinSamples,
+		},
+	})
+	if engine := routing.GetEngine(); engine != nil {
+		engine.SetParams(c.K, c.Theta, c.Skip)
+	}
+	return nil
+}
+
+// 启动探测任务调度：初始化协程池和路由计算引擎，立即下发一次任务后按周期下发并计算链路延迟
+// updates 收到重新加载的配置后调整周期和参数，阻塞直到 ctx 取消，返回前释放协程池
+func StartProbeScheduler(ctx context.Context, store *storage.Store, c config.ConfigInfo, updates <-chan config.ConfigInfo) {
+	if c.MaxLinkLoss > 0 {
+		routing.MaxLinkLoss = c.MaxLinkLoss
+	}
+	pool.InitPool(c.PoolNum, taskHandler)
+	defer pool.ReleasePool()
+	routing.InitEngine(c.K, c.Theta, c.Skip)
+	if err := applyScheduleConfig(c); err != nil {
+		log.Fatalf("Invalid probe config: %v", err)
+	}
+
+	SendProbeTasksOnce(store, c.NodeTimeout)
+	createProbeTasksWithTimer(ctx, store, c.DetectCycle, c.CalculateCycle, c.NodeTimeout, updates)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"control/config"
+	pb "control/proto"
+	"control/routing"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// 测试重新加载的配置更新探测任务模板和路由计算参数
+func TestApplyScheduleConfig(t *testing.T) {
+	routing.InitEngine(6, 0.1, 3)
+	c := config.Default()
+	c.ProbeType = "udp"
+	c.ProbeTimeout = 2 * time.Second
+	c.K = 2
+	if err := applyScheduleConfig(c); err != nil {
+		t.Fatalf("applyScheduleConfig failed: %v", err)
+	}
+	req := newProbeTaskRequest(config.NodeInfo{ID: "node-1"}, []config.NodeInfo{{ID: "node-2"}}, 1)
+	task := req.Tasks[0]
+	if task.Type != pb.ProbeType_PROBE_TYPE_UDP || task.TimeoutMs != 2000 || task.IntervalMs != 100 {
+		t.Fatalf("probe template not updated: %v", task)
+	}
+
+	c.ProbeType = "ftp"
+	if err := applyScheduleConfig(c); err == nil {
+		t.Fatal("expected unknown probe type to be rejected")
+	}
+	if probeTemplate.Load().Type != pb.ProbeType_PROBE_TYPE_UDP {
+		t.Fatal("probe template changed by invalid config")
+	}
+}
+
+// 测试一个节点的探测任务一次下发，开始位置均匀分布在周期内
+func TestNewProbeTaskRequest(t *testing.T) {
+	c := config.Default()
+	if err := applyScheduleConfig(c); err != nil {
+		t.Fatalf("applyScheduleConfig failed: %v", err)
+	}
+	node := config.NodeInfo{ID: "node-1", Address: "10.0.0.1"}
+	peers := []config.NodeInfo{{ID: "node-2", Address: "10.0.0.2"}, {ID: "node-3", Address: "10.0.0.3"}, {ID: "node-4", Address: "10.0.0.4"}, {ID: "node-5", Address: "10.0.0.5"}}
+	req := newProbeTaskRequest(node, peers, 7)
+	if len(req.Tasks) != 4 {
+		t.Fatalf("expected 4 tasks, got %d", len(req.Tasks))
+	}
+	if req.Update != pb.ProbeTaskUpdate_PROBE_TASK_REPLACE || req.Generation != 7 {
+		t.Errorf("expected a full replace with generation 7, got %v %d", req.Update, req.Generation)
+	}
+	for i, task := range req.Tasks {
+		if task.NodeId1 != "node-1" || task.Ip1 != "10.0.0.1" || task.NodeId2 != peers[i].ID || task.Ip2 != peers[i].Address {
+			t.Errorf("task %d: unexpected link %v", i, task)
+		}
+		if task.TtlMs != 3*c.DetectCycle.Milliseconds() {
+			t.Errorf("task %d: expected tasks to expire after 3 cycles, got %dms", i, task.TtlMs)
+		}
+		if task.Phase < 0 || task.Phase >= 1 {
+			t.Errorf("task %d: phase %v out of [0, 1)", i, task.Phase)
+		}
+		// 相邻任务间隔四分之一周期
+		next := req.Tasks[(i+1)%4].Phase
+		if gap := next - task.Phase; math.Abs(gap-0.25) > 1e-9 && math.Abs(gap+0.75) > 1e-9 {
+			t.Errorf("task %d: expected a quarter cycle to the next task, got %v", i, gap)
+		}
+	}
+
+	c.ProbeSpread = false
+	applyScheduleConfig(c)
+	defer applyScheduleConfig(config.Default())
+	for _, task := range newProbeTaskRequest(node, peers, 8).Tasks {
+		if task.Phase != 0 {
+			t.Errorf("expected no phase without spread, got %v", task.Phase)
+		}
+	}
+}
+
+// 测试数据面回复 stale 时以高于其当前版本号的版本号重新下发上一次的全部任务
+func TestResendStaleTasks(t *testing.T) {
+	gens := memGenerationStore{"node-1": 5}
+	req := newProbeTaskRequest(config.NodeInfo{ID: "node-1"}, []config.NodeInfo{{ID: "node-2"}, {ID: "node-3"}}, 5)
+	rememberTasks("node-1", req)
+	t.Cleanup(func() { rememberTasks("node-1", nil) })
+
+	if retry := resendStaleTasks(gens, "node-1", &pb.ProbeTaskResponse{Status: "ok", Generation: 5}); retry != nil {
+		t.Fatalf("expected no resend after ok, got %v", retry)
+	}
+	if retry := resendStaleTasks(gens, "node-2", &pb.ProbeTaskResponse{Status: "stale", Generation: 100}); retry != nil {
+		t.Fatalf("expected no resend without previous tasks, got %v", retry)
+	}
+	retry := resendStaleTasks(gens, "node-1", &pb.ProbeTaskResponse{Status: "stale", Generation: 100})
+	if retry == nil || retry.Generation != 101 || retry.Update != pb.ProbeTaskUpdate_PROBE_TASK_REPLACE || len(retry.Tasks) != 2 {
+		t.Fatalf("expected the full table with generation 101, got %v", retry)
+	}
+	// 之后的下发从新的版本号继续递增
+	if next, _ := gens.NextTaskGeneration("node-1", 0); next != 102 {
+		t.Errorf("expected generation 102 after resend, got %d", next)
+	}
+}
+
+// 测试各策略选出的探测对端
+func TestMeshPlanPeers(t *testing.T) {
+	var nodes []config.NodeInfo
+	for i := 1; i <= 10; i++ {
+		nodes = append(nodes, config.NodeInfo{ID: fmt.Sprintf("node-%d", i)})
+	}
+	rng := rand.New(rand.NewSource(1))
+	ids := func(peers []config.NodeInfo) map[string]bool {
+		set := map[string]bool{}
+		for _, peer := range peers {
+			set[peer.ID] = true
+		}
+		return set
+	}
+
+	c := config.Default()
+	if peers := newMeshPlan(c).peers(nodes[0], nodes, nil, rng); len(peers) != 9 || ids(peers)["node-1"] {
+		t.Errorf("expected full mesh to probe the 9 other nodes, got %v", peers)
+	}
+
+	// 延迟最低的 2 个对端加上随机 3 个对端
+	c.ProbeStrategy = "nearest"
+	c.ProbeNearest = 2
+	c.ProbeRandom = 3
+	delays := map[string]float64{"node-1:node-5": 3, "node-1:node-7": 1, "node-1:node-9": 2, "node-2:node-3": 0.5}
+	plan := newMeshPlan(c)
+	seen := map[string]bool{}
+	for round := 0; round < 20; round++ {
+		peers := plan.peers(nodes[0], nodes, delays, rng)
+		if len(peers) != 5 || peers[0].ID != "node-7" || peers[1].ID != "node-9" {
+			t.Fatalf("expected node-7 and node-9 first, got %v", peers)
+		}
+		if set := ids(peers); len(set) != 5 || set["node-1"] {
+			t.Fatalf("expected 5 distinct peers, got %v", peers)
+		}
+		for _, peer := range peers {
+			seen[peer.ID] = true
+		}
+	}
+	// 随机抽取的对端每个周期轮换
+	if len(seen) != 9 {
+		t.Errorf("expected random peers to rotate over all nodes, saw %v", seen)
+	}
+
+	c.ProbeStrategy = "landmarks"
+	c.ProbeLandmarks = []string{"node-2", "node-3"}
+	c.ProbeRandom = 1
+	plan = newMeshPlan(c)
+	if peers := plan.peers(nodes[1], nodes, nil, rng); len(peers) != 9 {
+		t.Errorf("expected a landmark to probe every node, got %v", peers)
+	}
+	peers := plan.peers(nodes[0], nodes, nil, rng)
+	if set := ids(peers); len(peers) != 3 || !set["node-2"] || !set["node-3"] {
+		t.Errorf("expected the landmarks and one random peer, got %v", peers)
+	}
+}
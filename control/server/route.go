@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"control/config"
+	"control/pki"
+	pb "control/proto"
+	"control/routing"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// 将某个节点的路由转换为 gRPC 请求
+// 路由计算以节点 ID 为键，下发时转换为节点地址，数据面据此直接建立连接
+func buildRouteTableRequest(node config.NodeInfo, routes map[string][]routing.Path, nodes map[string]config.NodeInfo, version uint64) *pb.RouteTableRequest {
+	req := &pb.RouteTableRequest{
+		Node:    node.Address,
+		Version: version,
+	}
+	for dst, paths := range routes {
+		if len(paths) == 0 || len(paths[0].Nodes) < 2 {
+			continue
+		}
+		entry := &pb.RouteEntry{}
+		for _, path := range paths {
+			addrs, ok := nodeAddresses(path.Nodes, nodes)
+			if !ok {
+				continue
+			}
+			entry.Paths = append(entry.Paths, &pb.RoutePath{
+				Nodes: addrs,
+				Delay: path.Delay,
+			})
+		}
+		// 路径上有节点已经下线时不下发该目的节点的路由
+		if len(entry.Paths) == 0 {
+			continue
+		}
+		entry.Destination = nodes[dst].Address
+		entry.NextHop = entry.Paths[0].Nodes[1]
+		req.Routes = append(req.Routes, entry)
+	}
+	return req
+}
+
+// 将路径上的节点 ID 转换为节点地址
+func nodeAddresses(ids []string, nodes map[string]config.NodeInfo) ([]string, bool) {
+	addrs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		node, ok := nodes[id]
+		if !ok {
+			return nil, false
+		}
+		addrs = append(addrs, node.Address)
+	}
+	return addrs, true
+}
+
+// 向单个节点下发路由表，节点已建立长连接时经由长连接下发，应答由长连接异步返回
+func pushRoutes(node config.NodeInfo, req *pb.RouteTableRequest) {
+	if sendToNode(node.ID, &pb.ControlMessage{Payload: &pb.ControlMessage_Routes{Routes: req}}) {
+		log.Printf("Route table version %d queued to %s over channel, %d routes", req.Version, node.ID, len(req.Routes))
+		return
+	}
+	conn, err := grpc.Dial(fmt.Sprintf("%s:%d", node.Address, node.ProbePort), pki.DialOption(node.ID))
+	if err != nil {
+		log.Printf("Failed to connect to gRPC server at %s: %v", node.Address, err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := pb.NewRouteServiceClient(conn).PushRoutes(ctx, req)
+	if err != nil {
+		log.Printf("Failed to push routes to %s: %v", node.ID, err)
+		return
+	}
+	if resp.Status != "ok" {
+		log.Printf("Route table version %d rejected by %s, applied version: %d", req.Version, node.ID, resp.AppliedVersion)
+		return
+	}
+	log.Printf("Route table version %d pushed to %s, %d routes", resp.AppliedVersion, node.ID, len(req.Routes))
+}
+
+// 将路由计算引擎的最新路由表下发到所有在线节点
+func PushRoutesToAll(engine *routing.Engine, alive []config.NodeInfo) {
+	table, version := engine.Table()
+	nodes := make(map[string]config.NodeInfo, len(alive))
+	for _, node := range alive {
+		nodes[node.ID] = node
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range alive {
+		wg.Add(1)
+		go func(node config.NodeInfo) {
+			defer wg.Done()
+			pushRoutes(node, buildRouteTableRequest(node, table[node.ID], nodes, version))
+		}(node)
+	}
+	wg.Wait()
+	log.Printf("Route table version %d pushed to %d nodes", version, len(alive))
+}
@@ -3,138 +3,173 @@ package server
 import (
 	"context"
 	"control/config"
-	"control/dao"
-	"control/models"
+	"control/pki"
 	pb "control/proto"
-	"encoding/json"
+	"control/storage"
 	"fmt"
 	"log"
 	"net"
+	"strings"
 	"time"
 
-	"github.com/gomodule/redigo/redis"
 	"google.golang.org/grpc"
 )
 
 // 节点信息接收结构体重写
 type Server struct {
 	pb.UnimplementedMetricsServiceServer
+	metrics storage.MetricsStore //节点指标存储
 }
+
 // 探测结构体重写
 type Probe struct {
 	pb.UnimplementedProbeResultServiceServer
+	samples storage.SampleStore //探测样本存储
+	nodes   storage.NodeStore   //节点存储，用于校验上报节点的身份
 }
 
 // 节点信息上传方法实现
 func (s *Server) SendMetrics(ctx context.Context, req *pb.Metrics) (*pb.Response, error) {
-	//连接数据库
-	db := dao.ConnectToDB()
-	if db == nil {
-		return &pb.Response{Status: "error"}, fmt.Errorf("unable to connect to the database")
-	}
-	defer db.Close()
-        fmt.Println(req)
-        if req==nil {
+	fmt.Println(req)
+	if req == nil {
 		return &pb.Response{Status: "error"}, fmt.Errorf("invalid request")
 	}
-	// 将数据插入数据库，调用sql语句
-	err := models.InsertMetricsInfo(db, req)
+	// 只能上报客户端证书所属节点的指标
+	if err := checkNodeID(ctx, req.NodeId); err != nil {
+		return nil, err
+	}
+	// 将数据插入数据库
+	err := s.metrics.InsertMetrics(req)
 	if err != nil {
 		return nil, err
 	}
 	return &pb.Response{Status: "ok"}, nil
 }
-// 开启8080端口，接收节点信息上报
-func ReceiveMetrics() {
-	c := dao.UseToml()
+
+// 开启8080端口，接收节点信息上报，ctx 取消后优雅退出
+func ReceiveMetrics(ctx context.Context, store *storage.Store, c config.ConfigInfo) error {
 	// 开启端口
 	listen, err := net.Listen("tcp", "0.0.0.0:"+c.ReceivePort)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	//创建grpc服务
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(pki.ServerOptions()...)
 	//注册服务
-	pb.RegisterMetricsServiceServer(grpcServer, &Server{})
+	pb.RegisterMetricsServiceServer(grpcServer, &Server{metrics: store.Metrics})
+	//ctx 取消后停止接收新请求，等待处理中的请求完成
+	go func() {
+		<-ctx.Done()
+		grpcServer.GracefulStop()
+	}()
 	//启动服务
-	err = grpcServer.Serve(listen)
-	if err != nil {
-		panic(err)
-	}
+	return grpcServer.Serve(listen)
 }
 
 // SendProbeResults 接收探测结果并处理
 func (p *Probe) SendProbeResults(ctx context.Context, req *pb.ProbeResultRequest) (*pb.ProbeResultResponse, error) {
-	// 获取 Redis 连接
-	conn := dao.ConnRedis()
-	c := dao.UseToml()
-	// 设置列表的过期时间（单位：hour）
-	expireDuration := c.ExpireDuration * time.Hour// 一天
-	// 遍历探测结果，处理探测结果，存入 Redis 里
+	// 拒绝源节点或源地址与客户端证书所属节点不符的探测结果
+	if err := checkProbeResults(ctx, p.nodes, req.Results); err != nil {
+		log.Printf("Rejected probe results: %v", err)
+		return nil, err
+	}
+	// 遍历探测结果，处理探测结果，存入样本存储
 	for _, result := range req.Results {
-		log.Printf("Received probe result: IP1=%s, IP2=%s, TCP Delay=%d ms, Timestamp=%s",
-			result.Ip1, result.Ip2, result.TcpDelay, result.Timestamp)
-		// 组合 Redis 键，key 为 ip1:ip2
-		key := result.Ip1 + ":" + result.Ip2
-		value, err := json.Marshal(config.ProbeResult{
+		if result.Failed {
+			log.Printf("Received failed probe result: Node1=%s, Node2=%s, IP1=%s, IP2=%s, Error=%v, %s",
+				result.NodeId1, result.NodeId2, result.Ip1, result.Ip2, result.ErrorClass, result.Error)
+		} else {
+			log.Printf("Received probe result: Node1=%s, Node2=%s, IP1=%s, IP2=%s, Delay=%d/%d/%d us (min/avg/max), Jitter=%d us, Loss=%.2f, Timestamp=%s",
+				result.NodeId1, result.NodeId2, result.Ip1, result.Ip2, result.MinUs, result.AvgUs, result.MaxUs, result.JitterUs, result.Loss, result.Timestamp)
+		}
+		// 按链路保存，链路以节点 ID 标识，旧版本数据面未上报节点 ID 时退化为 IP
+		src, dst := linkEnds(result)
+		err := p.samples.AppendSample(src, dst, config.ProbeResult{
 			SourceIP:      result.Ip1,
 			DestinationIP: result.Ip2,
+			SourceID:      result.NodeId1,
+			DestinationID: result.NodeId2,
+			Type:          probeTypeName(result.Type),
 			Delay:         result.TcpDelay,
+			MinDelay:      result.MinUs,
+			AvgDelay:      result.AvgUs,
+			MaxDelay:      result.MaxUs,
+			StdDev:        result.StddevUs,
+			Jitter:        result.JitterUs,
+			Loss:          result.Loss,
+			Sent:          int(result.Sent),
+			Received:      int(result.Received),
+			Failed:        result.Failed,
+			ErrorClass:    errorClassName(result.ErrorClass),
+			Error:         result.Error,
+			OneWay:        result.OneWay,
+			ForwardLoss:   result.ForwardLoss,
+			ReverseLoss:   result.ReverseLoss,
+			Reordered:     int(result.Reordered),
 			Timestamp:     result.Timestamp,
+			ReceivedAt:    time.Now(),
 		})
 		if err != nil {
-			log.Printf("Error marshalling result to JSON: %v", err)
+			log.Printf("Error storing probe result: %v", err)
 			return nil, err
 		}
-		// 检查 key 是否存在
-		exists, err := redis.Int(conn.Do("EXISTS", key)) // 使用 ConnRedis() 封装的连接
-		if err != nil {
-			log.Printf("Error checking if key exists: %v", err)
-			return nil, err
-		}
-		// 如果 key 不存在，说明已经过期，重新插入并设置过期时间
-		if exists == 0 {
-			// 使用 Redis 的 LPUSH 命令将数据插入列表
-			_, err := conn.Do("LPUSH", key, value)
-			if err != nil {
-				log.Printf("Error storing result in redis: %v", err)
-				return nil, err
-			}
-			// 设置过期时间（仅在首次插入时）
-			_, err = conn.Do("EXPIRE", key, expireDuration)
-			if err != nil {
-				log.Printf("Error setting expiration for key: %v", err)
-				return nil, err
-			}
-		} 
-		//key 存在，直接插入数据，
-		_, lpushErr := conn.Do("LPUSH", key, value)
-		if lpushErr != nil {
-			log.Printf("Error storing result in redis: %v", lpushErr)
-			return nil, lpushErr
-		}
 	}
 	// 返回成功响应
 	return &pb.ProbeResultResponse{Status: "ok"}, nil
 }
 
-// 开启8081端口，接收探测信息
-func ReceiveProbe() {
-	c := dao.UseToml()
+// 探测类型在样本存储中保存的名称
+func probeTypeName(t pb.ProbeType) string {
+	for name, probeType := range probeTypes {
+		if name != "" && probeType == t {
+			return name
+		}
+	}
+	return t.String()
+}
+
+// 探测失败原因在样本存储中保存的名称，成功时为空
+func errorClassName(c pb.ProbeErrorClass) string {
+	if c == pb.ProbeErrorClass_PROBE_ERROR_NONE {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(c.String(), "PROBE_ERROR_"))
+}
+
+// 探测结果所属链路的两端
+func linkEnds(result *pb.ProbeResult) (string, string) {
+	if result.NodeId1 != "" && result.NodeId2 != "" {
+		return result.NodeId1, result.NodeId2
+	}
+	return result.Ip1, result.Ip2
+}
+
+// 开启8081端口，接收探测信息和节点注册、心跳，ctx 取消后优雅退出
+func ReceiveProbe(ctx context.Context, store *storage.Store, c config.ConfigInfo) error {
 	// 创建 gRPC 服务器
-	server := grpc.NewServer()
+	server := grpc.NewServer(pki.ServerOptions()...)
 
 	// 注册 ProbeResultService
-	pb.RegisterProbeResultServiceServer(server, &Probe{})
+	probe := &Probe{samples: store.LinkSamples, nodes: store.Nodes}
+	pb.RegisterProbeResultServiceServer(server, probe)
+
+	// 注册 NodeService
+	pb.RegisterNodeServiceServer(server, &Node{nodes: store.Nodes, tokens: store.Tokens, heartbeatInterval: c.HeartbeatInterval, agentConfig: newAgentConfig(c)})
+
+	// 注册 ChannelService，数据面经由长连接上报的指标和探测结果交给对应的服务处理
+	pb.RegisterChannelServiceServer(server, &Channel{metrics: &Server{metrics: store.Metrics}, probe: probe, nodes: store.Nodes, generations: store.Generations, done: ctx.Done()})
 
 	// 监听端口
-	lis, err := net.Listen("tcp", "0.0.0.0:" + c.DetectPort)
+	lis, err := net.Listen("tcp", "0.0.0.0:"+c.DetectPort)
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		return err
 	}
+	//ctx 取消后停止接收新请求，等待处理中的请求完成
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
 	// 启动服务器
-	log.Println("ProbeResultService server is running on port 8081...")
-	if err := server.Serve(lis); err != nil {
-		log.Fatalf("Failed to serve: %v", err)
-	}
-}
\ No newline at end of file
+	log.Printf("ProbeResultService server is running on port %s...", c.DetectPort)
+	return server.Serve(lis)
+}
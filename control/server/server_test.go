@@ -2,39 +2,55 @@ package server
 
 import (
 	"context"
-	"control/dao"
+	"control/config"
 	"control/pool"
+	"control/routing"
+	"control/storage"
 	"fmt"
 	"log"
+	"os"
 	"testing"
 	"time"
 )
 //测试接收节点信息
 func TestServer(t *testing.T) {
-	ReceiveMetrics()
+	c := config.Default()
+	c.DSN = os.Getenv("SIRIUS_DSN")
+	store, err := storage.Open(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := ReceiveMetrics(context.Background(), store, c); err != nil {
+		t.Fatal(err)
+	}
 }
 //测试下发探测任务
 func TestCreateProbeTasks(t *testing.T) {
 	//调用配置文件方法
-	c :=dao.UseToml()
+	c := config.Default()
+	c.DSN = os.Getenv("SIRIUS_DSN")
 	// 创建 Context 支持优雅退出
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	// 连接数据库
-	db := dao.ConnectToDB()
-	conn := dao.ConnRedis()
-	defer db.Close()
-	defer conn.Close()
+	// 连接数据库和 redis
+	store, err := storage.Open(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
 	// 初始化协程池
 	poolSize := c.PoolNum // 协程池大小
 	pool.InitPool(poolSize, taskHandler)
 	defer pool.ReleasePool()
+	// 初始化路由计算引擎
+	routing.InitEngine(c.K, c.Theta, c.Skip)
 	// 先立即下发一次任务
-	SendProbeTasksOnce(db)
+	SendProbeTasksOnce(store, c.NodeTimeout)
 	// 启动定时器，每隔 30 s下发一次任务
-	interval := c.DetectCycle * time.Second
+	interval := c.DetectCycle
 	
-	go createProbeTasksWithTimer(ctx, db, conn, interval,10 *time.Second)
+	go createProbeTasksWithTimer(ctx, store, interval,10 *time.Second, c.NodeTimeout, nil)
 	// 程序运行
 	log.Println("Probe task scheduler started. Press Ctrl+C to stop.")
 	time.Sleep(30 * time.Minute) // 程序运行 30 分钟
@@ -44,5 +60,44 @@ func TestCreateProbeTasks(t *testing.T) {
 //测试接收探测信息，并存储
 func TestReceiveProbe(t *testing.T) {
 	fmt.Println("ReceiveProbe已启动！")
-	ReceiveProbe()
+	c := config.Default()
+	c.DSN = os.Getenv("SIRIUS_DSN")
+	store, err := storage.Open(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := ReceiveProbe(context.Background(), store, c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//测试路由表转换为下发请求
+func TestBuildRouteTableRequest(t *testing.T) {
+	routes := map[string][]routing.Path{
+		"node-3": {
+			{Nodes: []string{"node-1", "node-2", "node-3"}, Delay: 3},
+			{Nodes: []string{"node-1", "node-3"}, Delay: 5},
+		},
+	}
+	nodes := map[string]config.NodeInfo{
+		"node-1": {ID: "node-1", Address: "10.0.0.1"},
+		"node-2": {ID: "node-2", Address: "10.0.0.2"},
+		"node-3": {ID: "node-3", Address: "10.0.0.3"},
+	}
+	req := buildRouteTableRequest(nodes["node-1"], routes, nodes, 7)
+	if req.Version != 7 || len(req.Routes) != 1 {
+		t.Fatalf("unexpected request: %v", req)
+	}
+	entry := req.Routes[0]
+	if entry.Destination != "10.0.0.3" || entry.NextHop != "10.0.0.2" || len(entry.Paths) != 2 {
+		t.Fatalf("unexpected route entry: %v", entry)
+	}
+
+	// 路径上的节点下线后，该路径不再下发
+	delete(nodes, "node-2")
+	req = buildRouteTableRequest(nodes["node-1"], routes, nodes, 8)
+	if entry := req.Routes[0]; entry.NextHop != "10.0.0.3" || len(entry.Paths) != 1 {
+		t.Fatalf("unexpected route entry: %v", entry)
+	}
 }
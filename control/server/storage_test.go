@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"control/config"
+	pb "control/proto"
+	"control/storage"
+	"testing"
+	"time"
+)
+
+// 内存中的链路统计存储
+type memLinkStatsStore []config.LinkInfo
+
+func (m *memLinkStatsStore) InsertLinkStats(link config.LinkInfo) error {
+	*m = append(*m, link)
+	return nil
+}
+
+func (m *memLinkStatsStore) LatestLinkStats() ([]config.LinkInfo, error) {
+	return *m, nil
+}
+
+// 内存中的链路历史存储
+type memHistoryStore map[string][]config.LinkRollup
+
+func (m memHistoryStore) SaveRollups(tier string, rollups []config.LinkRollup) error {
+	m[tier] = append(m[tier], rollups...)
+	return nil
+}
+
+func (m memHistoryStore) LinkRollups(tier, src, dst string, from, to time.Time) ([]config.LinkRollup, error) {
+	var rollups []config.LinkRollup
+	for _, r := range m[tier] {
+		if r.SourceIP == src && r.DestinationIP == dst && !r.Bucket.Before(from) && r.Bucket.Before(to) {
+			rollups = append(rollups, r)
+		}
+	}
+	return rollups, nil
+}
+
+func (m memHistoryStore) Rollups(tier string, from, to time.Time) ([]config.LinkRollup, error) {
+	var rollups []config.LinkRollup
+	for _, r := range m[tier] {
+		if !r.Bucket.Before(from) && r.Bucket.Before(to) {
+			rollups = append(rollups, r)
+		}
+	}
+	return rollups, nil
+}
+
+func (m memHistoryStore) Prune(tier string, before time.Time) (int64, error) {
+	var kept []config.LinkRollup
+	for _, r := range m[tier] {
+		if !r.Bucket.Before(before) {
+			kept = append(kept, r)
+		}
+	}
+	n := int64(len(m[tier]) - len(kept))
+	m[tier] = kept
+	return n, nil
+}
+
+// 内存中的任务表版本号存储
+type memGenerationStore map[string]uint64
+
+func (m memGenerationStore) NextTaskGeneration(nodeID string, atLeast uint64) (uint64, error) {
+	m[nodeID] = max(m[nodeID]+1, atLeast)
+	return m[nodeID], nil
+}
+
+// 内存中的节点存储
+type memNodeStore map[string]config.NodeInfo
+
+func (m memNodeStore) UpsertNode(node config.NodeInfo) error {
+	m[node.ID] = node
+	return nil
+}
+
+func (m memNodeStore) Heartbeat(id string, lastSeen time.Time) (bool, error) {
+	node, ok := m[id]
+	if ok {
+		node.LastSeen = lastSeen
+		m[id] = node
+	}
+	return ok, nil
+}
+
+func (m memNodeStore) MarkDeadNodes(deadline time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m memNodeStore) AliveNodes(deadline time.Time) ([]config.NodeInfo, error) {
+	var nodes []config.NodeInfo
+	for _, node := range m {
+		if node.LastSeen.After(deadline) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+func (m memNodeStore) Node(id string) (config.NodeInfo, error) {
+	node, ok := m[id]
+	if !ok {
+		return node, storage.ErrNotFound
+	}
+	return node, nil
+}
+
+// 测试探测结果按链路保存到样本存储，旧版本数据面按 IP 保存
+func TestSendProbeResults(t *testing.T) {
+	samples := storage.NewMemorySampleStore(10, time.Hour)
+	p := &Probe{samples: samples}
+	_, err := p.SendProbeResults(context.Background(), &pb.ProbeResultRequest{Results: []*pb.ProbeResult{
+		{NodeId1: "node-1", NodeId2: "node-2", Ip1: "10.0.0.1", Ip2: "10.0.0.2", AvgUs: 1500, Sent: 5, Received: 5, OneWay: true, ReverseLoss: 0.2, Reordered: 1},
+		{Ip1: "10.0.0.1", Ip2: "10.0.0.3", TcpDelay: 2},
+		{NodeId1: "node-1", NodeId2: "node-2", Failed: true, ErrorClass: pb.ProbeErrorClass_PROBE_ERROR_TIMEOUT},
+	}})
+	if err != nil {
+		t.Fatalf("SendProbeResults failed: %v", err)
+	}
+	// 样本从新到旧排列
+	link, _ := samples.Samples("node-1", "node-2", 10)
+	if len(link) != 2 || link[1].AvgDelay != 1500 || !link[1].OneWay || link[1].ReverseLoss != 0.2 || link[1].Reordered != 1 || !link[0].Failed || link[0].ErrorClass != "timeout" {
+		t.Errorf("unexpected samples for node-1:node-2: %+v", link)
+	}
+	if legacy, _ := samples.Samples("10.0.0.1", "10.0.0.3", 10); len(legacy) != 1 || legacy[0].Delay != 2 {
+		t.Errorf("unexpected samples for legacy link: %+v", legacy)
+	}
+}
+
+// 测试由探测样本计算链路统计并保存
+func TestUpdateLinkStats(t *testing.T) {
+	samples := storage.NewMemorySampleStore(10, time.Hour)
+	stats := &memLinkStatsStore{}
+	store := &storage.Store{LinkSamples: samples, LinkStats: stats}
+	samples.AppendSample("node-1", "node-2", config.ProbeResult{AvgDelay: 1000, Sent: 4, Received: 4})
+	samples.AppendSample("node-1", "node-2", config.ProbeResult{AvgDelay: 3000, Sent: 4, Received: 3})
+	if err := updateLinkStats(store, "node-1", "node-2"); err != nil {
+		t.Fatalf("updateLinkStats failed: %v", err)
+	}
+	if err := updateLinkStats(store, "node-2", "node-1"); err != nil {
+		t.Fatalf("updateLinkStats failed: %v", err)
+	}
+	links, _ := stats.LatestLinkStats()
+	if len(links) != 2 {
+		t.Fatalf("expected 2 links, got %+v", links)
+	}
+	if l := links[0]; l.SourceIP != "node-1" || l.DestinationIP != "node-2" || l.Delay != 2 || l.Loss != 1.0/8 {
+		t.Errorf("unexpected link stats: %+v", l)
+	}
+	if _, err := time.ParseInLocation("2006-01-02 15:04:05", links[0].Timestamp, time.Local); err != nil {
+		t.Errorf("unexpected timestamp %q: %v", links[0].Timestamp, err)
+	}
+	// 没有样本的链路按不通计入
+	if l := links[1]; l.Delay != 0 || l.Loss != 1 {
+		t.Errorf("expected link without samples to be dead, got %+v", l)
+	}
+
+	// 只使用最新的 2 个样本计算中位数，样本不足 3 个时保留上一次的统计
+	c := config.Default()
+	c.StatsSamples = 2
+	c.StatsMethod = "median"
+	if err := applyScheduleConfig(c); err != nil {
+		t.Fatalf("applyScheduleConfig failed: %v", err)
+	}
+	defer applyScheduleConfig(config.Default())
+	samples.AppendSample("node-1", "node-2", config.ProbeResult{AvgDelay: 5000, Sent: 4, Received: 4})
+	if err := updateLinkStats(store, "node-1", "node-2"); err != nil {
+		t.Fatalf("updateLinkStats failed: %v", err)
+	}
+	links, _ = stats.LatestLinkStats()
+	if l := links[2]; l.Delay != 4 || l.Loss != 1.0/8 {
+		t.Errorf("expected stats of the newest 2 samples, got %+v", l)
+	}
+	c.StatsMinSamples = 3
+	c.StatsSamples = 3
+	applyScheduleConfig(c)
+	if err := updateLinkStats(store, "node-2", "node-1"); err != nil {
+		t.Fatalf("updateLinkStats failed: %v", err)
+	}
+	samples.AppendSample("node-2", "node-1", config.ProbeResult{AvgDelay: 1000, Sent: 4, Received: 4})
+	if err := updateLinkStats(store, "node-2", "node-1"); err != nil {
+		t.Fatalf("updateLinkStats failed: %v", err)
+	}
+	if links, _ = stats.LatestLinkStats(); len(links) != 4 {
+		t.Errorf("expected link with too few samples to keep its stats, got %+v", links[3:])
+	}
+}
+
+// 测试部分探测时暂时不在探测计划中的链路保留统计，离开探测计划超过任务有效期的链路按不通计入
+func TestUpdateLinkStatsStale(t *testing.T) {
+	c := config.Default()
+	c.ProbeStrategy = "nearest"
+	c.StatsWindow = 30 * time.Second
+	if err := applyScheduleConfig(c); err != nil {
+		t.Fatalf("applyScheduleConfig failed: %v", err)
+	}
+	defer applyScheduleConfig(config.Default())
+
+	samples := storage.NewMemorySampleStore(10, 0)
+	stats := &memLinkStatsStore{}
+	store := &storage.Store{LinkSamples: samples, LinkStats: stats}
+	now := time.Now()
+	// 上一个周期探测过，本周期没有新样本
+	samples.AppendSample("node-1", "node-2", config.ProbeResult{AvgDelay: 1000, Sent: 4, Received: 4, ReceivedAt: now.Add(-time.Minute)})
+	// 一小时前离开探测计划
+	samples.AppendSample("node-1", "node-3", config.ProbeResult{AvgDelay: 1000, Sent: 4, Received: 4, ReceivedAt: now.Add(-time.Hour)})
+	for _, dst := range []string{"node-2", "node-3"} {
+		if err := updateLinkStats(store, "node-1", dst); err != nil {
+			t.Fatalf("updateLinkStats failed: %v", err)
+		}
+	}
+	links, _ := stats.LatestLinkStats()
+	if len(links) != 1 {
+		t.Fatalf("expected only the stale link to be updated, got %+v", links)
+	}
+	if l := links[0]; l.DestinationIP != "node-3" || l.Loss != 1 {
+		t.Errorf("expected stale link to be dead, got %+v", l)
+	}
+}
+
+// 测试由探测样本汇总 1 分钟聚合、合并为 1 小时聚合并清理
+func TestRollupHistory(t *testing.T) {
+	samples := storage.NewMemorySampleStore(10, 0)
+	history := memHistoryStore{}
+	store := &storage.Store{LinkSamples: samples, History: history}
+	hour := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	nodes := []config.NodeInfo{{ID: "node-1"}, {ID: "node-2"}, {ID: "node-3"}}
+	samples.AppendSample("node-1", "node-2", config.ProbeResult{AvgDelay: 1000, Sent: 4, Received: 4, ReceivedAt: hour.Add(10 * time.Second)})
+	samples.AppendSample("node-1", "node-2", config.ProbeResult{AvgDelay: 3000, Sent: 4, Received: 2, ReceivedAt: hour.Add(70 * time.Second)})
+	samples.AppendSample("node-2", "node-1", config.ProbeResult{Failed: true, Sent: 4, ReceivedAt: hour.Add(20 * time.Second)})
+	for _, bucket := range []time.Time{hour, hour.Add(time.Minute)} {
+		if err := rollupMinute(store, nodes, bucket, 10); err != nil {
+			t.Fatalf("rollupMinute failed: %v", err)
+		}
+	}
+	if minutes := history[storage.TierMinute]; len(minutes) != 3 {
+		t.Fatalf("expected 3 minute rollups, got %+v", minutes)
+	}
+	if err := rollupHour(history, hour); err != nil {
+		t.Fatalf("rollupHour failed: %v", err)
+	}
+	link, _ := history.LinkRollups(storage.TierHour, "node-1", "node-2", hour, hour.Add(time.Hour))
+	if len(link) != 1 || link[0].Samples != 2 || link[0].AvgDelay != 2 || link[0].Loss != 0.25 {
+		t.Errorf("unexpected hourly rollup of node-1:node-2: %+v", link)
+	}
+	if dead, _ := history.LinkRollups(storage.TierHour, "node-2", "node-1", hour, hour.Add(time.Hour)); len(dead) != 1 || dead[0].Loss != 1 {
+		t.Errorf("unexpected hourly rollup of node-2:node-1: %+v", dead)
+	}
+
+	// 保留时长为 0 的层不清理
+	pruneHistory(history, historyRetention{minute: time.Hour}, hour.Add(time.Hour+30*time.Second))
+	if minutes := history[storage.TierMinute]; len(minutes) != 1 {
+		t.Errorf("expected 1 minute rollup kept, got %+v", minutes)
+	}
+	if hours := history[storage.TierHour]; len(hours) != 2 {
+		t.Errorf("expected hourly rollups kept, got %+v", hours)
+	}
+}
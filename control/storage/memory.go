@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"control/config"
+	"sync"
+	"time"
+)
+
+// 进程内的探测样本存储，每条链路一个定长环形缓冲区，样本超过 ttl 后过期
+// 单节点控制面和测试可以不依赖 redis
+type MemorySampleStore struct {
+	mu        sync.Mutex
+	capacity  int
+	ttl       time.Duration
+	links     map[string]*sampleRing
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// 一条链路的样本，按追加顺序从旧到新保存在 samples[start:] 和 samples[:start] 中
+type sampleRing struct {
+	samples []timedSample
+	start   int
+	size    int
+}
+
+type timedSample struct {
+	result     config.ProbeResult
+	appendedAt time.Time
+}
+
+// 创建进程内的探测样本存储，每条链路最多保留 capacity（必须为正数）个样本，ttl 为 0 时样本不过期
+func NewMemorySampleStore(capacity int, ttl time.Duration) *MemorySampleStore {
+	return &MemorySampleStore{
+		capacity: capacity,
+		ttl:      ttl,
+		links:    map[string]*sampleRing{},
+		now:      time.Now,
+	}
+}
+
+func (s *MemorySampleStore) AppendSample(src, dst string, result config.ProbeResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	// 每隔 ttl 清理一次不再上报的链路
+	if s.ttl > 0 && now.Sub(s.lastSweep) >= s.ttl {
+		for key, ring := range s.links {
+			if ring.expire(now.Add(-s.ttl)) == 0 {
+				delete(s.links, key)
+			}
+		}
+		s.lastSweep = now
+	}
+	key := sampleKey(src, dst)
+	ring := s.links[key]
+	if ring == nil {
+		ring = &sampleRing{samples: make([]timedSample, s.capacity)}
+		s.links[key] = ring
+	}
+	ring.push(timedSample{result: result, appendedAt: now})
+	return nil
+}
+
+func (s *MemorySampleStore) Samples(src, dst string, n int) ([]config.ProbeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := sampleKey(src, dst)
+	ring := s.links[key]
+	if ring == nil {
+		return nil, nil
+	}
+	if s.ttl > 0 && ring.expire(s.now().Add(-s.ttl)) == 0 {
+		delete(s.links, key)
+		return nil, nil
+	}
+	if n > ring.size {
+		n = ring.size
+	}
+	results := make([]config.ProbeResult, 0, n)
+	for i := ring.size - 1; i >= ring.size-n; i-- {
+		results = append(results, ring.at(i).result)
+	}
+	return results, nil
+}
+
+// 追加一个样本，缓冲区已满时覆盖最旧的样本
+func (r *sampleRing) push(sample timedSample) {
+	if r.size < len(r.samples) {
+		r.samples[(r.start+r.size)%len(r.samples)] = sample
+		r.size++
+		return
+	}
+	r.samples[r.start] = sample
+	r.start = (r.start + 1) % len(r.samples)
+}
+
+// 按追加顺序的第 i 个样本，0 为最旧的样本
+func (r *sampleRing) at(i int) timedSample {
+	return r.samples[(r.start+i)%len(r.samples)]
+}
+
+// 丢弃 deadline 之前追加的样本，返回剩余的样本数
+func (r *sampleRing) expire(deadline time.Time) int {
+	for r.size > 0 && r.at(0).appendedAt.Before(deadline) {
+		r.samples[r.start] = timedSample{}
+		r.start = (r.start + 1) % len(r.samples)
+		r.size--
+	}
+	return r.size
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"control/config"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func delays(results []config.ProbeResult) []int64 {
+	var ds []int64
+	for _, result := range results {
+		ds = append(ds, result.Delay)
+	}
+	return ds
+}
+
+// 测试环形缓冲区满后覆盖最旧的样本，按从新到旧读取最新的样本
+func TestMemorySampleStore(t *testing.T) {
+	store := NewMemorySampleStore(3, time.Hour)
+	for i := int64(1); i <= 5; i++ {
+		store.AppendSample("node-1", "node-2", config.ProbeResult{Delay: i})
+	}
+	store.AppendSample("node-2", "node-1", config.ProbeResult{Delay: 9})
+
+	// 只保留最新的 3 个样本
+	if got, _ := store.Samples("node-1", "node-2", 10); !reflect.DeepEqual(delays(got), []int64{5, 4, 3}) {
+		t.Errorf("expected 5 4 3, got %v", delays(got))
+	}
+	if got, _ := store.Samples("node-1", "node-2", 2); !reflect.DeepEqual(delays(got), []int64{5, 4}) {
+		t.Errorf("expected 5 4, got %v", delays(got))
+	}
+	if got, _ := store.Samples("node-2", "node-1", 10); !reflect.DeepEqual(delays(got), []int64{9}) {
+		t.Errorf("expected 9, got %v", delays(got))
+	}
+	if got, _ := store.Samples("node-1", "node-3", 10); len(got) != 0 {
+		t.Errorf("expected no samples for unknown link, got %v", got)
+	}
+}
+
+// 测试样本超过 ttl 后过期，不再上报的链路被清理
+func TestMemorySampleStoreTTL(t *testing.T) {
+	now := time.Now()
+	store := NewMemorySampleStore(10, time.Minute)
+	store.now = func() time.Time { return now }
+
+	store.AppendSample("node-1", "node-2", config.ProbeResult{Delay: 1})
+	store.AppendSample("node-2", "node-1", config.ProbeResult{Delay: 1})
+	now = now.Add(40 * time.Second)
+	store.AppendSample("node-1", "node-2", config.ProbeResult{Delay: 2})
+	now = now.Add(40 * time.Second)
+
+	if got, _ := store.Samples("node-1", "node-2", 10); !reflect.DeepEqual(delays(got), []int64{2}) {
+		t.Errorf("expected only the unexpired sample, got %v", delays(got))
+	}
+	store.AppendSample("node-1", "node-2", config.ProbeResult{Delay: 3})
+	if _, ok := store.links[sampleKey("node-2", "node-1")]; ok {
+		t.Error("expected expired link to be removed")
+	}
+	now = now.Add(2 * time.Minute)
+	if got, _ := store.Samples("node-1", "node-2", 10); len(got) != 0 {
+		t.Errorf("expected all samples expired, got %v", delays(got))
+	}
+}
+
+// 测试按配置选择进程内的样本存储，不创建 redis 连接池
+func TestOpenMemorySampleStore(t *testing.T) {
+	c := config.Default()
+	c.SampleStore = "memory"
+	store, err := Open(c)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer store.Close()
+	if _, ok := store.LinkSamples.(*MemorySampleStore); !ok || store.redisPool != nil {
+		t.Fatalf("expected memory sample store without redis, got %T", store.LinkSamples)
+	}
+}
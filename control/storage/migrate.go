@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// 内置的数据库迁移，每种数据库一个目录，文件名为 <版本号>_<名称>.up.sql 和 <版本号>_<名称>.down.sql
+// 各目录中的迁移版本一一对应，表结构相同
+//
+//go:embed migrations/mysql/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// 记录已应用迁移的表
+const migrationTable = "schema_migrations"
+
+// 一个版本的数据库迁移
+type Migration struct {
+	Version int
+	Name    string
+	Up      string //升级语句，多条语句以分号分隔
+	Down    string //回滚语句
+}
+
+// 迁移及其应用状态
+type MigrationState struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// 读取 driver 数据库的全部内置迁移，按版本号升序排列
+func Migrations(driver string) ([]Migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.up.sql or .down.sql", name)
+		}
+		versionText, title, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionText)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", name, versionText)
+		}
+		data, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: both up and down are required", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// 应用全部未应用的迁移，返回本次应用的迁移数
+func (s *Store) MigrateUp() (int, error) {
+	states, err := s.MigrationStatus()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, state := range states {
+		if state.Applied {
+			continue
+		}
+		if err := s.execMigration(state.Version, state.Name, state.Up); err != nil {
+			return count, err
+		}
+		if _, err := s.db.Exec(`INSERT INTO `+migrationTable+` (version, name, applied_at) VALUES (?, ?, ?)`, state.Version, state.Name, time.Now()); err != nil {
+			return count, err
+		}
+		log.Printf("Migration %d_%s applied", state.Version, state.Name)
+		count++
+	}
+	return count, nil
+}
+
+// 按版本号从高到低回滚 steps 个已应用的迁移，返回本次回滚的迁移数
+func (s *Store) MigrateDown(steps int) (int, error) {
+	states, err := s.MigrationStatus()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := len(states) - 1; i >= 0 && count < steps; i-- {
+		state := states[i]
+		if !state.Applied {
+			continue
+		}
+		if err := s.execMigration(state.Version, state.Name, state.Down); err != nil {
+			return count, err
+		}
+		if _, err := s.db.Exec(`DELETE FROM `+migrationTable+` WHERE version = ?`, state.Version); err != nil {
+			return count, err
+		}
+		log.Printf("Migration %d_%s rolled back", state.Version, state.Name)
+		count++
+	}
+	return count, nil
+}
+
+// 查询内置迁移的应用状态，按版本号升序排列
+func (s *Store) MigrationStatus() ([]MigrationState, error) {
+	if s.db == nil {
+		return nil, errors.New("storage has no database")
+	}
+	migrations, err := Migrations(s.driver)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS ` + migrationTable + ` (
+			version INT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at DATETIME NOT NULL
+		)
+	`); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT version, applied_at FROM ` + migrationTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.Version]
+		states = append(states, MigrationState{Migration: m, Applied: ok, AppliedAt: appliedAt})
+	}
+	return states, nil
+}
+
+// 依次执行一个迁移中的语句
+// 以前的 mysql 环境按提交说明手工建表和改表，遇到表、列或索引已存在（回滚时已不存在）的错误时视为该语句已执行
+func (s *Store) execMigration(version int, name string, script string) error {
+	for _, stmt := range splitStatements(script) {
+		_, err := s.db.Exec(stmt)
+		if alreadyApplied(err) {
+			log.Printf("Migration %d_%s: skipping statement already applied by hand: %v", version, name, err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", version, name, err)
+		}
+	}
+	return nil
+}
+
+// 把迁移脚本拆分为单条语句，去掉 -- 开头的注释行
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	var stmts []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// mysql 中表已存在、列已存在、索引已存在、要删除的列或索引不存在的错误码
+var alreadyAppliedErrors = map[uint16]bool{1050: true, 1060: true, 1061: true, 1091: true}
+
+func alreadyApplied(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && alreadyAppliedErrors[mysqlErr.Number]
+}
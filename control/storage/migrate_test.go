@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// 测试内置迁移的版本号连续且各数据库一致，都有升级和回滚语句
+func TestMigrations(t *testing.T) {
+	mysqlMigrations, err := Migrations("mysql")
+	if err != nil {
+		t.Fatalf("Migrations failed: %v", err)
+	}
+	if len(mysqlMigrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	sqliteMigrations, err := Migrations("sqlite")
+	if err != nil {
+		t.Fatalf("Migrations failed: %v", err)
+	}
+	if len(sqliteMigrations) != len(mysqlMigrations) {
+		t.Fatalf("expected %d sqlite migrations, got %d", len(mysqlMigrations), len(sqliteMigrations))
+	}
+	for _, migrations := range [][]Migration{mysqlMigrations, sqliteMigrations} {
+		for i, m := range migrations {
+			if m.Version != i+1 || m.Name != mysqlMigrations[i].Name {
+				t.Errorf("expected %d_%s, got %d_%s", i+1, mysqlMigrations[i].Name, m.Version, m.Name)
+			}
+			if len(splitStatements(m.Up)) == 0 || len(splitStatements(m.Down)) == 0 {
+				t.Errorf("migration %d_%s has empty up or down", m.Version, m.Name)
+			}
+		}
+		// 查询依赖的索引由迁移创建
+		var all strings.Builder
+		for _, m := range migrations {
+			all.WriteString(m.Up)
+		}
+		for _, index := range []string{"system_info (ip, timestamp)", "link_info (SourceIP, DestinationIP, Timestamp)", "link_info (Timestamp)"} {
+			if !strings.Contains(all.String(), index) {
+				t.Errorf("expected index on %s", index)
+			}
+		}
+	}
+}
+
+// 测试迁移脚本拆分为单条语句并去掉注释
+func TestSplitStatements(t *testing.T) {
+	stmts := splitStatements("-- comment\nCREATE TABLE a (\n  id INT\n);\n\nDROP TABLE b;\n")
+	if len(stmts) != 2 || stmts[0] != "CREATE TABLE a (\n  id INT\n)" || stmts[1] != "DROP TABLE b" {
+		t.Errorf("unexpected statements: %q", stmts)
+	}
+}
+
+// 测试手工建表留下的表、列、索引已存在的错误视为已执行
+func TestAlreadyApplied(t *testing.T) {
+	duplicate := fmt.Errorf("exec: %w", &mysql.MySQLError{Number: 1060, Message: "Duplicate column name 'Loss'"})
+	if !alreadyApplied(duplicate) {
+		t.Error("expected duplicate column to be treated as applied")
+	}
+	if alreadyApplied(&mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}) || alreadyApplied(nil) {
+		t.Error("expected other errors to fail the migration")
+	}
+}
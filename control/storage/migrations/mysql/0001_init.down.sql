@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS link_info;
+DROP TABLE IF EXISTS system_info;
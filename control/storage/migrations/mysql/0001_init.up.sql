@@ -0,0 +1,41 @@
+-- 节点指标和链路统计，已有手工建表的环境跳过
+CREATE TABLE IF NOT EXISTS system_info (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    ip VARCHAR(64) NOT NULL,
+    cpu_cores INT NOT NULL DEFAULT 0,
+    cpu_model_name VARCHAR(255) NOT NULL DEFAULT '',
+    cpu_mhz DOUBLE NOT NULL DEFAULT 0,
+    cpu_cache_size INT NOT NULL DEFAULT 0,
+    cpu_usage DOUBLE NOT NULL DEFAULT 0,
+    memory_total BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    memory_available BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    memory_used BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    memory_used_percent DOUBLE NOT NULL DEFAULT 0,
+    disk_device VARCHAR(255) NOT NULL DEFAULT '',
+    disk_total BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    disk_free BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    disk_used BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    disk_used_percent DOUBLE NOT NULL DEFAULT 0,
+    network_interface_name VARCHAR(255) NOT NULL DEFAULT '',
+    network_bytes_sent BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    network_bytes_recv BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    network_packets_sent BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    network_packets_recv BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    hostname VARCHAR(255) NOT NULL DEFAULT '',
+    os VARCHAR(64) NOT NULL DEFAULT '',
+    platform VARCHAR(64) NOT NULL DEFAULT '',
+    platform_version VARCHAR(64) NOT NULL DEFAULT '',
+    uptime BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    load1 DOUBLE NOT NULL DEFAULT 0,
+    load5 DOUBLE NOT NULL DEFAULT 0,
+    load15 DOUBLE NOT NULL DEFAULT 0,
+    timestamp DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS link_info (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    SourceIP VARCHAR(64) NOT NULL,
+    DestinationIP VARCHAR(64) NOT NULL,
+    Delay DOUBLE NOT NULL,
+    Timestamp DATETIME NOT NULL
+);
@@ -0,0 +1,10 @@
+-- 节点注册和心跳，addresses、labels 为 JSON
+CREATE TABLE IF NOT EXISTS node_info (
+    id VARCHAR(64) PRIMARY KEY,
+    address VARCHAR(64) NOT NULL,
+    addresses TEXT NULL,
+    probe_port INT NOT NULL,
+    labels TEXT NULL,
+    last_seen DATETIME NOT NULL,
+    state VARCHAR(16) NOT NULL DEFAULT 'alive'
+);
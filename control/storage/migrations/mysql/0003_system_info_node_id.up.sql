@@ -0,0 +1,2 @@
+-- 指标按节点 ID 上报
+ALTER TABLE system_info ADD COLUMN node_id VARCHAR(64) NOT NULL DEFAULT '' AFTER id;
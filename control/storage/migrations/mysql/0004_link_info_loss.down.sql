@@ -0,0 +1 @@
+ALTER TABLE link_info DROP COLUMN Loss;
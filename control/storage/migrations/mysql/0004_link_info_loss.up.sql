@@ -0,0 +1,2 @@
+-- 统计窗口内的丢包率
+ALTER TABLE link_info ADD COLUMN Loss DOUBLE NOT NULL DEFAULT 0 AFTER Delay;
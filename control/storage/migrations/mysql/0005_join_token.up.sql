@@ -0,0 +1,8 @@
+-- 节点加入令牌，只保存密钥的哈希
+CREATE TABLE IF NOT EXISTS join_token (
+    id VARCHAR(32) PRIMARY KEY,
+    secret_hash CHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME NULL,
+    node_id VARCHAR(64) NULL
+);
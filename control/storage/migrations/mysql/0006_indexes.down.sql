@@ -0,0 +1,2 @@
+DROP INDEX idx_link_info_link_timestamp ON link_info;
+DROP INDEX idx_system_info_ip_timestamp ON system_info;
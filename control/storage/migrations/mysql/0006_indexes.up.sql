@@ -0,0 +1,3 @@
+-- 按节点查询指标、按链路查询最新统计
+CREATE INDEX idx_system_info_ip_timestamp ON system_info (ip, timestamp);
+CREATE INDEX idx_link_info_link_timestamp ON link_info (SourceIP, DestinationIP, Timestamp);
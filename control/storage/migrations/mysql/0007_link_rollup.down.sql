@@ -0,0 +1,3 @@
+DROP INDEX idx_link_info_timestamp ON link_info;
+DROP TABLE IF EXISTS link_rollup_1h;
+DROP TABLE IF EXISTS link_rollup_1m;
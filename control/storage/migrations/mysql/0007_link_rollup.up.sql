@@ -0,0 +1,32 @@
+-- 链路历史的 1 分钟和 1 小时聚合，按聚合区间清理过期数据
+CREATE TABLE IF NOT EXISTS link_rollup_1m (
+    SourceIP VARCHAR(64) NOT NULL,
+    DestinationIP VARCHAR(64) NOT NULL,
+    Bucket DATETIME NOT NULL,
+    Samples INT NOT NULL,
+    Sent INT NOT NULL,
+    Received INT NOT NULL,
+    MinDelay DOUBLE NOT NULL,
+    AvgDelay DOUBLE NOT NULL,
+    P95Delay DOUBLE NOT NULL,
+    Loss DOUBLE NOT NULL,
+    PRIMARY KEY (SourceIP, DestinationIP, Bucket)
+);
+
+CREATE TABLE IF NOT EXISTS link_rollup_1h (
+    SourceIP VARCHAR(64) NOT NULL,
+    DestinationIP VARCHAR(64) NOT NULL,
+    Bucket DATETIME NOT NULL,
+    Samples INT NOT NULL,
+    Sent INT NOT NULL,
+    Received INT NOT NULL,
+    MinDelay DOUBLE NOT NULL,
+    AvgDelay DOUBLE NOT NULL,
+    P95Delay DOUBLE NOT NULL,
+    Loss DOUBLE NOT NULL,
+    PRIMARY KEY (SourceIP, DestinationIP, Bucket)
+);
+
+CREATE INDEX idx_link_rollup_1m_bucket ON link_rollup_1m (Bucket);
+CREATE INDEX idx_link_rollup_1h_bucket ON link_rollup_1h (Bucket);
+CREATE INDEX idx_link_info_timestamp ON link_info (Timestamp);
@@ -0,0 +1,2 @@
+DROP INDEX idx_join_token_node_id ON join_token;
+ALTER TABLE join_token DROP COLUMN bound_node_id;
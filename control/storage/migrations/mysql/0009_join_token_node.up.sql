@@ -0,0 +1,3 @@
+-- 每个节点 ID 只能用普通令牌加入一次；绑定节点 ID 的令牌供已加入的节点重新申请证书
+ALTER TABLE join_token ADD COLUMN bound_node_id VARCHAR(64) NULL;
+CREATE UNIQUE INDEX idx_join_token_node_id ON join_token (node_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS join_token;
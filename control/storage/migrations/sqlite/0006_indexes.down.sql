@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_link_info_link_timestamp;
+DROP INDEX IF EXISTS idx_system_info_ip_timestamp;
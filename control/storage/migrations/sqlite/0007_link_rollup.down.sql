@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_link_info_timestamp;
+DROP TABLE IF EXISTS link_rollup_1h;
+DROP TABLE IF EXISTS link_rollup_1m;
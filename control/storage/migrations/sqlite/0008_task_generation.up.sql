@@ -0,0 +1,5 @@
+-- 每个节点探测任务表的版本号，控制面重启或时钟回拨后仍单调递增
+CREATE TABLE IF NOT EXISTS task_generation (
+    node_id VARCHAR(64) PRIMARY KEY,
+    generation BIGINT NOT NULL
+);
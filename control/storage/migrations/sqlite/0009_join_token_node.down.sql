@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_join_token_node_id;
+ALTER TABLE join_token DROP COLUMN bound_node_id;
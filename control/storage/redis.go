@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"control/config"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// 基于 redis 的探测样本存储，每条链路一个列表，键为 src:dst
+type redisSampleStore struct {
+	pool     *redis.Pool
+	capacity int           //每个列表最多保留的样本数，超出时丢弃最旧的样本
+	ttl      time.Duration //列表过期时间，从列表创建时开始计算
+}
+
+func sampleKey(src, dst string) string {
+	return src + ":" + dst
+}
+
+func (s *redisSampleStore) AppendSample(src, dst string, result config.ProbeResult) error {
+	value, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	conn := s.pool.Get()
+	defer conn.Close()
+	key := sampleKey(src, dst)
+	length, err := redis.Int(conn.Do("LPUSH", key, value))
+	if err != nil {
+		return err
+	}
+	if s.capacity > 0 && length > s.capacity {
+		if _, err := conn.Do("LTRIM", key, 0, s.capacity-1); err != nil {
+			return err
+		}
+	}
+	// 列表不存在或已经过期时 LPUSH 创建新列表，只在此时设置过期时间
+	if length == 1 && s.ttl > 0 {
+		if _, err := conn.Do("EXPIRE", key, int64(s.ttl/time.Second)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *redisSampleStore) Samples(src, dst string, n int) ([]config.ProbeResult, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	// LPUSH 把新样本插入列表头部，最新的 n 条在列表开头
+	values, err := redis.Values(conn.Do("LRANGE", sampleKey(src, dst), 0, n-1))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]config.ProbeResult, 0, len(values))
+	for _, value := range values {
+		var result config.ProbeResult
+		if err := json.Unmarshal(value.([]byte), &result); err != nil {
+			log.Printf("Failed to parse Redis value: %v", err)
+			continue // 跳过无法解析的数据
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
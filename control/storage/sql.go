@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"control/config"
+	"control/models"
+	pb "control/proto"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 基于数据库的存储，保存节点指标、链路统计和历史、节点和加入令牌
+type sqlStore struct {
+	db *sql.DB
+}
+
+func (s *sqlStore) InsertMetrics(m *pb.Metrics) error {
+	return models.InsertMetricsInfo(s.db, m)
+}
+
+func (s *sqlStore) QueryIPs() ([]string, error) {
+	return models.QueryIp(s.db)
+}
+
+func (s *sqlStore) InsertLinkStats(link config.LinkInfo) error {
+	return models.InsertLinkInfo(s.db, link.SourceIP, link.DestinationIP, link.Delay, link.Loss, link.Timestamp)
+}
+
+func (s *sqlStore) LatestLinkStats() ([]config.LinkInfo, error) {
+	return models.QueryLatestLinkInfo(s.db)
+}
+
+// 各层链路历史聚合所在的表
+var rollupTables = map[string]string{TierMinute: "link_rollup_1m", TierHour: "link_rollup_1h"}
+
+func rollupTable(tier string) (string, error) {
+	table, ok := rollupTables[tier]
+	if !ok {
+		return "", fmt.Errorf("unknown link history tier %q", tier)
+	}
+	return table, nil
+}
+
+// 聚合的区间开始时间统一以 UTC 保存和比较
+func (s *sqlStore) SaveRollups(tier string, rollups []config.LinkRollup) error {
+	table, err := rollupTable(tier)
+	if err != nil {
+		return err
+	}
+	utc := make([]config.LinkRollup, len(rollups))
+	for i, r := range rollups {
+		r.Bucket = r.Bucket.UTC()
+		utc[i] = r
+	}
+	return models.InsertLinkRollups(s.db, table, utc)
+}
+
+func (s *sqlStore) LinkRollups(tier, src, dst string, from, to time.Time) ([]config.LinkRollup, error) {
+	table, err := rollupTable(tier)
+	if err != nil {
+		return nil, err
+	}
+	return models.QueryLinkRollups(s.db, table, src, dst, from.UTC(), to.UTC())
+}
+
+func (s *sqlStore) Rollups(tier string, from, to time.Time) ([]config.LinkRollup, error) {
+	table, err := rollupTable(tier)
+	if err != nil {
+		return nil, err
+	}
+	return models.QueryRollups(s.db, table, from.UTC(), to.UTC())
+}
+
+func (s *sqlStore) Prune(tier string, before time.Time) (int64, error) {
+	if tier == TierRaw {
+		return models.DeleteLinkInfo(s.db, before)
+	}
+	table, err := rollupTable(tier)
+	if err != nil {
+		return 0, err
+	}
+	return models.DeleteLinkRollups(s.db, table, before.UTC())
+}
+
+func (s *sqlStore) UpsertNode(node config.NodeInfo) error {
+	return models.UpsertNodeInfo(s.db, node)
+}
+
+func (s *sqlStore) Heartbeat(id string, lastSeen time.Time) (bool, error) {
+	return models.UpdateNodeHeartbeat(s.db, id, lastSeen)
+}
+
+func (s *sqlStore) MarkDeadNodes(deadline time.Time) (int64, error) {
+	return models.MarkDeadNodes(s.db, deadline)
+}
+
+func (s *sqlStore) AliveNodes(deadline time.Time) ([]config.NodeInfo, error) {
+	return models.QueryAliveNodes(s.db, deadline)
+}
+
+func (s *sqlStore) Node(id string) (config.NodeInfo, error) {
+	node, err := models.QueryNodeInfo(s.db, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return node, ErrNotFound
+	}
+	return node, err
+}
+
+func (s *sqlStore) InsertJoinToken(id, secretHash string, expiresAt time.Time, boundNodeID string) error {
+	return models.InsertJoinToken(s.db, id, secretHash, expiresAt, boundNodeID)
+}
+
+func (s *sqlStore) ConsumeJoinToken(id, secretHash, nodeID string, now time.Time) (bool, error) {
+	return models.ConsumeJoinToken(s.db, id, secretHash, nodeID, now)
+}
+
+func (s *sqlStore) ConsumeRejoinToken(id, secretHash, nodeID string, now time.Time) (bool, error) {
+	return models.ConsumeRejoinToken(s.db, id, secretHash, nodeID, now)
+}
+
+func (s *sqlStore) JoinTokenUsedBy(nodeID string) (bool, error) {
+	return models.JoinTokenUsedBy(s.db, nodeID)
+}
+
+func (s *sqlStore) NextTaskGeneration(nodeID string, atLeast uint64) (uint64, error) {
+	return models.NextTaskGeneration(s.db, nodeID, atLeast)
+}
@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"control/config"
+	pb "control/proto"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 打开临时的 sqlite 数据库并应用全部迁移
+func openSQLiteStore(t *testing.T) *Store {
+	t.Helper()
+	c := config.Default()
+	c.Driver = "sqlite"
+	c.DSN = filepath.Join(t.TempDir(), "sirius.db")
+	store, err := Open(c)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if _, err := store.MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+	return store
+}
+
+func newMetrics(nodeID, ip string) *pb.Metrics {
+	return &pb.Metrics{
+		NodeId:      nodeID,
+		Ip:          ip,
+		CpuInfo:     &pb.CPUInfo{Cores: 4, ModelName: "test", Usage: 12.5},
+		MemoryInfo:  &pb.MemoryInfo{Total: 8 << 30, Used: 2 << 30, UsedPercent: 25},
+		DiskInfo:    &pb.DiskInfo{Device: "/dev/sda1", Total: 100 << 30},
+		NetworkInfo: &pb.NetworkInfo{InterfaceName: "eth0", BytesSent: 1024},
+		HostInfo:    &pb.HostInfo{Hostname: nodeID, Os: "linux", Uptime: 3600},
+		LoadInfo:    &pb.LoadInfo{Load1: 0.5},
+	}
+}
+
+// 测试迁移的应用、回滚和状态
+func TestSQLiteMigrate(t *testing.T) {
+	store := openSQLiteStore(t)
+	states, err := store.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus failed: %v", err)
+	}
+	for _, state := range states {
+		if !state.Applied || state.AppliedAt.IsZero() {
+			t.Errorf("expected migration %d_%s to be applied, got %+v", state.Version, state.Name, state)
+		}
+	}
+	if n, err := store.MigrateUp(); err != nil || n != 0 {
+		t.Fatalf("expected nothing to apply, got %d %v", n, err)
+	}
+	if n, err := store.MigrateDown(len(states)); err != nil || n != len(states) {
+		t.Fatalf("expected %d migrations rolled back, got %d %v", len(states), n, err)
+	}
+	if n, err := store.MigrateUp(); err != nil || n != len(states) {
+		t.Fatalf("expected %d migrations applied again, got %d %v", len(states), n, err)
+	}
+}
+
+// 测试节点指标和链路统计的读写
+func TestSQLiteMetricsAndLinkStats(t *testing.T) {
+	store := openSQLiteStore(t)
+	for _, m := range []*pb.Metrics{newMetrics("node-1", "10.0.0.1"), newMetrics("node-2", "10.0.0.2"), newMetrics("node-1", "10.0.0.1")} {
+		if err := store.Metrics.InsertMetrics(m); err != nil {
+			t.Fatalf("InsertMetrics failed: %v", err)
+		}
+	}
+	ips, err := store.Metrics.QueryIPs()
+	if err != nil || len(ips) != 2 {
+		t.Fatalf("expected 2 distinct IPs, got %v %v", ips, err)
+	}
+
+	links := []config.LinkInfo{
+		{SourceIP: "node-1", DestinationIP: "node-2", Delay: 5, Loss: 0.2, Timestamp: "2026-01-01 00:00:00"},
+		{SourceIP: "node-1", DestinationIP: "node-2", Delay: 3, Loss: 0, Timestamp: "2026-01-01 00:01:00"},
+		{SourceIP: "node-2", DestinationIP: "node-1", Delay: 4, Loss: 0.1, Timestamp: "2026-01-01 00:00:30"},
+	}
+	for _, link := range links {
+		if err := store.LinkStats.InsertLinkStats(link); err != nil {
+			t.Fatalf("InsertLinkStats failed: %v", err)
+		}
+	}
+	latest, err := store.LinkStats.LatestLinkStats()
+	if err != nil {
+		t.Fatalf("LatestLinkStats failed: %v", err)
+	}
+	if len(latest) != 2 {
+		t.Fatalf("expected latest stats of 2 links, got %+v", latest)
+	}
+	for _, link := range latest {
+		if link.SourceIP == "node-1" && (link.Delay != 3 || link.Loss != 0) {
+			t.Errorf("expected the newest node-1 -> node-2 stats, got %+v", link)
+		}
+	}
+}
+
+// 测试节点注册、心跳、下线和查询
+func TestSQLiteNodes(t *testing.T) {
+	store := openSQLiteStore(t)
+	now := time.Now()
+	node := config.NodeInfo{ID: "node-1", Address: "10.0.0.1", Addresses: []string{"10.0.0.1"}, ProbePort: 50051, LastSeen: now.Add(-time.Minute), State: "alive"}
+	if err := store.Nodes.UpsertNode(node); err != nil {
+		t.Fatalf("UpsertNode failed: %v", err)
+	}
+	// 重新注册时更新地址和标签
+	node.Addresses = []string{"10.0.0.1", "192.168.0.1"}
+	node.Labels = map[string]string{"region": "bj"}
+	if err := store.Nodes.UpsertNode(node); err != nil {
+		t.Fatalf("UpsertNode failed: %v", err)
+	}
+	if err := store.Nodes.UpsertNode(config.NodeInfo{ID: "node-2", Address: "10.0.0.2", ProbePort: 50051, LastSeen: now, State: "alive"}); err != nil {
+		t.Fatalf("UpsertNode failed: %v", err)
+	}
+	got, err := store.Nodes.Node("node-1")
+	if err != nil || len(got.Addresses) != 2 || got.Labels["region"] != "bj" {
+		t.Fatalf("unexpected node %+v %v", got, err)
+	}
+	if _, err := store.Nodes.Node("node-3"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if known, err := store.Nodes.Heartbeat("node-3", now); err != nil || known {
+		t.Fatalf("expected unknown node, got %v %v", known, err)
+	}
+
+	// node-1 一分钟前心跳，超过 30 秒视为下线
+	deadline := now.Add(-30 * time.Second)
+	if dead, err := store.Nodes.MarkDeadNodes(deadline); err != nil || dead != 1 {
+		t.Fatalf("expected 1 dead node, got %d %v", dead, err)
+	}
+	alive, err := store.Nodes.AliveNodes(deadline)
+	if err != nil || len(alive) != 1 || alive[0].ID != "node-2" {
+		t.Fatalf("expected only node-2 alive, got %+v %v", alive, err)
+	}
+	if known, err := store.Nodes.Heartbeat("node-1", now); err != nil || !known {
+		t.Fatalf("expected heartbeat of node-1 to be accepted, got %v %v", known, err)
+	}
+	if alive, _ := store.Nodes.AliveNodes(deadline); len(alive) != 2 {
+		t.Fatalf("expected node-1 alive again, got %+v", alive)
+	}
+}
+
+// 测试加入令牌只能使用一次且过期后不可用
+func TestSQLiteJoinTokens(t *testing.T) {
+	store := openSQLiteStore(t)
+	now := time.Now()
+	if err := store.Tokens.InsertJoinToken("t1", "hash", now.Add(time.Hour), ""); err != nil {
+		t.Fatalf("InsertJoinToken failed: %v", err)
+	}
+	if err := store.Tokens.InsertJoinToken("t2", "hash", now.Add(-time.Second), ""); err != nil {
+		t.Fatalf("InsertJoinToken failed: %v", err)
+	}
+	if ok, err := store.Tokens.ConsumeJoinToken("t1", "wrong", "node-1", now); err != nil || ok {
+		t.Fatalf("expected wrong secret to be rejected, got %v %v", ok, err)
+	}
+	if ok, err := store.Tokens.ConsumeJoinToken("t1", "hash", "node-1", now); err != nil || !ok {
+		t.Fatalf("expected token to be accepted, got %v %v", ok, err)
+	}
+	if ok, err := store.Tokens.ConsumeJoinToken("t1", "hash", "node-2", now); err != nil || ok {
+		t.Fatalf("expected used token to be rejected, got %v %v", ok, err)
+	}
+	if ok, err := store.Tokens.ConsumeJoinToken("t2", "hash", "node-2", now); err != nil || ok {
+		t.Fatalf("expected expired token to be rejected, got %v %v", ok, err)
+	}
+	if used, err := store.Tokens.JoinTokenUsedBy("node-1"); err != nil || !used {
+		t.Fatalf("expected node-1 to have joined, got %v %v", used, err)
+	}
+	if used, err := store.Tokens.JoinTokenUsedBy("node-2"); err != nil || used {
+		t.Fatalf("expected node-2 not to have joined, got %v %v", used, err)
+	}
+
+	// 同一节点 ID 不能用两个令牌加入
+	if err := store.Tokens.InsertJoinToken("t3", "hash", now.Add(time.Hour), ""); err != nil {
+		t.Fatalf("InsertJoinToken failed: %v", err)
+	}
+	if ok, err := store.Tokens.ConsumeJoinToken("t3", "hash", "node-1", now); err == nil || ok {
+		t.Fatalf("expected second token of node-1 to be rejected, got %v %v", ok, err)
+	}
+	// 绑定节点 ID 的令牌只能供该节点重新加入，不能用于新节点加入
+	if err := store.Tokens.InsertJoinToken("t4", "hash", now.Add(time.Hour), "node-1"); err != nil {
+		t.Fatalf("InsertJoinToken failed: %v", err)
+	}
+	if ok, err := store.Tokens.ConsumeJoinToken("t4", "hash", "node-2", now); err != nil || ok {
+		t.Fatalf("expected bound token to be rejected for a new node, got %v %v", ok, err)
+	}
+	if ok, err := store.Tokens.ConsumeRejoinToken("t4", "hash", "node-2", now); err != nil || ok {
+		t.Fatalf("expected bound token to be rejected for another node, got %v %v", ok, err)
+	}
+	if ok, err := store.Tokens.ConsumeRejoinToken("t4", "hash", "node-1", now); err != nil || !ok {
+		t.Fatalf("expected node-1 to rejoin, got %v %v", ok, err)
+	}
+	if ok, err := store.Tokens.ConsumeRejoinToken("t4", "hash", "node-1", now); err != nil || ok {
+		t.Fatalf("expected used rejoin token to be rejected, got %v %v", ok, err)
+	}
+}
+
+// 测试任务表版本号按节点持久化递增，且不小于指定的下限
+func TestSQLiteTaskGeneration(t *testing.T) {
+	store := openSQLiteStore(t)
+	steps := []struct {
+		node    string
+		atLeast uint64
+		want    uint64
+	}{
+		{"node-1", 0, 1},
+		{"node-1", 0, 2},
+		{"node-1", 10, 10},
+		{"node-1", 0, 11},
+		{"node-1", 5, 12},
+		{"node-2", 0, 1},
+	}
+	for _, step := range steps {
+		got, err := store.Generations.NextTaskGeneration(step.node, step.atLeast)
+		if err != nil {
+			t.Fatalf("NextTaskGeneration failed: %v", err)
+		}
+		if got != step.want {
+			t.Fatalf("%s at least %d: expected generation %d, got %d", step.node, step.atLeast, step.want, got)
+		}
+	}
+}
+
+// 测试链路历史聚合的保存、查询和按保留时长清理
+func TestSQLiteLinkHistory(t *testing.T) {
+	store := openSQLiteStore(t)
+	bucket := time.Now().Truncate(time.Minute)
+	rollups := []config.LinkRollup{
+		{SourceIP: "node-1", DestinationIP: "node-2", Bucket: bucket.Add(-2 * time.Minute), Samples: 2, Sent: 8, Received: 8, MinDelay: 1, AvgDelay: 2, P95Delay: 3},
+		{SourceIP: "node-1", DestinationIP: "node-2", Bucket: bucket.Add(-time.Minute), Samples: 1, Sent: 4, Received: 2, MinDelay: 2, AvgDelay: 4, P95Delay: 5, Loss: 0.5},
+		{SourceIP: "node-2", DestinationIP: "node-1", Bucket: bucket.Add(-time.Minute), Samples: 1, Sent: 4, Received: 4, MinDelay: 1, AvgDelay: 1, P95Delay: 1},
+	}
+	if err := store.History.SaveRollups(TierMinute, rollups); err != nil {
+		t.Fatalf("SaveRollups failed: %v", err)
+	}
+	// 同一区间的聚合被覆盖
+	rollups[1].AvgDelay = 5
+	if err := store.History.SaveRollups(TierMinute, rollups[1:2]); err != nil {
+		t.Fatalf("SaveRollups failed: %v", err)
+	}
+	link, err := store.History.LinkRollups(TierMinute, "node-1", "node-2", bucket.Add(-time.Hour), bucket)
+	if err != nil {
+		t.Fatalf("LinkRollups failed: %v", err)
+	}
+	if len(link) != 2 || !link[0].Bucket.Equal(rollups[0].Bucket) || link[1].AvgDelay != 5 || link[1].Loss != 0.5 {
+		t.Errorf("unexpected rollups of node-1:node-2: %+v", link)
+	}
+	all, err := store.History.Rollups(TierMinute, bucket.Add(-time.Minute), bucket)
+	if err != nil || len(all) != 2 {
+		t.Errorf("expected 2 rollups in the last minute, got %+v %v", all, err)
+	}
+	if all, _ := store.History.Rollups(TierHour, bucket.Add(-time.Hour), bucket); len(all) != 0 {
+		t.Errorf("expected no hourly rollups, got %+v", all)
+	}
+	if err := store.History.SaveRollups(TierRaw, rollups); err == nil {
+		t.Error("expected an error saving rollups to the raw tier")
+	}
+
+	if n, err := store.History.Prune(TierMinute, bucket.Add(-time.Minute)); err != nil || n != 1 {
+		t.Errorf("expected 1 minute rollup pruned, got %d %v", n, err)
+	}
+	old := time.Now().Add(-3 * time.Hour).Format("2006-01-02 15:04:05")
+	store.LinkStats.InsertLinkStats(config.LinkInfo{SourceIP: "node-1", DestinationIP: "node-2", Delay: 1, Timestamp: old})
+	store.LinkStats.InsertLinkStats(config.LinkInfo{SourceIP: "node-1", DestinationIP: "node-2", Delay: 2, Timestamp: time.Now().Format("2006-01-02 15:04:05")})
+	if n, err := store.History.Prune(TierRaw, time.Now().Add(-time.Hour)); err != nil || n != 1 {
+		t.Errorf("expected 1 raw link stat pruned, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"control/config"
+	"control/dao"
+	pb "control/proto"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// 查询的记录不存在
+var ErrNotFound = errors.New("not found")
+
+// 节点指标存储
+type MetricsStore interface {
+	// 保存节点上报的一次指标
+	InsertMetrics(m *pb.Metrics) error
+	// 查询上报过指标的节点 IP
+	QueryIPs() ([]string, error)
+}
+
+// 链路探测样本存储，按链路保存最近上报的探测结果，src、dst 为节点 ID
+// 每条链路的样本构成一个从新到旧排列的列表，数量和保存时长有上限
+type SampleStore interface {
+	// 追加一条 src 到 dst 的探测结果
+	AppendSample(src, dst string, result config.ProbeResult) error
+	// 读取 src 到 dst 最新的 n 条探测结果，按从新到旧排列
+	Samples(src, dst string, n int) ([]config.ProbeResult, error)
+}
+
+// 链路统计存储，保存由探测样本计算出的平均延迟和丢包率
+type LinkStatsStore interface {
+	// 保存一次链路统计结果
+	InsertLinkStats(link config.LinkInfo) error
+	// 查询每条链路最新一次的统计结果
+	LatestLinkStats() ([]config.LinkInfo, error)
+}
+
+// 链路历史的分层，原始层为 link_info 中每个计算周期的链路统计
+const (
+	TierRaw    = "raw"
+	TierMinute = "1m"
+	TierHour   = "1h"
+)
+
+// 链路历史存储，按 1 分钟和 1 小时两个粒度保存链路的延迟和丢包聚合，各层数据按保留时长清理
+type LinkHistoryStore interface {
+	// 保存 tier 层的聚合，同一链路同一区间的聚合已存在时覆盖
+	SaveRollups(tier string, rollups []config.LinkRollup) error
+	// 查询 src 到 dst 在 [from, to) 内 tier 层的聚合，按区间开始时间升序排列
+	LinkRollups(tier, src, dst string, from, to time.Time) ([]config.LinkRollup, error)
+	// 查询全部链路在 [from, to) 内 tier 层的聚合
+	Rollups(tier string, from, to time.Time) ([]config.LinkRollup, error)
+	// 删除 tier 层 before 之前的数据，tier 可以为 TierRaw，返回删除的行数
+	Prune(tier string, before time.Time) (int64, error)
+}
+
+// 节点存储
+type NodeStore interface {
+	// 注册节点，节点已存在时更新
+	UpsertNode(node config.NodeInfo) error
+	// 更新节点心跳时间，返回节点是否已注册
+	Heartbeat(id string, lastSeen time.Time) (bool, error)
+	// 将 deadline 之前没有心跳的节点标记为下线，返回下线的节点数
+	MarkDeadNodes(deadline time.Time) (int64, error)
+	// 查询 deadline 之后有过心跳的在线节点
+	AliveNodes(deadline time.Time) ([]config.NodeInfo, error)
+	// 按节点 ID 查询节点，节点不存在时返回 ErrNotFound
+	Node(id string) (config.NodeInfo, error)
+}
+
+// 节点加入令牌存储
+type JoinTokenStore interface {
+	// 保存加入令牌，只保存密钥的哈希，boundNodeID 非空时令牌只能供该节点重新加入
+	InsertJoinToken(id, secretHash string, expiresAt time.Time, boundNodeID string) error
+	// 使用未绑定节点的加入令牌，令牌有效时标记为已被 nodeID 使用并返回 true，nodeID 已用其他令牌加入时返回错误
+	ConsumeJoinToken(id, secretHash, nodeID string, now time.Time) (bool, error)
+	// 使用绑定 nodeID 的加入令牌重新加入，令牌有效时标记为已使用并返回 true
+	ConsumeRejoinToken(id, secretHash, nodeID string, now time.Time) (bool, error)
+	// 查询是否已有令牌被 nodeID 使用，即该节点 ID 已经加入过
+	JoinTokenUsedBy(nodeID string) (bool, error)
+}
+
+// 探测任务表版本号存储，每个节点一个持久化的计数器
+type TaskGenerationStore interface {
+	// 递增 nodeID 的版本号并返回，返回值不小于 atLeast
+	NextTaskGeneration(nodeID string, atLeast uint64) (uint64, error)
+}
+
+// 控制面的全部存储，服务只通过这些接口读写数据，不直接访问数据库和 redis
+type Store struct {
+	Metrics     MetricsStore
+	LinkSamples SampleStore
+	LinkStats   LinkStatsStore
+	History     LinkHistoryStore
+	Nodes       NodeStore
+	Tokens      JoinTokenStore
+	Generations TaskGenerationStore
+
+	driver    string      //数据库类型，决定使用的迁移
+	db        *sql.DB     //共享的数据库连接池
+	redisPool *redis.Pool //共享的redis连接池
+}
+
+// 按配置创建共享的数据库连接池和样本存储，样本保存在 redis 中时创建共享的 redis 连接池
+func Open(c config.ConfigInfo) (*Store, error) {
+	db, err := dao.OpenDB(c)
+	if err != nil {
+		return nil, err
+	}
+	sqlStore := &sqlStore{db: db}
+	store := &Store{
+		Metrics:     sqlStore,
+		LinkStats:   sqlStore,
+		History:     sqlStore,
+		Nodes:       sqlStore,
+		Tokens:      sqlStore,
+		Generations: sqlStore,
+		driver:      c.Driver,
+		db:          db,
+	}
+	// 样本保存在进程内存中时不需要 redis，控制面重启后样本丢失
+	if c.SampleStore == "memory" {
+		store.LinkSamples = NewMemorySampleStore(c.SampleCapacity, c.ExpireDuration)
+	} else {
+		store.redisPool = dao.NewRedisPool(c)
+		store.LinkSamples = &redisSampleStore{pool: store.redisPool, capacity: c.SampleCapacity, ttl: c.ExpireDuration}
+	}
+	return store, nil
+}
+
+// 确认数据库和 redis 可以连接
+func (s *Store) Ping(ctx context.Context) error {
+	if s.db != nil {
+		if err := s.db.PingContext(ctx); err != nil {
+			return err
+		}
+	}
+	if s.redisPool != nil {
+		conn, err := s.redisPool.GetContext(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err := conn.Do("PING"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 关闭连接池
+func (s *Store) Close() error {
+	var errs []error
+	if s.db != nil {
+		errs = append(errs, s.db.Close())
+	}
+	if s.redisPool != nil {
+		errs = append(errs, s.redisPool.Close())
+	}
+	return errors.Join(errs...)
+}
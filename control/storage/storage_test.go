@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"control/config"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// 按 redis 的语义实现样本存储用到的列表命令，不依赖 redis 服务验证样本的顺序
+type listConn struct {
+	lists map[string][][]byte
+}
+
+func (c *listConn) Close() error                      { return nil }
+func (c *listConn) Err() error                        { return nil }
+func (c *listConn) Send(string, ...interface{}) error { return nil }
+func (c *listConn) Flush() error                      { return nil }
+func (c *listConn) Receive() (interface{}, error)     { return nil, nil }
+
+func (c *listConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	switch cmd {
+	case "":
+		return nil, nil
+	case "LPUSH":
+		key := args[0].(string)
+		for _, value := range args[1:] {
+			c.lists[key] = append([][]byte{value.([]byte)}, c.lists[key]...)
+		}
+		return int64(len(c.lists[key])), nil
+	case "LTRIM":
+		key := args[0].(string)
+		start, stop := c.span(key, args[1].(int), args[2].(int))
+		c.lists[key] = c.lists[key][start:stop]
+		return "OK", nil
+	case "LRANGE":
+		key := args[0].(string)
+		start, stop := c.span(key, args[1].(int), args[2].(int))
+		values := make([]interface{}, 0, stop-start)
+		for _, value := range c.lists[key][start:stop] {
+			values = append(values, value)
+		}
+		return values, nil
+	case "EXPIRE":
+		return int64(1), nil
+	}
+	return nil, fmt.Errorf("unsupported command %s", cmd)
+}
+
+// 把 LRANGE、LTRIM 的闭区间下标换算为切片范围，负数下标从列表末尾起算
+func (c *listConn) span(key string, start, stop int) (int, int) {
+	n := len(c.lists[key])
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop += n
+	}
+	stop = min(stop+1, n)
+	if start >= stop {
+		return 0, 0
+	}
+	return start, stop
+}
+
+// 测试 redis 样本存储按从新到旧返回最新的样本，超出容量时丢弃最旧的样本
+func TestRedisSampleStoreOrder(t *testing.T) {
+	conn := &listConn{lists: map[string][][]byte{}}
+	store := &redisSampleStore{
+		pool:     &redis.Pool{Dial: func() (redis.Conn, error) { return conn, nil }},
+		capacity: 3,
+		ttl:      time.Hour,
+	}
+	for i := int64(1); i <= 5; i++ {
+		if err := store.AppendSample("node-1", "node-2", config.ProbeResult{AvgDelay: i}); err != nil {
+			t.Fatalf("AppendSample failed: %v", err)
+		}
+	}
+	samples, err := store.Samples("node-1", "node-2", 10)
+	if err != nil {
+		t.Fatalf("Samples failed: %v", err)
+	}
+	if len(samples) != 3 || samples[0].AvgDelay != 5 || samples[1].AvgDelay != 4 || samples[2].AvgDelay != 3 {
+		t.Fatalf("expected samples 5 4 3 from newest to oldest, got %+v", samples)
+	}
+	if newest, _ := store.Samples("node-1", "node-2", 2); len(newest) != 2 || newest[0].AvgDelay != 5 || newest[1].AvgDelay != 4 {
+		t.Fatalf("expected the 2 newest samples, got %+v", newest)
+	}
+}
+
+// 测试 redis 样本存储的写入、读取和过期时间，redis 不可用时跳过
+func TestRedisSampleStore(t *testing.T) {
+	c := config.Default()
+	store, err := Open(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	conn := store.redisPool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		t.Skipf("redis is not available: %v", err)
+	}
+	src, dst := "test-node-1", "test-node-2"
+	key := sampleKey(src, dst)
+	conn.Do("DEL", key)
+	defer conn.Do("DEL", key)
+
+	for i := int64(1); i <= 3; i++ {
+		if err := store.LinkSamples.AppendSample(src, dst, config.ProbeResult{SourceID: src, DestinationID: dst, AvgDelay: i * 1000}); err != nil {
+			t.Fatalf("AppendSample failed: %v", err)
+		}
+	}
+	samples, err := store.LinkSamples.Samples(src, dst, 10)
+	if err != nil {
+		t.Fatalf("Samples failed: %v", err)
+	}
+	if len(samples) != 3 || samples[0].AvgDelay != 3000 || samples[2].AvgDelay != 1000 {
+		t.Fatalf("expected 3 samples from newest to oldest, got %+v", samples)
+	}
+	if newest, _ := store.LinkSamples.Samples(src, dst, 1); len(newest) != 1 || newest[0].AvgDelay != 3000 {
+		t.Fatalf("expected the newest sample, got %+v", newest)
+	}
+	ttl, err := conn.Do("TTL", key)
+	if err != nil || ttl.(int64) <= 0 || ttl.(int64) > int64(c.ExpireDuration/time.Second) {
+		t.Errorf("expected expiry within %v, got %v %v", c.ExpireDuration, ttl, err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/identity"
+	"dataPlane/internal/agent/node"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"time"
+)
+
+// 使用控制面签发的加入令牌换取本节点证书和配置，写入工作目录后退出
+// 用法：dataplane join --token <token> --control host:port --ca-hash sha256:...
+// 令牌和证书经由这次连接传输，必须用 --ca-hash 校验控制面，只有显式指定 --insecure 时才信任首次连接的控制面
+// 已加入的节点（例如证书过期）使用控制面 token --node <node-id> 生成的令牌，加上 --force 重新加入
+func runJoin(args []string) {
+	fs := flag.NewFlagSet("join", flag.ExitOnError)
+	token := fs.String("token", "", "控制面 token 命令生成的加入令牌")
+	control := fs.String("control", "", "控制面地址 host:port")
+	caHash := fs.String("ca-hash", "", "控制面 CA 的指纹 sha256:<hex>，由控制面 token 命令打印")
+	insecure := fs.Bool("insecure", false, "不校验控制面 CA 的指纹，信任首次连接的控制面，连接可能被中间人截获令牌和证书")
+	force := fs.Bool("force", false, "已有证书时重新加入，令牌须由控制面 token --node 为本节点 ID 生成")
+	path := fs.String("config", config.File, "配置文件路径，证书和节点 ID 的路径取自其中，加入后写入控制面下发的配置")
+	fs.Parse(args)
+
+	if *token == "" || *control == "" {
+		log.Fatal("--token and --control are required")
+	}
+	if *caHash == "" && !*insecure {
+		log.Fatal("--ca-hash is required, use --insecure to trust the control plane on first use")
+	}
+	if *caHash != "" && *insecure {
+		log.Fatal("--ca-hash and --insecure are mutually exclusive")
+	}
+	// 在已有配置的基础上更新，保留本地设置的端口、标签等
+	cfg := config.Default()
+	if err := cfg.Load(*path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("Failed to load config %s: %v", *path, err)
+	}
+	if _, err := os.Stat(cfg.CertFile); err == nil && !*force {
+		log.Fatalf("Certificate %s already exists, use --force with a token created by control token --node <node-id> to join again", cfg.CertFile)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatal(err)
+	}
+	nodeID, err := identity.LoadOrCreateNodeID(cfg.IDFile)
+	if err != nil {
+		log.Fatalf("Failed to load node ID: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	joined, err := node.Join(ctx, cfg, *control, *token, *caHash, nodeID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg.ControlAddr = joined.ControlAddr
+	cfg.MetricsAddr = joined.MetricsAddr
+	if joined.HeartbeatInterval > 0 {
+		cfg.HeartbeatInterval = time.Duration(joined.HeartbeatInterval) * time.Second
+	}
+	cfg.TLSEnabled = true
+	if err := cfg.Save(*path); err != nil {
+		log.Fatalf("Failed to save config: %v", err)
+	}
+	log.Printf("Node %s joined, certificate written to %s, config written to %s", nodeID, cfg.CertFile, *path)
+}
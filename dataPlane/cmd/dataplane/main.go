@@ -1,18 +1,63 @@
 package main
 
 import (
+	"context"
+	"dataPlane/internal/agent/channel"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/identity"
 	"dataPlane/internal/agent/metrics" //
+	"dataPlane/internal/agent/node"
 	"dataPlane/internal/agent/probe"
+	"dataPlane/internal/router"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/panjf2000/ants/v2" // 引入 ants 包
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
+	// 子命令 join：使用加入令牌换取证书和配置后退出
+	if len(os.Args) > 1 && os.Args[1] == "join" {
+		runJoin(os.Args[2:])
+		return
+	}
+
+	// 依次叠加默认值、配置文件、环境变量和命令行参数，校验通过后传给各模块
+	cfg, err := config.Parse("dataplane", os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// 收到退出信号时取消 ctx，停止长连接和心跳
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	log.Println("Starting metrics collection with ants goroutine pool...")
 
-	// 创建一个固定大小的协程池，这里假设池大小为10
-	poolSize := 10
-	pool, err := ants.NewPool(poolSize)
+	// 加载节点身份：持久化的节点 ID 和对外通告地址
+	id, err := identity.Load(cfg.IDFile, cfg.AdvertiseAddrs)
+	if err != nil {
+		log.Fatalf("Failed to load node identity: %v", err)
+	}
+	log.Printf("Node ID: %s, advertised addresses: %v", id.NodeID, id.Addresses)
+
+	// 建立到控制面的长连接，经由它接收探测任务和路由表、上报指标和探测结果，断线后自动重连
+	ch := channel.NewClient(cfg, id.NodeID)
+	ch.OnProbeTasks = probe.ApplyProbeTasks
+	ch.OnRoutes = router.NewRouteServiceServer(router.DefaultRouteTable()).Apply
+	channel.SetDefault(ch)
+	go ch.Run(ctx)
+
+	// 创建固定大小的协程池
+	pool, err := ants.NewPool(cfg.PoolSize)
 	if err != nil {
 		log.Fatalf("Failed to create ants pool: %v", err)
 	}
@@ -20,7 +65,7 @@ func main() {
 
 	// 向协程池提交第一个任务：开始指标收集
 	err = pool.Submit(func() {
-		metrics.StartMetricsCollection()
+		metrics.StartMetricsCollection(cfg, id)
 	})
 	if err != nil {
 		log.Fatalf("Failed to submit task to ants pool: %v", err)
@@ -28,12 +73,28 @@ func main() {
 
 	// 向协程池提交第二个任务：执行TCP探测
 	err = pool.Submit(func() {
-		probe.StartTcp_probe()
+		probe.StartTcp_probe(cfg)
 	})
 	if err != nil {
 		log.Fatalf("Failed to submit task to ants pool: %v", err)
 	}
 
-	// 阻塞主协程，防止程序退出
-	select {}
+	// 向协程池提交第三个任务：向控制面注册并上报心跳
+	err = pool.Submit(func() {
+		if err := node.StartHeartbeat(ctx, cfg, id.NodeID, id.Addresses); err != nil {
+			log.Fatalf("Failed to start heartbeat: %v", err)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to submit task to ants pool: %v", err)
+	}
+
+	// 启动中继转发器，按控制面下发的路由表转发流量
+	if _, err := router.StartForwarder(cfg, id.PrimaryAddress(), id.Addresses); err != nil {
+		log.Printf("Failed to start relay forwarder: %v", err)
+	}
+
+	// 阻塞主协程直到收到退出信号
+	<-ctx.Done()
+	log.Println("Shutting down")
 }
@@ -3,7 +3,10 @@ module dataPlane
 go 1.22.4
 
 require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/panjf2000/ants/v2 v2.11.2
 	github.com/shirou/gopsutil/v3 v3.24.5
+	golang.org/x/net v0.34.0
 	google.golang.org/grpc v1.71.0
 	google.golang.org/protobuf v1.36.4
 )
@@ -11,13 +14,11 @@ require (
 require (
 	github.com/go-ole/go-ole v1.2.6 // indirect
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
-	github.com/panjf2000/ants/v2 v2.11.2 // indirect
 	github.com/power-devops/perfstat v0.0.0-20210106213030-5aafc221ea8c // indirect
 	github.com/shoenig/go-m1cpu v0.1.6 // indirect
 	github.com/tklauser/go-sysconf v0.3.12 // indirect
 	github.com/tklauser/numcpus v0.6.1 // indirect
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
-	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
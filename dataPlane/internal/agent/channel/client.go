@@ -0,0 +1,202 @@
+package channel
+
+import (
+	"context"
+	"dataPlane/internal/agent/channel/protocol"
+	"dataPlane/internal/agent/config"
+	metricsproto "dataPlane/internal/agent/metrics/protocol"
+	"dataPlane/internal/agent/pki"
+	probeproto "dataPlane/internal/agent/probe/protocol"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// 包级全局变量，可在外部修改
+var (
+	MinBackoff    = time.Second      // 断线后首次重连的等待时间
+	MaxBackoff    = 30 * time.Second // 重连等待时间的上限
+	SendQueueSize = 256              // 待发送消息队列长度，断线期间的消息暂存于此
+)
+
+// ErrQueueFull 待发送消息队列已满
+var ErrQueueFull = errors.New("channel send queue is full")
+
+// Client 维护由数据面发起的到控制面的双向流，断线后按指数退避自动重连
+type Client struct {
+	cfg       *config.Config // 节点配置，取其中的控制面地址和 TLS 证书
+	nodeID    string
+	out       chan *protocol.AgentMessage
+	connected atomic.Bool
+
+	// 收到控制面下发的探测任务时调用
+	OnProbeTasks func(*probeproto.ProbeTaskRequest) *probeproto.ProbeTaskResponse
+	// 收到控制面下发的路由表时调用，返回值作为应答发回控制面
+	OnRoutes func(*probeproto.RouteTableRequest) *probeproto.RouteTableResponse
+}
+
+// NewClient 创建连接 cfg.ControlAddr 的长连接客户端，调用 Run 后开始连接
+func NewClient(cfg *config.Config, nodeID string) *Client {
+	return &Client{
+		cfg:    cfg,
+		nodeID: nodeID,
+		out:    make(chan *protocol.AgentMessage, SendQueueSize),
+	}
+}
+
+// Connected 当前是否与控制面保持连接，控制面接受 hello 后才为 true
+func (c *Client) Connected() bool {
+	return c.connected.Load()
+}
+
+// SendMetrics 上报节点指标，断线期间先进入队列，重连后发送
+func (c *Client) SendMetrics(metrics *metricsproto.Metrics) error {
+	return c.enqueue(&protocol.AgentMessage{Payload: &protocol.AgentMessage_Metrics{Metrics: metrics}})
+}
+
+// SendProbeResults 上报探测结果，断线期间先进入队列，重连后发送
+func (c *Client) SendProbeResults(req *probeproto.ProbeResultRequest) error {
+	return c.enqueue(&protocol.AgentMessage{Payload: &protocol.AgentMessage_ProbeResults{ProbeResults: req}})
+}
+
+func (c *Client) enqueue(msg *protocol.AgentMessage) error {
+	select {
+	case c.out <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run 建立并保持与控制面的双向流，断线后重连，直到 ctx 取消
+func (c *Client) Run(ctx context.Context) {
+	backoff := MinBackoff
+	for {
+		start := time.Now()
+		err := c.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// 连接保持了足够长的时间才断开，说明控制面正常，从最短等待时间重新退避
+		if time.Since(start) > MaxBackoff {
+			backoff = MinBackoff
+		}
+		// 在退避时间的 [1/2, 1] 之间随机等待，避免大量节点同时重连
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		log.Printf("Channel to control plane closed: %v, reconnecting in %v", err, wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		backoff *= 2
+		if backoff > MaxBackoff {
+			backoff = MaxBackoff
+		}
+	}
+}
+
+// session 建立一次双向流并收发消息，返回断开的原因
+func (c *Client) session(ctx context.Context) error {
+	creds, err := pki.DialOption(c.cfg)
+	if err != nil {
+		return err
+	}
+	conn, err := grpc.Dial(c.cfg.ControlAddr, creds)
+	if err != nil {
+		return fmt.Errorf("failed to connect to control plane: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := protocol.NewChannelServiceClient(conn).Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %v", err)
+	}
+	hello := &protocol.AgentMessage{Payload: &protocol.AgentMessage_Hello{Hello: &protocol.Hello{NodeId: c.nodeID}}}
+	if err := stream.Send(hello); err != nil {
+		return fmt.Errorf("failed to send hello: %v", err)
+	}
+	// 控制面接受 hello 后发送响应头，拒绝时不发送响应头直接结束流，原因由 Recv 返回
+	// 收到响应头前不发送队列中的消息，被拒绝时消息留在队列中
+	if md, err := stream.Header(); err != nil || md == nil {
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		return fmt.Errorf("channel rejected by control plane: %w", err)
+	}
+	c.connected.Store(true)
+	defer c.connected.Store(false)
+	log.Printf("Channel to control plane %s established, node ID: %s", c.cfg.ControlAddr, c.nodeID)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.receive(stream)
+	}()
+	for {
+		select {
+		case err := <-errc:
+			return err
+		case msg := <-c.out:
+			if err := stream.Send(msg); err != nil {
+				// 发送失败的消息放回队列，重连后重新发送
+				c.enqueue(msg)
+				return err
+			}
+		}
+	}
+}
+
+// receive 处理控制面下发的消息，直到流断开
+func (c *Client) receive(stream protocol.ChannelService_ConnectClient) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		switch payload := msg.Payload.(type) {
+		case *protocol.ControlMessage_ProbeTasks:
+			if c.OnProbeTasks == nil {
+				continue
+			}
+			if resp := c.OnProbeTasks(payload.ProbeTasks); resp != nil {
+				c.enqueue(&protocol.AgentMessage{Payload: &protocol.AgentMessage_TaskAck{TaskAck: resp}})
+			}
+		case *protocol.ControlMessage_Routes:
+			if c.OnRoutes == nil {
+				continue
+			}
+			if resp := c.OnRoutes(payload.Routes); resp != nil {
+				c.enqueue(&protocol.AgentMessage{Payload: &protocol.AgentMessage_RouteAck{RouteAck: resp}})
+			}
+		default:
+			log.Printf("Unknown message from control plane: %T", msg.Payload)
+		}
+	}
+}
+
+var (
+	defaultClient *Client
+	defaultMutex  sync.RWMutex
+)
+
+// SetDefault 设置全局长连接客户端，指标和探测结果优先经由它上报
+func SetDefault(c *Client) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultClient = c
+}
+
+// Default 获取全局长连接客户端，未设置时返回 nil
+func Default() *Client {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultClient
+}
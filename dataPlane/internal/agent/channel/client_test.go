@@ -0,0 +1,178 @@
+package channel
+
+import (
+	"context"
+	"dataPlane/internal/agent/channel/protocol"
+	"dataPlane/internal/agent/config"
+	metricsproto "dataPlane/internal/agent/metrics/protocol"
+	probeproto "dataPlane/internal/agent/probe/protocol"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// mockChannelServer 模拟控制面的 ChannelService，每个连接收到 hello 后下发一次路由表
+type mockChannelServer struct {
+	protocol.UnimplementedChannelServiceServer
+	hellos   chan string
+	received chan *protocol.AgentMessage
+	// 收到信号后断开当前连接，模拟控制面重启
+	drop chan struct{}
+}
+
+func (s *mockChannelServer) Connect(stream protocol.ChannelService_ConnectServer) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	s.hellos <- msg.GetHello().GetNodeId()
+	err = stream.Send(&protocol.ControlMessage{Payload: &protocol.ControlMessage_Routes{
+		Routes: &probeproto.RouteTableRequest{Version: 7},
+	}})
+	if err != nil {
+		return err
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				errc <- err
+				return
+			}
+			s.received <- msg
+		}
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-s.drop:
+		return nil
+	}
+}
+
+// TestClientReconnect 测试握手、路由表应答、断线重连以及断线期间消息的缓存
+func TestClientReconnect(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	mockSrv := &mockChannelServer{
+		hellos:   make(chan string, 4),
+		received: make(chan *protocol.AgentMessage, 16),
+		drop:     make(chan struct{}, 1),
+	}
+	grpcServer := grpc.NewServer()
+	protocol.RegisterChannelServiceServer(grpcServer, mockSrv)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	originalMin, originalMax := MinBackoff, MaxBackoff
+	MinBackoff, MaxBackoff = 10*time.Millisecond, 50*time.Millisecond
+	defer func() { MinBackoff, MaxBackoff = originalMin, originalMax }()
+
+	// 模拟服务端未启用 TLS
+	cfg := config.Default()
+	cfg.ControlAddr = lis.Addr().String()
+	cfg.TLSEnabled = false
+	c := NewClient(cfg, "node-1")
+	c.OnRoutes = func(req *probeproto.RouteTableRequest) *probeproto.RouteTableResponse {
+		return &probeproto.RouteTableResponse{Status: "ok", AppliedVersion: req.Version}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	expectHello := func() {
+		select {
+		case id := <-mockSrv.hellos:
+			if id != "node-1" {
+				t.Fatalf("unexpected node ID in hello: %s", id)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for hello")
+		}
+	}
+	expectMessage := func() *protocol.AgentMessage {
+		select {
+		case msg := <-mockSrv.received:
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for message")
+			return nil
+		}
+	}
+
+	expectHello()
+	if ack := expectMessage().GetRouteAck(); ack == nil || ack.AppliedVersion != 7 {
+		t.Fatalf("expected route ack for version 7, got %v", ack)
+	}
+
+	// 断开连接，断线期间上报的指标在重连后送达
+	mockSrv.drop <- struct{}{}
+	for c.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+	if err := c.SendMetrics(&metricsproto.Metrics{NodeId: "node-1"}); err != nil {
+		t.Fatalf("SendMetrics failed: %v", err)
+	}
+	expectHello()
+
+	var gotMetrics bool
+	for i := 0; i < 2; i++ {
+		msg := expectMessage()
+		if m := msg.GetMetrics(); m != nil && m.NodeId == "node-1" {
+			gotMetrics = true
+		}
+	}
+	if !gotMetrics {
+		t.Fatal("metrics queued while disconnected were not delivered")
+	}
+}
+
+// rejectingChannelServer 模拟拒绝未注册节点的控制面，收到 hello 后直接结束流
+type rejectingChannelServer struct {
+	protocol.UnimplementedChannelServiceServer
+	hellos chan string
+}
+
+func (s *rejectingChannelServer) Connect(stream protocol.ChannelService_ConnectServer) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	s.hellos <- msg.GetHello().GetNodeId()
+	return status.Error(codes.PermissionDenied, "node is not registered")
+}
+
+// TestClientRejected 测试控制面拒绝 hello 时不视为已连接，队列中的消息不被取出
+func TestClientRejected(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	mockSrv := &rejectingChannelServer{hellos: make(chan string, 1)}
+	grpcServer := grpc.NewServer()
+	protocol.RegisterChannelServiceServer(grpcServer, mockSrv)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	cfg := config.Default()
+	cfg.ControlAddr = lis.Addr().String()
+	cfg.TLSEnabled = false
+	c := NewClient(cfg, "node-1")
+	if err := c.SendMetrics(&metricsproto.Metrics{NodeId: "node-1"}); err != nil {
+		t.Fatalf("SendMetrics failed: %v", err)
+	}
+	if err := c.session(context.Background()); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected the channel to be rejected, got %v", err)
+	}
+	if c.Connected() || len(c.out) != 1 {
+		t.Fatalf("expected the queued message to stay queued, connected=%v queued=%d", c.Connected(), len(c.out))
+	}
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// 包级全局变量，可在外部修改
+var File = "agent.toml" // 默认配置文件，加入控制面时由 join 命令生成
+
+// 环境变量前缀，每个命令行参数都有对应的环境变量，例如 -control-addr 对应 SIRIUS_CONTROL_ADDR
+const EnvPrefix = "SIRIUS_"
+
+// Config 节点配置，优先级从低到高依次为默认值、配置文件、环境变量、命令行参数
+type Config struct {
+	ControlAddr       string            // 控制面注册、长连接和探测结果上报地址
+	MetricsAddr       string            // 控制面指标上报地址
+	HeartbeatInterval time.Duration     // 默认心跳间隔，注册成功后以控制面返回的为准
+	MetricsInterval   time.Duration     // 指标上报间隔
+	ProbeInterval     time.Duration     // 探测间隔
+	ProbePort         int               // 接收探测任务和路由表的端口
+	RelayPort         int               // 中继监听端口，所有节点使用同一端口
+	RelayTargets      []string          // 作为出口节点时除本节点通告地址外允许连接的目标，IP 或 CIDR
+	ResponderPort     int               // 回显服务的 TCP、UDP 端口，所有节点使用同一端口
+	PoolSize          int               // 协程池大小
+	ProbeConcurrency  int               // 同时执行的探测数
+	ProbeJitter       time.Duration     // 每个探测开始时间的随机抖动上限
+	Collectors        []string          // 启用的指标采集项
+	IDFile            string            // 节点 ID 持久化文件
+	AdvertiseAddrs    []string          // 对外通告地址，为空时自动探测本机网卡地址
+	Labels            map[string]string // 本节点标签，例如地域、运营商
+	TLSEnabled        bool              // 是否启用双向 TLS
+	CAFile            string            // 控制面内置 CA 的证书
+	CertFile          string            // 本节点证书
+	KeyFile           string            // 本节点私钥
+}
+
+// AllCollectors 全部指标采集项
+var AllCollectors = []string{"cpu", "memory", "disk", "network", "host", "load"}
+
+// 节点的状态目录，持久化的节点 ID 默认保存在此，不随工作目录变化
+const StateDir = "/var/lib/sirius"
+
+// Default 默认配置，控制面地址没有默认值，须由配置文件、环境变量或命令行参数指定
+func Default() *Config {
+	return &Config{
+		MetricsAddr:       "localhost:50051",
+		HeartbeatInterval: 10 * time.Second,
+		MetricsInterval:   30 * time.Second,
+		ProbeInterval:     10 * time.Second,
+		ProbePort:         50051,
+		RelayPort:         50053,
+		ResponderPort:     50054,
+		PoolSize:          10,
+		ProbeConcurrency:  10,
+		ProbeJitter:       500 * time.Millisecond,
+		Collectors:        slices.Clone(AllCollectors),
+		IDFile:            filepath.Join(StateDir, "node_id"),
+		TLSEnabled:        true,
+		CAFile:            "ca.crt",
+		CertFile:          "node.crt",
+		KeyFile:           "node.key",
+	}
+}
+
+// Load 在 c 的基础上读取配置文件，文件中没有的项保持原值
+// 文件不存在时返回的错误满足 errors.Is(err, os.ErrNotExist)
+func (c *Config) Load(path string) error {
+	_, err := toml.DecodeFile(path, c)
+	return err
+}
+
+// Load 读取配置文件，文件中没有的项使用默认值
+func Load(path string) (*Config, error) {
+	c := Default()
+	if err := c.Load(path); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save 写入配置文件
+func (c *Config) Save(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := toml.NewEncoder(f).Encode(c); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// flagSet 定义与配置项一一对应的命令行参数，解析结果直接写入 c
+func (c *Config) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&c.ControlAddr, "control-addr", c.ControlAddr, "控制面注册、长连接和探测结果上报地址")
+	fs.StringVar(&c.MetricsAddr, "metrics-addr", c.MetricsAddr, "控制面指标上报地址")
+	fs.DurationVar(&c.HeartbeatInterval, "heartbeat-interval", c.HeartbeatInterval, "默认心跳间隔")
+	fs.DurationVar(&c.MetricsInterval, "metrics-interval", c.MetricsInterval, "指标上报间隔")
+	fs.DurationVar(&c.ProbeInterval, "probe-interval", c.ProbeInterval, "探测间隔")
+	fs.IntVar(&c.ProbePort, "probe-port", c.ProbePort, "接收探测任务和路由表的端口")
+	fs.IntVar(&c.RelayPort, "relay-port", c.RelayPort, "中继监听端口")
+	fs.Var((*listValue)(&c.RelayTargets), "relay-targets", "作为出口节点时除本节点通告地址外允许连接的目标，IP 或 CIDR，逗号分隔")
+	fs.IntVar(&c.ResponderPort, "responder-port", c.ResponderPort, "回显服务的 TCP、UDP 端口")
+	fs.IntVar(&c.PoolSize, "pool-size", c.PoolSize, "协程池大小")
+	fs.IntVar(&c.ProbeConcurrency, "probe-concurrency", c.ProbeConcurrency, "同时执行的探测数")
+	fs.DurationVar(&c.ProbeJitter, "probe-jitter", c.ProbeJitter, "每个探测开始时间的随机抖动上限")
+	fs.Var((*listValue)(&c.Collectors), "collectors", "启用的指标采集项，逗号分隔，可选 "+strings.Join(AllCollectors, ","))
+	fs.StringVar(&c.IDFile, "id-file", c.IDFile, "节点 ID 持久化文件")
+	fs.Var((*listValue)(&c.AdvertiseAddrs), "advertise-addrs", "对外通告地址，逗号分隔，为空时自动探测")
+	fs.Var((*mapValue)(&c.Labels), "labels", "本节点标签，格式 key=value，逗号分隔")
+	fs.BoolVar(&c.TLSEnabled, "tls", c.TLSEnabled, "是否启用双向 TLS")
+	fs.StringVar(&c.CAFile, "ca-file", c.CAFile, "控制面 CA 证书")
+	fs.StringVar(&c.CertFile, "cert-file", c.CertFile, "本节点证书")
+	fs.StringVar(&c.KeyFile, "key-file", c.KeyFile, "本节点私钥")
+	return fs
+}
+
+// EnvName 命令行参数对应的环境变量名
+func EnvName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Parse 解析命令行参数，依次叠加默认值、配置文件、环境变量和命令行参数并校验
+// 配置文件路径由 -config 或环境变量 SIRIUS_CONFIG 指定，未指定且默认文件不存在时只使用默认值
+func Parse(name string, args []string) (*Config, error) {
+	// 第一遍解析只为取得配置文件路径和命令行中出现的参数
+	fs := Default().flagSet(name)
+	path := fs.String("config", File, "配置文件路径")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		explicit = explicit || f.Name == "config"
+	})
+	if v, ok := os.LookupEnv(EnvName("config")); ok && !explicit {
+		*path, explicit = v, true
+	}
+
+	c := Default()
+	if err := c.Load(*path); err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+		return nil, fmt.Errorf("failed to load config %s: %v", *path, err)
+	}
+	target := c.flagSet(name)
+	var errs []error
+	target.VisitAll(func(f *flag.Flag) {
+		if v, ok := os.LookupEnv(EnvName(f.Name)); ok {
+			if err := target.Set(f.Name, v); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %v", EnvName(f.Name), err))
+			}
+		}
+	})
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "config" {
+			target.Set(f.Name, f.Value.String())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate 校验配置，返回全部不合法的配置项
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	for name, addr := range map[string]string{"ControlAddr": c.ControlAddr, "MetricsAddr": c.MetricsAddr} {
+		_, port, err := net.SplitHostPort(addr)
+		check(err == nil && port != "", "%s: %q is not a valid host:port address", name, addr)
+	}
+	check(c.HeartbeatInterval > 0, "HeartbeatInterval: must be positive, got %v", c.HeartbeatInterval)
+	check(c.MetricsInterval > 0, "MetricsInterval: must be positive, got %v", c.MetricsInterval)
+	check(c.ProbeInterval > 0, "ProbeInterval: must be positive, got %v", c.ProbeInterval)
+	check(c.ProbePort > 0 && c.ProbePort < 65536, "ProbePort: %d is not a valid port", c.ProbePort)
+	check(c.RelayPort > 0 && c.RelayPort < 65536, "RelayPort: %d is not a valid port", c.RelayPort)
+	check(c.ProbePort != c.RelayPort, "RelayPort: must differ from ProbePort %d", c.ProbePort)
+	for _, target := range c.RelayTargets {
+		_, _, err := net.ParseCIDR(target)
+		check(err == nil || net.ParseIP(target) != nil, "RelayTargets: %q is not an IP address or CIDR", target)
+	}
+	check(c.ResponderPort > 0 && c.ResponderPort < 65536, "ResponderPort: %d is not a valid port", c.ResponderPort)
+	check(c.ResponderPort != c.ProbePort && c.ResponderPort != c.RelayPort, "ResponderPort: must differ from ProbePort %d and RelayPort %d", c.ProbePort, c.RelayPort)
+	check(c.PoolSize > 0, "PoolSize: must be positive, got %d", c.PoolSize)
+	check(c.ProbeConcurrency > 0, "ProbeConcurrency: must be positive, got %d", c.ProbeConcurrency)
+	check(c.ProbeJitter >= 0 && c.ProbeJitter < c.ProbeInterval, "ProbeJitter: must be in [0, ProbeInterval), got %v", c.ProbeJitter)
+	for _, collector := range c.Collectors {
+		check(slices.Contains(AllCollectors, collector), "Collectors: unknown collector %q, expected one of %s", collector, strings.Join(AllCollectors, ","))
+	}
+	check(c.IDFile != "", "IDFile: must not be empty")
+	for _, addr := range c.AdvertiseAddrs {
+		check(net.ParseIP(addr) != nil, "AdvertiseAddrs: %q is not an IP address", addr)
+	}
+	for key := range c.Labels {
+		check(key != "", "Labels: empty label key")
+	}
+	if c.TLSEnabled {
+		check(c.CAFile != "" && c.CertFile != "" && c.KeyFile != "", "CAFile, CertFile, KeyFile: required when TLS is enabled")
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// listValue 逗号分隔的列表参数，每次设置都替换原值
+type listValue []string
+
+func (v *listValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return strings.Join(*v, ",")
+}
+
+func (v *listValue) Set(s string) error {
+	*v = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v = append(*v, item)
+		}
+	}
+	return nil
+}
+
+// mapValue 逗号分隔的 key=value 参数，每次设置都替换原值
+type mapValue map[string]string
+
+func (v *mapValue) String() string {
+	if v == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*v))
+	for key, value := range *v {
+		pairs = append(pairs, key+"="+value)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v *mapValue) Set(s string) error {
+	m := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("label %q is not key=value", pair)
+		}
+		m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	*v = m
+	return nil
+}
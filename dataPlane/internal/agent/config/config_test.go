@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试配置文件的写入和读取
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.toml")
+	if _, err := Load(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+
+	c := Default()
+	c.ControlAddr = "10.0.0.1:8081"
+	c.MetricsAddr = "10.0.0.1:8080"
+	c.HeartbeatInterval = 5 * time.Second
+	c.Labels = map[string]string{"region": "bj"}
+	if err := c.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, c) {
+		t.Fatalf("expected %+v, got %+v", c, loaded)
+	}
+}
+
+// 测试配置来源的优先级：命令行参数 > 环境变量 > 配置文件 > 默认值
+func TestParsePrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.toml")
+	content := `
+ControlAddr = "file:8081"
+MetricsAddr = "file:8080"
+ProbeInterval = "20s"
+PoolSize = 4
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SIRIUS_CONFIG", path)
+	t.Setenv("SIRIUS_METRICS_ADDR", "env:8080")
+	t.Setenv("SIRIUS_POOL_SIZE", "6")
+	t.Setenv("SIRIUS_LABELS", "region=bj,isp=cmcc")
+
+	c, err := Parse("test", []string{"-pool-size", "8", "-collectors", "cpu,load"})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if c.ControlAddr != "file:8081" || c.ProbeInterval != 20*time.Second {
+		t.Fatalf("file values not loaded: %+v", c)
+	}
+	if c.MetricsAddr != "env:8080" || c.Labels["isp"] != "cmcc" {
+		t.Fatalf("environment overrides not applied: %+v", c)
+	}
+	if c.PoolSize != 8 || !reflect.DeepEqual(c.Collectors, []string{"cpu", "load"}) {
+		t.Fatalf("flags not applied: %+v", c)
+	}
+	if c.MetricsInterval != Default().MetricsInterval {
+		t.Fatalf("expected default metrics interval, got %v", c.MetricsInterval)
+	}
+
+	// 显式指定的配置文件不存在时报错
+	if _, err := Parse("test", []string{"-config", filepath.Join(t.TempDir(), "missing.toml")}); err == nil {
+		t.Fatal("expected missing config file to be rejected")
+	}
+	t.Setenv("SIRIUS_POOL_SIZE", "many")
+	if _, err := Parse("test", nil); err == nil || !strings.Contains(err.Error(), "SIRIUS_POOL_SIZE") {
+		t.Fatalf("expected invalid environment variable to be reported, got %v", err)
+	}
+}
+
+// 测试校验报告全部不合法的配置项
+func TestValidate(t *testing.T) {
+	c := Default()
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "ControlAddr") {
+		t.Fatalf("expected ControlAddr to be required, got %v", err)
+	}
+	c.ControlAddr = "10.0.0.1:8081"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("expected default config with ControlAddr to be valid, got %v", err)
+	}
+	c.ControlAddr = "no-port"
+	c.ProbeInterval = 0
+	c.ProbePort = 70000
+	c.ProbeConcurrency = 0
+	c.Collectors = []string{"gpu"}
+	c.AdvertiseAddrs = []string{"not-an-ip"}
+	c.RelayTargets = []string{"10.0.0.0/8", "10.0.0.0/33"}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("expected invalid config to be rejected")
+	}
+	for _, field := range []string{"ControlAddr", "ProbeInterval", "ProbePort", "ProbeConcurrency", "gpu", "AdvertiseAddrs", "10.0.0.0/33"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected error to mention %s, got %v", field, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package identity
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Identity 节点身份
+type Identity struct {
+	NodeID    string   // 节点 ID，首次启动时生成并写入节点 ID 文件
+	Addresses []string // 对外通告地址，第一个为主地址
+}
+
+// Load 从 idFile 读取节点 ID，文件不存在时生成新 ID 并写入；advertise 为空时自动探测地址
+func Load(idFile string, advertise []string) (*Identity, error) {
+	id, err := LoadOrCreateNodeID(idFile)
+	if err != nil {
+		return nil, err
+	}
+	addrs := advertise
+	if len(addrs) == 0 {
+		addrs, err = DetectAddresses()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Identity{NodeID: id, Addresses: addrs}, nil
+}
+
+// PrimaryAddress 主通告地址
+func (i *Identity) PrimaryAddress() string {
+	if len(i.Addresses) == 0 {
+		return ""
+	}
+	return i.Addresses[0]
+}
+
+// LoadOrCreateNodeID 读取持久化的节点 ID，不存在时生成 16 字节随机 ID 并写入文件
+func LoadOrCreateNodeID(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		id := strings.TrimSpace(string(data))
+		if id != "" {
+			return id, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read node ID file %s: %w", path, err)
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate node ID: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create node ID directory %s: %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(id+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write node ID file %s: %w", path, err)
+	}
+	return id, nil
+}
+
+// DetectAddresses 探测本机已启用网卡上的全局单播地址，IPv4 在前
+// 没有可用地址时返回错误，回环地址无法被其他节点访问，需通过 AdvertiseAddrs 指定
+func DetectAddresses() ([]string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+	var v4, v6 []string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !ipNet.IP.IsGlobalUnicast() {
+				continue
+			}
+			if ipNet.IP.To4() != nil {
+				v4 = append(v4, ipNet.IP.String())
+			} else {
+				v6 = append(v6, ipNet.IP.String())
+			}
+		}
+	}
+	addrs := append(v4, v6...)
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no global unicast address found on any interface, set AdvertiseAddrs explicitly")
+	}
+	return addrs, nil
+}
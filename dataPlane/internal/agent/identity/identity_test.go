@@ -0,0 +1,50 @@
+package identity
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLoadOrCreateNodeID 测试节点 ID 只生成一次并持久化
+func TestLoadOrCreateNodeID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sirius", "node_id")
+	id, err := LoadOrCreateNodeID(path)
+	if err != nil {
+		t.Fatalf("LoadOrCreateNodeID failed: %v", err)
+	}
+	if len(id) != 32 {
+		t.Fatalf("unexpected node ID %q", id)
+	}
+	again, err := LoadOrCreateNodeID(path)
+	if err != nil {
+		t.Fatalf("LoadOrCreateNodeID failed: %v", err)
+	}
+	if again != id {
+		t.Fatalf("node ID changed after reload: %s -> %s", id, again)
+	}
+}
+
+// TestLoad 测试配置的通告地址优先于自动探测
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node_id")
+	advertise := []string{"10.0.0.1", "192.168.1.1"}
+	id, err := Load(path, advertise)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(id.Addresses, advertise) || id.PrimaryAddress() != "10.0.0.1" {
+		t.Fatalf("unexpected addresses: %v", id.Addresses)
+	}
+
+	// 没有可用网卡地址的环境中应当报错，而不是回退到回环地址
+	detected, err := Load(path, nil)
+	if err != nil {
+		t.Logf("no address detected: %v", err)
+		return
+	}
+	if detected.NodeID != id.NodeID || net.ParseIP(detected.PrimaryAddress()).IsLoopback() {
+		t.Fatalf("unexpected identity: %+v", detected)
+	}
+}
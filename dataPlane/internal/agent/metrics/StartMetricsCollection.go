@@ -2,6 +2,9 @@ package metrics
 
 import (
 	"context"
+	"dataPlane/internal/agent/channel"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/identity"
 	"dataPlane/internal/agent/metrics/protocol"
 	"fmt"
 	"log"
@@ -9,12 +12,6 @@ import (
 	"time"
 )
 
-// 包级全局变量，可在外部修改
-var (
-	ServerAddr     = "localhost:50051" // 默认服务端地址
-	ReportInterval = 30 * time.Second  // 默认上报间隔
-)
-
 // convertToProtoMetrics 辅助函数，用于将 InfoData 转换为 protocol.Metrics
 func convertToProtoMetrics(info InfoData) *protocol.Metrics {
 	// 将字节单位转换为兆（MB）
@@ -23,7 +20,8 @@ func convertToProtoMetrics(info InfoData) *protocol.Metrics {
 	}
 
 	return &protocol.Metrics{
-		Ip: info.IP,
+		Ip:     info.IP,
+		NodeId: info.NodeID,
 		CpuInfo: &protocol.CPUInfo{
 			Cores:     info.CPUInfo.Cores,
 			ModelName: info.CPUInfo.ModelName,
@@ -72,16 +70,27 @@ func convertToProtoMetrics(info InfoData) *protocol.Metrics {
 	}
 }
 
-func StartMetricsCollection() {
-	// 创建gRPC客户端，连接到控制面服务器
-	grpcClient, err := NewGrpcClient(ServerAddr)
-	if err != nil {
-		log.Fatalf("Error creating gRPC client: %v", err)
+// StartMetricsCollection 按 cfg.MetricsInterval 定时收集 cfg.Collectors 中的指标，以节点身份 id 上报
+// 与控制面的长连接已建立时经由长连接上报，否则单独连接 cfg.MetricsAddr 上报
+func StartMetricsCollection(cfg *config.Config, id *identity.Identity) {
+	ch := channel.Default()
+	var grpcClient *GrpcClient
+	if ch == nil {
+		// 创建gRPC客户端，连接到控制面服务器
+		var err error
+		grpcClient, err = NewGrpcClient(cfg)
+		if err != nil {
+			log.Fatalf("Error creating gRPC client: %v", err)
+		}
 	}
-	defer grpcClient.Close()
+	defer func() {
+		if grpcClient != nil {
+			grpcClient.Close()
+		}
+	}()
 
 	// 设置定时器
-	ticker := time.NewTicker(ReportInterval)
+	ticker := time.NewTicker(cfg.MetricsInterval)
 	defer ticker.Stop()
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -95,7 +104,7 @@ func StartMetricsCollection() {
 			defer uploadCancel()
 
 			// 收集系统信息
-			info, err := CollectSystemInfo()
+			info, err := CollectSystemInfo(id, cfg.Collectors)
 			if err != nil {
 				log.Printf("Error collecting system info: %v", err)
 				continue
@@ -104,8 +113,17 @@ func StartMetricsCollection() {
 			// 创建Metrics数据结构
 			metricsData := convertToProtoMetrics(info)
 
-			// 上传数据到控制面
-			err = grpcClient.UploadMetrics(uploadCtx, metricsData)
+			// 上传数据到控制面，长连接断开期间改为单独连接上报，连接在首次需要时创建
+			if ch != nil && ch.Connected() {
+				err = ch.SendMetrics(metricsData)
+			} else {
+				if grpcClient == nil {
+					grpcClient, err = NewGrpcClient(cfg)
+				}
+				if err == nil {
+					err = grpcClient.UploadMetrics(uploadCtx, metricsData)
+				}
+			}
 			if err != nil {
 				log.Printf("Error sending metrics: %v", err)
 			} else {
@@ -2,9 +2,12 @@ package metrics
 
 import (
 	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/identity"
 	"dataPlane/internal/agent/metrics/protocol"
 	"log"
 	"net"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -53,15 +56,19 @@ func TestStartMetricsCollection(t *testing.T) {
 	}()
 	defer grpcServer.Stop()
 
-	// 修改 ServerAddr 为模拟服务端的地址
-	originalAddr := ServerAddr
-	ServerAddr = lis.Addr().String()
-	defer func() { ServerAddr = originalAddr }()
+	cfg := config.Default()
+	// 模拟服务端未启用 TLS
+	cfg.TLSEnabled = false
+	// 指标上报到模拟服务端
+	cfg.MetricsAddr = lis.Addr().String()
+	// 缩短上报间隔，以便更快地进行测试
+	cfg.MetricsInterval = 1 * time.Second
 
-	// 修改 ReportInterval 为更短的时间，以便更快地进行测试
-	originalInterval := ReportInterval
-	ReportInterval = 1 * time.Second
-	defer func() { ReportInterval = originalInterval }()
+	// 节点 ID 写入临时目录，避免在源码目录生成文件
+	id, err := identity.Load(filepath.Join(t.TempDir(), "node_id"), []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("Failed to load identity: %v", err)
+	}
 
 	// 创建带超时的上下文
 	_, cancel := context.WithCancel(context.Background())
@@ -70,7 +77,7 @@ func TestStartMetricsCollection(t *testing.T) {
 	// 定义超时限制，确保测试不无限期运行
 	done := make(chan struct{})
 	go func() {
-		StartMetricsCollection()
+		StartMetricsCollection(cfg, id)
 		close(done)
 	}()
 
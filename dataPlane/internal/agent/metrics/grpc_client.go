@@ -2,7 +2,9 @@ package metrics
 
 import (
 	"context"
+	"dataPlane/internal/agent/config"
 	"dataPlane/internal/agent/metrics/protocol" // 引入由protobuf生成的protocol包
+	"dataPlane/internal/agent/pki"
 	"fmt"
 	"google.golang.org/grpc"
 	"log"
@@ -15,13 +17,16 @@ type GrpcClient struct {
 	conn   *grpc.ClientConn
 }
 
-// NewGrpcClient 创建并返回 gRPC 客户端实例
-func NewGrpcClient(address string) (*GrpcClient, error) {
+// NewGrpcClient 创建并返回连接 cfg.MetricsAddr 的 gRPC 客户端实例
+func NewGrpcClient(cfg *config.Config) (*GrpcClient, error) {
+	creds, err := pki.DialOption(cfg)
+	if err != nil {
+		return nil, err
+	}
 	var conn *grpc.ClientConn
-	var err error
 	maxRetries := 3
 	for i := 0; i < maxRetries; i++ {
-		conn, err = grpc.Dial(address, grpc.WithInsecure(), grpc.WithBlock())
+		conn, err = grpc.Dial(cfg.MetricsAddr, creds, grpc.WithBlock())
 		if err == nil {
 			break
 		}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"dataPlane/internal/agent/identity" // 获取节点 ID 和通告地址
 	"fmt"
 	"github.com/shirou/gopsutil/v3/cpu"  // 获取CPU信息和使用率
 	"github.com/shirou/gopsutil/v3/disk" // 获取磁盘信息，如分区和使用情况
@@ -8,10 +9,7 @@ import (
 	"github.com/shirou/gopsutil/v3/load" // 获取系统平均负载信息
 	"github.com/shirou/gopsutil/v3/mem"  // 获取内存信息，如总量、使用量等
 	"github.com/shirou/gopsutil/v3/net"  // 获取网络接口的I/O统计信息
-	"io"
-	"net/http" // 执行HTTP请求
-	"strings"
-	"time"
+	"slices"
 )
 
 type CPUInfo struct {
@@ -60,6 +58,7 @@ type LoadInfo struct {
 }
 
 type InfoData struct {
+	NodeID      string
 	IP          string
 	CPUInfo     CPUInfo
 	MemoryInfo  MemoryInfo
@@ -69,42 +68,6 @@ type InfoData struct {
 	LoadInfo    LoadInfo
 }
 
-// GetIP 获取公网IP地址，使用多个备用服务提高可靠性
-func GetIP() (string, error) {
-	urls := []string{
-		"http://icanhazip.com",
-		"http://api.ipify.org",
-		"http://ifconfig.me/ip",
-	}
-
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	var lastErr error
-	for _, url := range urls {
-		resp, err := client.Get(url)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to query %s: %w", url, err)
-			continue
-		}
-
-		ipBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			lastErr = fmt.Errorf("failed to read response from %s: %w", url, err)
-			continue
-		}
-
-		ip := strings.TrimSpace(string(ipBytes))
-		if ip != "" {
-			return ip, nil
-		}
-	}
-
-	return "", fmt.Errorf("all IP services failed: %w", lastErr)
-}
-
 // GetCPUInfo 获取整体CPU信息及使用率
 // 返回一个CPUInfo结构体，包含整体CPU的信息和使用率
 func GetCPUInfo() (CPUInfo, error) {
@@ -248,50 +211,44 @@ func GetLoadInfo() (LoadInfo, error) {
 }
 
 // CollectSystemInfo 收集所有系统信息并返回
-// 通过调用上述所有函数收集系统信息，并将它们组合成一个InfoData结构体返回
-func CollectSystemInfo() (InfoData, error) {
-	ip, err := GetIP()
-	if err != nil {
-		return InfoData{}, err
-	}
-
-	cpuInfo, err := GetCPUInfo()
-	if err != nil {
-		return InfoData{}, err
+// 通过调用上述函数收集 collectors 中启用的指标，并将它们组合成一个InfoData结构体返回
+// 节点 ID 和地址来自本地持久化的节点身份 id，不依赖外部服务
+func CollectSystemInfo(id *identity.Identity, collectors []string) (InfoData, error) {
+	// 只采集启用的指标，其余保持零值
+	enabled := func(collector string) bool {
+		return slices.Contains(collectors, collector)
+	}
+	data := InfoData{NodeID: id.NodeID, IP: id.PrimaryAddress()}
+	var err error
+	if enabled("cpu") {
+		if data.CPUInfo, err = GetCPUInfo(); err != nil {
+			return InfoData{}, err
+		}
 	}
-
-	memoryInfo, err := GetMemoryInfo()
-	if err != nil {
-		return InfoData{}, err
+	if enabled("memory") {
+		if data.MemoryInfo, err = GetMemoryInfo(); err != nil {
+			return InfoData{}, err
+		}
 	}
-
-	diskInfo, err := GetDiskInfo()
-	if err != nil {
-		return InfoData{}, err
+	if enabled("disk") {
+		if data.DiskInfo, err = GetDiskInfo(); err != nil {
+			return InfoData{}, err
+		}
 	}
-
-	networkInfo, err := GetNetworkInfo()
-	if err != nil {
-		return InfoData{}, err
+	if enabled("network") {
+		if data.NetworkInfo, err = GetNetworkInfo(); err != nil {
+			return InfoData{}, err
+		}
 	}
-
-	hostInfo, err := GetHostInfo()
-	if err != nil {
-		return InfoData{}, err
+	if enabled("host") {
+		if data.HostInfo, err = GetHostInfo(); err != nil {
+			return InfoData{}, err
+		}
 	}
-
-	loadInfo, err := GetLoadInfo()
-	if err != nil {
-		return InfoData{}, err
+	if enabled("load") {
+		if data.LoadInfo, err = GetLoadInfo(); err != nil {
+			return InfoData{}, err
+		}
 	}
-
-	return InfoData{
-		IP:          ip,
-		CPUInfo:     cpuInfo,
-		MemoryInfo:  memoryInfo,
-		DiskInfo:    diskInfo,
-		NetworkInfo: networkInfo,
-		HostInfo:    hostInfo,
-		LoadInfo:    loadInfo,
-	}, nil
+	return data, nil
 }
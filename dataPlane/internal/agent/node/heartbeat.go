@@ -0,0 +1,118 @@
+package node
+
+import (
+	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/node/protocol"
+	"dataPlane/internal/agent/pki"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Heartbeater 负责向控制面注册并定期上报心跳
+type Heartbeater struct {
+	cfg       *config.Config // 节点配置，取其中的控制面地址、探测端口、标签和默认心跳间隔
+	client    protocol.NodeServiceClient
+	conn      *grpc.ClientConn
+	nodeID    string
+	addresses []string
+}
+
+// NewHeartbeater 创建连接 cfg.ControlAddr 的 Heartbeater，addresses 为本节点对外通告的地址，第一个为主地址
+func NewHeartbeater(cfg *config.Config, nodeID string, addresses []string) (*Heartbeater, error) {
+	if cfg.ControlAddr == "" {
+		return nil, fmt.Errorf("control plane address is not configured")
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("at least one advertised address is required")
+	}
+	creds, err := pki.DialOption(cfg)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(cfg.ControlAddr, creds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to control plane: %v", err)
+	}
+	return &Heartbeater{
+		cfg:       cfg,
+		client:    protocol.NewNodeServiceClient(conn),
+		conn:      conn,
+		nodeID:    nodeID,
+		addresses: addresses,
+	}, nil
+}
+
+// Register 向控制面注册本节点，返回控制面要求的心跳间隔
+func (h *Heartbeater) Register(ctx context.Context) (time.Duration, error) {
+	resp, err := h.client.Register(ctx, &protocol.RegisterRequest{
+		NodeId:    h.nodeID,
+		Address:   h.addresses[0],
+		ProbePort: int32(h.cfg.ProbePort),
+		Labels:    h.cfg.Labels,
+		Addresses: h.addresses,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to register node: %v", err)
+	}
+	h.nodeID = resp.NodeId
+	log.Printf("Node registered to control plane, ID: %s", h.nodeID)
+	if resp.HeartbeatInterval > 0 {
+		return time.Duration(resp.HeartbeatInterval) * time.Second, nil
+	}
+	return h.cfg.HeartbeatInterval, nil
+}
+
+// Heartbeat 上报一次心跳，控制面不认识本节点时重新注册
+func (h *Heartbeater) Heartbeat(ctx context.Context) error {
+	resp, err := h.client.Heartbeat(ctx, &protocol.HeartbeatRequest{NodeId: h.nodeID})
+	if err != nil {
+		return fmt.Errorf("failed to send heartbeat: %v", err)
+	}
+	if resp.Status == "unknown" {
+		log.Printf("Node %s unknown to control plane, registering again", h.nodeID)
+		_, err := h.Register(ctx)
+		return err
+	}
+	return nil
+}
+
+// Close 关闭与控制面的连接
+func (h *Heartbeater) Close() {
+	h.conn.Close()
+}
+
+// StartHeartbeat 注册本节点并按控制面要求的间隔上报心跳，注册失败时按默认间隔重试，直到 ctx 取消
+// 无法创建 Heartbeater（例如未配置控制面地址）时立即返回错误
+func StartHeartbeat(ctx context.Context, cfg *config.Config, nodeID string, addresses []string) error {
+	h, err := NewHeartbeater(cfg, nodeID, addresses)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	registered := false
+	interval := cfg.HeartbeatInterval
+	for {
+		reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if !registered {
+			if d, err := h.Register(reqCtx); err != nil {
+				log.Printf("Error registering node: %v", err)
+			} else {
+				registered = true
+				interval = d
+			}
+		} else if err := h.Heartbeat(reqCtx); err != nil {
+			log.Printf("Error sending heartbeat: %v", err)
+		}
+		cancel()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
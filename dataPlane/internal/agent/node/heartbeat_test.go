@@ -0,0 +1,130 @@
+package node
+
+import (
+	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/node/protocol"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// mockNodeServer 模拟控制面的 NodeService
+type mockNodeServer struct {
+	protocol.UnimplementedNodeServiceServer
+	mu        sync.Mutex
+	nodes     map[string]*protocol.RegisterRequest
+	registers int
+}
+
+func (s *mockNodeServer) Register(ctx context.Context, req *protocol.RegisterRequest) (*protocol.RegisterResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := req.NodeId
+	if id == "" {
+		id = req.Address
+	}
+	s.nodes[id] = req
+	s.registers++
+	return &protocol.RegisterResponse{Status: "ok", NodeId: id, HeartbeatInterval: 3}, nil
+}
+
+func (s *mockNodeServer) Heartbeat(ctx context.Context, req *protocol.HeartbeatRequest) (*protocol.HeartbeatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.nodes[req.NodeId]; !ok {
+		return &protocol.HeartbeatResponse{Status: "unknown"}, nil
+	}
+	return &protocol.HeartbeatResponse{Status: "ok"}, nil
+}
+
+// TestHeartbeater 测试注册、心跳以及控制面丢失节点后的重新注册
+func TestHeartbeater(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	mockSrv := &mockNodeServer{nodes: make(map[string]*protocol.RegisterRequest)}
+	grpcServer := grpc.NewServer()
+	protocol.RegisterNodeServiceServer(grpcServer, mockSrv)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	// 模拟服务端未启用 TLS
+	cfg := config.Default()
+	cfg.ControlAddr = lis.Addr().String()
+	cfg.TLSEnabled = false
+	h, err := NewHeartbeater(cfg, "node-1", []string{"10.0.0.1"})
+	if err != nil {
+		t.Fatalf("Failed to create heartbeater: %v", err)
+	}
+	defer h.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	interval, err := h.Register(ctx)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if interval != 3*time.Second || h.nodeID != "node-1" {
+		t.Fatalf("unexpected register result: interval=%v id=%s", interval, h.nodeID)
+	}
+	if err := h.Heartbeat(ctx); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+
+	// 模拟控制面丢失节点信息
+	mockSrv.mu.Lock()
+	delete(mockSrv.nodes, "node-1")
+	mockSrv.mu.Unlock()
+	if err := h.Heartbeat(ctx); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	mockSrv.mu.Lock()
+	defer mockSrv.mu.Unlock()
+	if mockSrv.registers != 2 {
+		t.Fatalf("expected node to register again, registers=%d", mockSrv.registers)
+	}
+}
+
+// TestStartHeartbeat 测试未配置控制面地址时立即返回错误，ctx 取消后停止上报
+func TestStartHeartbeat(t *testing.T) {
+	cfg := config.Default()
+	cfg.TLSEnabled = false
+	if err := StartHeartbeat(context.Background(), cfg, "node-1", []string{"10.0.0.1"}); err == nil {
+		t.Fatal("expected missing control address to be rejected")
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	mockSrv := &mockNodeServer{nodes: make(map[string]*protocol.RegisterRequest)}
+	grpcServer := grpc.NewServer()
+	protocol.RegisterNodeServiceServer(grpcServer, mockSrv)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	cfg.ControlAddr = lis.Addr().String()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- StartHeartbeat(ctx, cfg, "node-1", []string{"10.0.0.1"}) }()
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected heartbeat to stop cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("heartbeat did not stop after ctx was cancelled")
+	}
+	mockSrv.mu.Lock()
+	defer mockSrv.mu.Unlock()
+	if mockSrv.registers != 1 {
+		t.Fatalf("expected node to register once, registers=%d", mockSrv.registers)
+	}
+}
@@ -0,0 +1,67 @@
+package node
+
+import (
+	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/node/protocol"
+	"dataPlane/internal/agent/pki"
+	"fmt"
+	"log"
+	"net"
+
+	"google.golang.org/grpc"
+)
+
+// Join 使用加入令牌向控制面申请本节点证书，校验后保存到 cfg 中的证书、私钥和 CA 证书路径，返回控制面下发的配置
+// caHash 为控制面 CA 的指纹，为空时信任首次连接的控制面，调用方须确认用户显式接受了这一风险
+func Join(ctx context.Context, cfg *config.Config, controlAddr, token, caHash, nodeID string) (*protocol.AgentConfig, error) {
+	keyPEM, csrPEM, err := pki.NewKeyAndCSR(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %v", err)
+	}
+	conn, err := grpc.Dial(controlAddr, pki.JoinDialOption(caHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to control plane: %v", err)
+	}
+	defer conn.Close()
+	resp, err := protocol.NewNodeServiceClient(conn).Join(ctx, &protocol.JoinRequest{
+		Token:  token,
+		NodeId: nodeID,
+		Csr:    csrPEM,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to join control plane: %v", err)
+	}
+	if resp.NodeId != nodeID {
+		return nil, fmt.Errorf("control plane issued certificate for node %q, expected %q", resp.NodeId, nodeID)
+	}
+	if err := pki.SaveCredentials(cfg, resp.CaCertificate, resp.Certificate, keyPEM, caHash); err != nil {
+		return nil, fmt.Errorf("failed to save certificate: %v", err)
+	}
+	log.Printf("Node %s joined control plane %s", nodeID, controlAddr)
+
+	joined := resp.Config
+	if joined == nil {
+		joined = &protocol.AgentConfig{}
+	}
+	// 控制面未通告主机名时沿用加入时连接的主机
+	if joined.ControlAddr == "" {
+		joined.ControlAddr = controlAddr
+	}
+	joined.ControlAddr = resolveAddr(joined.ControlAddr, controlAddr)
+	joined.MetricsAddr = resolveAddr(joined.MetricsAddr, controlAddr)
+	return joined, nil
+}
+
+// 地址的主机部分为空时补上 controlAddr 的主机
+func resolveAddr(addr, controlAddr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	controlHost, _, err := net.SplitHostPort(controlAddr)
+	if err != nil {
+		return addr
+	}
+	return net.JoinHostPort(controlHost, port)
+}
@@ -0,0 +1,127 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/node/protocol"
+	"dataPlane/internal/agent/pki"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// mockJoinServer 模拟控制面的 Join：用测试 CA 为证书签名请求签发证书
+type mockJoinServer struct {
+	protocol.UnimplementedNodeServiceServer
+	ca  *x509.Certificate
+	key *ecdsa.PrivateKey
+}
+
+func (s *mockJoinServer) issue(commonName string, pub any) []byte {
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, s.ca, pub, s.key)
+	if err != nil {
+		panic(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func (s *mockJoinServer) Join(ctx context.Context, req *protocol.JoinRequest) (*protocol.JoinResponse, error) {
+	block, _ := pem.Decode(req.Csr)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.JoinResponse{
+		NodeId:        req.NodeId,
+		Certificate:   s.issue(req.NodeId, csr.PublicKey),
+		CaCertificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.ca.Raw}),
+		Config:        &protocol.AgentConfig{ControlAddr: ":8081", MetricsAddr: "metrics.example.com:8080", HeartbeatInterval: 5},
+	}, nil
+}
+
+// TestJoin 测试加入流程：按 CA 指纹校验控制面，保存签发的证书，补全配置中的地址
+func TestJoin(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Sirius CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	ca, _ := x509.ParseCertificate(der)
+	mockSrv := &mockJoinServer{ca: ca, key: caKey}
+
+	// 控制面证书链附带 CA 证书
+	serverKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	block, _ := pem.Decode(mockSrv.issue(pki.ControlCommonName, &serverKey.PublicKey))
+	serverCert := tls.Certificate{Certificate: [][]byte{block.Bytes, ca.Raw}, PrivateKey: serverKey}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}})))
+	protocol.RegisterNodeServiceServer(grpcServer, mockSrv)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	dir := t.TempDir()
+	local := config.Default()
+	local.CAFile = filepath.Join(dir, "ca.crt")
+	local.CertFile = filepath.Join(dir, "node.crt")
+	local.KeyFile = filepath.Join(dir, "node.key")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// CA 指纹不符时拒绝连接，不写入任何文件
+	if _, err := Join(ctx, local, lis.Addr().String(), "abc.secret", "sha256:00", "node-1"); err == nil {
+		t.Fatal("expected join with wrong CA fingerprint to fail")
+	}
+	if _, err := os.Stat(local.CertFile); err == nil {
+		t.Fatal("certificate written after failed join")
+	}
+
+	cfg, err := Join(ctx, local, lis.Addr().String(), "abc.secret", pki.Fingerprint(ca), "node-1")
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	if cfg.ControlAddr != "127.0.0.1:8081" || cfg.MetricsAddr != "metrics.example.com:8080" || cfg.HeartbeatInterval != 5 {
+		t.Fatalf("unexpected config: %v", cfg)
+	}
+	cert, err := tls.LoadX509KeyPair(local.CertFile, local.KeyFile)
+	if err != nil {
+		t.Fatalf("Saved certificate and key do not match: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+	if leaf.Subject.CommonName != "node-1" {
+		t.Fatalf("expected certificate for node-1, got %s", leaf.Subject.CommonName)
+	}
+	if _, err := os.Stat(local.CAFile); err != nil {
+		t.Fatalf("CA certificate not saved: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"dataPlane/internal/agent/config"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Fingerprint 证书的指纹，格式为 sha256:<hex>，与控制面 token 命令打印的 CA 指纹一致
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// NewKeyAndCSR 生成本节点私钥和证书签名请求，私钥只保存在本节点，返回私钥和请求的 PEM
+func NewKeyAndCSR(nodeID string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: nodeID},
+		DNSNames: []string{nodeID},
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// JoinDialOption 加入时连接控制面的传输凭证，本节点此时还没有证书和 CA 证书
+// 控制面在握手时一并发送 CA 证书，caHash 非空时要求其指纹一致
+// 为空时信任首次连接的控制面，只应在用户显式指定 --insecure 时使用
+func JoinDialOption(caHash string) grpc.DialOption {
+	if caHash == "" {
+		log.Printf("WARNING: no CA fingerprint given, trusting the CA presented by the control plane without verification")
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		// 证书链由 VerifyPeerCertificate 按指纹校验
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS12,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyControl(rawCerts, caHash)
+		},
+	}))
+}
+
+// 校验控制面发送的证书链：链中的 CA 与 caHash 一致，且控制面证书由该 CA 签发
+func verifyControl(rawCerts [][]byte, caHash string) error {
+	if len(rawCerts) < 2 {
+		return fmt.Errorf("control plane did not present its CA certificate")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+	ca := certs[len(certs)-1]
+	if caHash != "" && Fingerprint(ca) != caHash {
+		return fmt.Errorf("control plane CA fingerprint %s does not match %s", Fingerprint(ca), caHash)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	_, err := certs[0].Verify(x509.VerifyOptions{Roots: pool, DNSName: ControlCommonName})
+	return err
+}
+
+// SaveCredentials 校验控制面签发的证书并保存到 cfg 中的 CAFile、CertFile、KeyFile
+// 证书必须由 CA 签发且与私钥匹配，caHash 非空时 CA 的指纹必须一致
+func SaveCredentials(cfg *config.Config, caPEM, certPEM, keyPEM []byte, caHash string) error {
+	block, _ := pem.Decode(caPEM)
+	if block == nil {
+		return fmt.Errorf("invalid CA certificate")
+	}
+	ca, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	if caHash != "" && Fingerprint(ca) != caHash {
+		return fmt.Errorf("CA fingerprint %s does not match %s", Fingerprint(ca), caHash)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("certificate does not match private key: %v", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		return fmt.Errorf("certificate not issued by CA: %v", err)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+		perm os.FileMode
+	}{
+		{cfg.KeyFile, keyPEM, 0600},
+		{cfg.CertFile, certPEM, 0644},
+		{cfg.CAFile, caPEM, 0644},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(f.name, f.data, f.perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
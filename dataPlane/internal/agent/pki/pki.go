@@ -0,0 +1,115 @@
+package pki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"dataPlane/internal/agent/config"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// 控制面证书的通用名，连接控制面以及接受控制面请求时据此校验对端身份
+const ControlCommonName = "sirius-control"
+
+// 加载 cfg 中的本节点证书和 CA 证书池，每次调用都重新读取文件，证书更新后无需重启
+func load(cfg *config.Config) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to load node certificate: %v", err)
+	}
+	caPEM, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to load CA certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, fmt.Errorf("invalid CA certificate %s", cfg.CAFile)
+	}
+	return cert, pool, nil
+}
+
+// DialOption 连接控制面时的传输凭证，校验对端为控制面，cfg 未启用 TLS 时不加密
+func DialOption(cfg *config.Config) (grpc.DialOption, error) {
+	if !cfg.TLSEnabled {
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+	cert, pool, err := load(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   ControlCommonName,
+		MinVersion:   tls.VersionTLS12,
+	})), nil
+}
+
+// ServerOption 本节点 gRPC 服务的传输凭证，只接受控制面证书，其他节点不能向本节点下发任务
+func ServerOption(cfg *config.Config) (grpc.ServerOption, error) {
+	if !cfg.TLSEnabled {
+		return grpc.Creds(insecure.NewCredentials()), nil
+	}
+	cert, pool, err := load(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+		VerifyPeerCertificate: func(_ [][]byte, chains [][]*x509.Certificate) error {
+			if len(chains) == 0 || chains[0][0].Subject.CommonName != ControlCommonName {
+				return fmt.Errorf("client is not the control plane")
+			}
+			return nil
+		},
+	})), nil
+}
+
+// RelayTLSConfig 节点之间中继连接的 TLS 配置，双方都须出示控制面 CA 签发的节点证书，未启用 TLS 时返回 nil
+// 节点证书只含节点 ID，不含地址，因此按证书链校验对端，不校验主机名
+func RelayTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if !cfg.TLSEnabled {
+		return nil, nil
+	}
+	cert, pool, err := load(cfg)
+	if err != nil {
+		return nil, err
+	}
+	verify := func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("peer presented no certificate")
+		}
+		opts := x509.VerifyOptions{
+			Roots:         pool,
+			Intermediates: x509.NewCertPool(),
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}
+		for _, c := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(c)
+		}
+		leaf := cs.PeerCertificates[0]
+		if _, err := leaf.Verify(opts); err != nil {
+			return err
+		}
+		if cn := leaf.Subject.CommonName; cn == "" || cn == ControlCommonName {
+			return fmt.Errorf("peer %q is not a node", cn)
+		}
+		return nil
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+		// 由 VerifyConnection 校验证书链，双方都不校验主机名
+		InsecureSkipVerify: true,
+		VerifyConnection:   verify,
+		MinVersion:         tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,202 @@
+package pki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/probe/protocol"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// testCA 测试用的 CA，模拟控制面内置 CA 签发证书
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Sirius CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue 签发证书，返回证书和私钥的 PEM
+func (ca *testCA) issue(t *testing.T, commonName string) ([]byte, []byte) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to issue certificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// installNodeCert 把 CA 证书和 commonName 的节点证书写入临时目录，返回使用它们的配置
+func installNodeCert(t *testing.T, ca *testCA, commonName string) *config.Config {
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.CAFile = filepath.Join(dir, "ca.crt")
+	cfg.CertFile = filepath.Join(dir, "node.crt")
+	cfg.KeyFile = filepath.Join(dir, "node.key")
+	certPEM, keyPEM := ca.issue(t, commonName)
+	os.WriteFile(cfg.CAFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0644)
+	os.WriteFile(cfg.CertFile, certPEM, 0644)
+	os.WriteFile(cfg.KeyFile, keyPEM, 0600)
+	return cfg
+}
+
+// TestServerOption 测试本节点服务只接受控制面证书
+func TestServerOption(t *testing.T) {
+	ca := newTestCA(t)
+	cfg := installNodeCert(t, ca, "node-1")
+
+	creds, err := ServerOption(cfg)
+	if err != nil {
+		t.Fatalf("ServerOption failed: %v", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := grpc.NewServer(creds)
+	protocol.RegisterProbeTaskServiceServer(server, &protocol.UnimplementedProbeTaskServiceServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	// 以 commonName 的证书连接本节点，返回握手是否成功
+	call := func(commonName string) error {
+		certPEM, keyPEM := ca.issue(t, commonName)
+		cert, _ := tls.X509KeyPair(certPEM, keyPEM)
+		conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      ca.pool(),
+			ServerName:   "node-1",
+		})))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = protocol.NewProbeTaskServiceClient(conn).SendProbeTasks(ctx, &protocol.ProbeTaskRequest{})
+		return err
+	}
+
+	// 控制面的请求到达服务端，服务未实现
+	if err := call(ControlCommonName); !isUnimplemented(err) {
+		t.Fatalf("expected control plane to pass TLS, got %v", err)
+	}
+	// 其他节点无法通过握手
+	if err := call("node-2"); err == nil || isUnimplemented(err) {
+		t.Fatalf("expected other node to be rejected, got %v", err)
+	}
+}
+
+func isUnimplemented(err error) bool {
+	return status.Code(err) == codes.Unimplemented
+}
+
+// TestRelayTLSConfig 测试中继连接只接受同一 CA 签发的节点证书
+func TestRelayTLSConfig(t *testing.T) {
+	ca := newTestCA(t)
+	cfg := installNodeCert(t, ca, "node-1")
+	tlsConfig, err := RelayTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("RelayTLSConfig failed: %v", err)
+	}
+
+	// 以 cert 连接使用 tlsConfig 的服务端，返回双方的握手结果
+	handshake := func(cert tls.Certificate) (error, error) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		defer lis.Close()
+		done := make(chan error, 1)
+		go func() {
+			conn, err := lis.Accept()
+			if err != nil {
+				done <- err
+				return
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			done <- tls.Server(conn, tlsConfig).Handshake()
+		}()
+		clientConfig := tlsConfig.Clone()
+		clientConfig.Certificates = []tls.Certificate{cert}
+		conn, clientErr := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", lis.Addr().String(), clientConfig)
+		if clientErr == nil {
+			// TLS 1.3 下服务端在客户端握手完成后才校验客户端证书，读一次以收到服务端的拒绝
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+		return clientErr, <-done
+	}
+	keyPair := func(ca *testCA, commonName string) tls.Certificate {
+		certPEM, keyPEM := ca.issue(t, commonName)
+		cert, _ := tls.X509KeyPair(certPEM, keyPEM)
+		return cert
+	}
+
+	if clientErr, serverErr := handshake(keyPair(ca, "node-2")); clientErr != nil || serverErr != nil {
+		t.Fatalf("expected nodes to relay to each other, got %v / %v", clientErr, serverErr)
+	}
+	if _, serverErr := handshake(keyPair(ca, ControlCommonName)); serverErr == nil {
+		t.Error("expected control plane certificate to be rejected")
+	}
+	if _, serverErr := handshake(keyPair(newTestCA(t), "node-2")); serverErr == nil {
+		t.Error("expected certificate from another CA to be rejected")
+	}
+
+	cfg.TLSEnabled = false
+	if tlsConfig, err := RelayTLSConfig(cfg); tlsConfig != nil || err != nil {
+		t.Errorf("expected no TLS when disabled, got %v, %v", tlsConfig, err)
+	}
+}
@@ -1,11 +1,16 @@
 package probe
 
-func StartTcp_probe() {
+import "dataPlane/internal/agent/config"
+
+func StartTcp_probe(cfg *config.Config) {
 	// 启动 ProbeTaskServiceServer，处理探测任务的接收
-	go StartProbeTaskServiceServer()
+	go StartProbeTaskServiceServer(cfg)
+
+	// 启动回显服务，供对端探测本节点
+	StartResponder(cfg)
 
 	// 启动定时探测循环，定时执行 TCP 探测并上报
-	go StartProbeLoop()
+	go StartProbeLoop(cfg)
 
 	// 保持程序运行
 	select {}
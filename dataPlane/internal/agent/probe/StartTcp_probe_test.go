@@ -2,6 +2,7 @@ package probe
 
 import (
 	"context"
+	"dataPlane/internal/agent/config"
 	"fmt"
 	"google.golang.org/grpc"
 	"log"
@@ -87,17 +88,20 @@ func sendProbeTasks() {
 }
 
 func TestStartTcp_probe(t *testing.T) {
-	// 修改 gRPC 客户端地址，让数据面将结果上报到测试服务端
-	GRPCClientAddr = "localhost:50052"
+	cfg := config.Default()
+	// 测试服务端未启用 TLS
+	cfg.TLSEnabled = false
+	// 修改控制面地址，让数据面将结果上报到测试服务端
+	cfg.ControlAddr = "localhost:50052"
 	// 缩短探测间隔，加速测试
-	ProbeInterval = 1 * time.Second
+	cfg.ProbeInterval = 1 * time.Second
 
 	// 创建带超时的上下文
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// 启动被测试模块
-	go StartTcp_probe()
+	go StartTcp_probe(cfg)
 
 	// 启动服务端，用于接收探测结果
 	go startServer(t)
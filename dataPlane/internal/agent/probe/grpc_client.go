@@ -2,19 +2,18 @@ package probe
 
 import (
 	"context"
+	"dataPlane/internal/agent/channel"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/pki"
 	"dataPlane/internal/agent/probe/protocol"
 	"fmt"
+	"github.com/panjf2000/ants/v2"
 	"google.golang.org/grpc"
-	"net"
+	"io"
+	"log"
 	"time"
 )
 
-// 定义全局变量来配置 gRPC 客户端地址和探测循环时间间隔
-var (
-	GRPCClientAddr = "124.70.34.63:8081"
-	ProbeInterval  = 10 * time.Second
-)
-
 // ProbeTask 结构体定义
 type ProbeTask struct {
 	IP1 string
@@ -23,42 +22,148 @@ type ProbeTask struct {
 
 // ProbeResult 结构体定义
 type ProbeResult struct {
-	IP1       string
-	IP2       string
-	TCPDelay  int64 // 直接使用 int64 存储毫秒数
-	Timestamp time.Time
+	IP1        string
+	IP2        string
+	NodeID1    string                   // 源节点 ID
+	NodeID2    string                   // 目标节点 ID
+	Type       protocol.ProbeType       // 探测类型
+	TCPDelay   int64                    // 平均时延，单位毫秒，保留以兼容旧版本控制面
+	Stats      ProbeStats               // 多个样本的统计结果，单位微秒
+	Failed     bool                     // 全部样本失败
+	ErrorClass protocol.ProbeErrorClass // 最后一个失败样本的失败原因
+	Error      string                   // 最后一个失败样本的错误信息
+	Path       *PathStats               // 单向丢包和乱序，只有对端运行 Sirius 回显服务的 UDP 探测才有
+	Timestamp  time.Time
 }
 
-// performTCPProbe 执行 TCP 探测并返回探测结果
-func performTCPProbe(ip1 string, ip2 string) (*ProbeResult, error) {
-	// 记录开始时间
-	startTime := time.Now()
+// performProbe 按探测任务指定的类型、端口和超时发送多个样本，返回样本的统计结果
+// 单个样本失败计入丢包，全部样本失败时返回标记为失败的结果，只有任务本身非法时返回错误
+// ctx 结束后不再发送新的样本，已完成的样本照常统计；一个样本都未完成时返回错误
+// 任务未指定端口时按探测类型取默认端口，回显类探测默认探测对端的 responderPort
+func performProbe(ctx context.Context, task *protocol.ProbeTask, responderPort int) (*ProbeResult, error) {
+	ip1, ip2 := task.Ip1, task.Ip2
 
-	// 建立 TCP 连接
-	conn, err := net.DialTimeout("tcp", ip2+":50051", 5*time.Second)
+	prober, err := newProber(task)
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to %s: %v", ip2, err)
+		return nil, err
 	}
-	defer conn.Close()
-
-	// 计算 TCP 延迟（转换为毫秒）
-	tcpDelay := time.Since(startTime).Milliseconds()
+	// 回显探测的多个样本共用一个套接字
+	if closer, ok := prober.(io.Closer); ok {
+		defer closer.Close()
+	}
+	port, timeout := probeParams(task, responderPort)
+	count, interval := sampleParams(task)
+
+	// 执行探测
+	var samples []time.Duration
+	var lastErr error
+	sent := 0
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+		}
+		if ctxDone(ctx) {
+			break
+		}
+		sampleCtx, cancel := context.WithTimeout(ctx, timeout)
+		delay, err := prober.Probe(sampleCtx, ip2, port)
+		cancel()
+		// 被 ctx 中断的样本不计入丢包
+		if err != nil && ctxDone(ctx) {
+			break
+		}
+		sent++
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		samples = append(samples, delay)
+	}
+	if sent == 0 {
+		return nil, fmt.Errorf("no sample completed before the round deadline: %w", context.DeadlineExceeded)
+	}
+	stats := computeStats(samples, sent)
 
 	// 返回探测结果
 	result := &ProbeResult{
-		IP1:       ip1,
-		IP2:       ip2,
-		TCPDelay:  tcpDelay,
-		Timestamp: time.Now(),
+		IP1:        ip1,
+		IP2:        ip2,
+		NodeID1:    task.NodeId1,
+		NodeID2:    task.NodeId2,
+		Type:       task.Type,
+		TCPDelay:   time.Duration(stats.AvgUs * int64(time.Microsecond)).Milliseconds(),
+		Stats:      stats,
+		Failed:     len(samples) == 0,
+		ErrorClass: classifyError(lastErr),
+		Timestamp:  time.Now(),
+	}
+	if p, ok := prober.(pathProber); ok {
+		if path, ok := p.pathStats(); ok {
+			result.Path = &path
+		}
+	}
+	if lastErr != nil {
+		result.Error = fmt.Sprintf("error probing %s (%v, port %d): %v", ip2, task.Type, port, lastErr)
 	}
 
 	return result, nil
 }
 
 // SendProbeResults 发送探测结果
-func SendProbeResults(results []*ProbeResult) {
-	// 连接到 gRPC 服务器，使用全局变量 GRPCClientAddr
-	conn, err := grpc.Dial(GRPCClientAddr, grpc.WithInsecure())
+// 与控制面的长连接已建立时经由长连接上报，否则单独连接 cfg.ControlAddr 上报
+func SendProbeResults(cfg *config.Config, results []*ProbeResult) {
+	// 创建 ProbeResultRequest 消息
+	var protoResults []*protocol.ProbeResult
+	for _, result := range results {
+		protoResult := &protocol.ProbeResult{
+			Ip1:        result.IP1,
+			Ip2:        result.IP2,
+			NodeId1:    result.NodeID1,
+			NodeId2:    result.NodeID2,
+			Type:       result.Type,
+			TcpDelay:   result.TCPDelay, // 直接使用毫秒值
+			MinUs:      result.Stats.MinUs,
+			AvgUs:      result.Stats.AvgUs,
+			MaxUs:      result.Stats.MaxUs,
+			StddevUs:   result.Stats.StdDevUs,
+			JitterUs:   result.Stats.JitterUs,
+			Loss:       result.Stats.Loss,
+			Sent:       int32(result.Stats.Sent),
+			Received:   int32(result.Stats.Received),
+			Failed:     result.Failed,
+			ErrorClass: result.ErrorClass,
+			Error:      result.Error,
+			Timestamp:  result.Timestamp.Format(time.RFC3339),
+		}
+		if path := result.Path; path != nil {
+			protoResult.OneWay = true
+			protoResult.ForwardLoss = path.ForwardLoss
+			protoResult.ReverseLoss = path.ReverseLoss
+			protoResult.Reordered = int32(path.Reordered)
+		}
+		protoResults = append(protoResults, protoResult)
+	}
+	request := &protocol.ProbeResultRequest{
+		Results: protoResults,
+	}
+
+	if ch := channel.Default(); ch != nil && ch.Connected() {
+		if err := ch.SendProbeResults(request); err != nil {
+			fmt.Printf("Failed to send probe results: %v\n", err)
+		}
+		return
+	}
+
+	// 连接到 gRPC 服务器
+	creds, err := pki.DialOption(cfg)
+	if err != nil {
+		fmt.Printf("Failed to load credentials: %v\n", err)
+		return
+	}
+	conn, err := grpc.Dial(cfg.ControlAddr, creds)
 	if err != nil {
 		fmt.Printf("Failed to connect: %v\n", err)
 		return
@@ -68,20 +173,6 @@ func SendProbeResults(results []*ProbeResult) {
 	// 创建 ProbeResultService 客户端
 	client := protocol.NewProbeResultServiceClient(conn)
 
-	// 创建 ProbeResultRequest 消息
-	var protoResults []*protocol.ProbeResult
-	for _, result := range results {
-		protoResults = append(protoResults, &protocol.ProbeResult{
-			Ip1:       result.IP1,
-			Ip2:       result.IP2,
-			TcpDelay:  result.TCPDelay, // 直接使用毫秒值
-			Timestamp: result.Timestamp.Format(time.RFC3339),
-		})
-	}
-	request := &protocol.ProbeResultRequest{
-		Results: protoResults,
-	}
-
 	// 调用 SendProbeResults 方法
 	response, err := client.SendProbeResults(context.Background(), request)
 	if err != nil {
@@ -93,26 +184,23 @@ func SendProbeResults(results []*ProbeResult) {
 	fmt.Printf("Response: %s\n", response.Status)
 }
 
-// StartProbeLoop 启动定时探测循环
-func StartProbeLoop() {
-	// 使用全局变量 ProbeInterval 创建定时器
-	ticker := time.NewTicker(ProbeInterval)
+// StartProbeLoop 启动定时探测循环，每轮的探测由最多 cfg.ProbeConcurrency 个协程并发执行，须在 cfg.ProbeInterval 内完成
+func StartProbeLoop(cfg *config.Config) {
+	pool, err := ants.NewPool(cfg.ProbeConcurrency)
+	if err != nil {
+		log.Fatalf("Failed to create probe pool: %v", err)
+	}
+	defer pool.Release()
+
+	ticker := time.NewTicker(cfg.ProbeInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		// 调用 GetProbeTasks 函数获取探测任务
-		tasks := GetProbeTasks()
-		var results []*ProbeResult
-		for _, task := range tasks {
-			result, err := performTCPProbe(task.Ip1, task.Ip2)
-			if err != nil {
-				fmt.Printf("Error performing probe for %s -> %s: %v\n", task.Ip1, task.Ip2, err)
-				continue
-			}
-			results = append(results, result)
-		}
+		// 取出本轮到期的探测任务
+		tasks := probeTasks.Due(cfg.ProbeInterval)
+		results := runRound(cfg, pool, tasks, cfg.ProbeInterval)
 		if len(results) > 0 {
-			SendProbeResults(results)
+			SendProbeResults(cfg, results)
 		}
 	}
 }
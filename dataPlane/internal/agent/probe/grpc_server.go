@@ -2,19 +2,18 @@ package probe
 
 import (
 	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/pki"
 	"dataPlane/internal/agent/probe/protocol"
+	"dataPlane/internal/router"
 	"fmt"
 	"google.golang.org/grpc"
 	"log"
 	"net"
-	"sync"
 )
 
-// 全局变量用于存储接收到的探测任务，同时使用互斥锁保证并发安全
-var (
-	probeTasks []*protocol.ProbeTask
-	taskMutex  sync.Mutex
-)
+// 控制面下发的探测任务，gRPC 服务和长连接共用
+var probeTasks = NewTaskTable()
 
 // ProbeTaskServiceServer 实现 ProbeTaskService 服务接口
 type ProbeTaskServiceServer struct {
@@ -23,51 +22,67 @@ type ProbeTaskServiceServer struct {
 
 // SendProbeTasks 实现 SendProbeTasks 方法
 func (s *ProbeTaskServiceServer) SendProbeTasks(ctx context.Context, request *protocol.ProbeTaskRequest) (*protocol.ProbeTaskResponse, error) {
-	// 加锁，保证并发安全
-	taskMutex.Lock()
-	// 覆盖之前的任务
-	probeTasks = make([]*protocol.ProbeTask, 0, len(request.Tasks))
-	probeTasks = append(probeTasks, request.Tasks...)
-	taskMutex.Unlock()
+	return ApplyProbeTasks(request), nil
+}
 
-	// 打印接收到的任务信息
-	for _, task := range request.Tasks {
-		fmt.Printf("Received probe task: Source IP: %s, Destination IP: %s\n", task.Ip1, task.Ip2)
+// ListProbeTasks 实现 ListProbeTasks 方法，返回当前的全部探测任务和探测轮次的统计
+func (s *ProbeTaskServiceServer) ListProbeTasks(ctx context.Context, request *protocol.ListProbeTasksRequest) (*protocol.ListProbeTasksResponse, error) {
+	resp := probeTasks.List()
+	rounds := Rounds()
+	resp.Rounds = &protocol.ProbeRoundStats{
+		Rounds:         rounds.Rounds,
+		Overruns:       rounds.Overruns,
+		Skipped:        rounds.Skipped,
+		Cut:            rounds.Cut,
+		LastDurationMs: rounds.LastDuration.Milliseconds(),
+		MaxDurationMs:  rounds.MaxDuration.Milliseconds(),
+	}
+	return resp, nil
+}
+
+// ApplyProbeTasks 按更新方式修改探测任务表，供 gRPC 服务和长连接共用
+func ApplyProbeTasks(request *protocol.ProbeTaskRequest) *protocol.ProbeTaskResponse {
+	resp := probeTasks.Apply(request)
+	if resp.Status != "ok" {
+		fmt.Printf("Rejected %d probe tasks (%v, generation %d): current generation is %d\n", len(request.Tasks), request.Update, request.Generation, resp.Generation)
+		return resp
 	}
 
-	// 返回响应
-	response := &protocol.ProbeTaskResponse{
-		Status: "ok",
+	// 打印接收到的任务信息
+	for _, task := range request.Tasks {
+		fmt.Printf("Received probe task (%v): Source IP: %s, Destination IP: %s\n", request.Update, task.Ip1, task.Ip2)
 	}
-	return response, nil
+	return resp
 }
 
-// GetProbeTasks 用于获取缓存的探测任务
+// GetProbeTasks 用于获取当前未过期的探测任务
 func GetProbeTasks() []*protocol.ProbeTask {
-	taskMutex.Lock()
-	// 复制一份任务，避免外部修改
-	tasks := make([]*protocol.ProbeTask, len(probeTasks))
-	copy(tasks, probeTasks)
-	taskMutex.Unlock()
-	return tasks
+	return probeTasks.Tasks()
 }
 
-// StartProbeTaskServiceServer 启动 ProbeTaskService 服务
-func StartProbeTaskServiceServer() {
-	// 创建 gRPC 服务器
-	server := grpc.NewServer()
+// StartProbeTaskServiceServer 在 cfg.ProbePort 上启动 ProbeTaskService 服务，同时接收控制面下发的路由表
+func StartProbeTaskServiceServer(cfg *config.Config) {
+	// 创建 gRPC 服务器，只接受控制面的请求
+	creds, err := pki.ServerOption(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load credentials: %v\n", err)
+	}
+	server := grpc.NewServer(creds)
 
 	// 注册 ProbeTaskService 服务
 	protocol.RegisterProbeTaskServiceServer(server, &ProbeTaskServiceServer{})
 
+	// 注册 RouteService 服务，接收控制面下发的路由表
+	protocol.RegisterRouteServiceServer(server, router.NewRouteServiceServer(router.DefaultRouteTable()))
+
 	// 监听端口
-	lis, err := net.Listen("tcp", ":50051")
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.ProbePort))
 	if err != nil {
 		log.Fatalf("Failed to listen: %v\n", err)
 	}
 
 	// 启动服务器
-	fmt.Println("ProbeTaskService server is listening on port 50051")
+	fmt.Printf("ProbeTaskService server is listening on port %d\n", cfg.ProbePort)
 	if err := server.Serve(lis); err != nil {
 		log.Fatalf("Failed to serve: %v\n", err)
 	}
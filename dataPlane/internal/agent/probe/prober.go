@@ -0,0 +1,405 @@
+package probe
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"dataPlane/internal/agent/probe/protocol"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// 默认探测超时时间
+var DefaultProbeTimeout = 5 * time.Second
+
+// 各探测类型的默认目标端口，不在其中的探测类型默认探测对端的 Sirius 回显服务端口
+var DefaultProbePorts = map[protocol.ProbeType]int{
+	protocol.ProbeType_PROBE_TYPE_HTTP:  80,
+	protocol.ProbeType_PROBE_TYPE_HTTPS: 443,
+	protocol.ProbeType_PROBE_TYPE_TLS:   443,
+}
+
+// Prober 探测器接口，对目标执行一次探测并返回测得的时延
+type Prober interface {
+	Probe(ctx context.Context, host string, port int) (time.Duration, error)
+}
+
+// TCPProber 测量 TCP 建连时延
+type TCPProber struct{}
+
+// Probe 实现 Prober 接口
+func (TCPProber) Probe(ctx context.Context, host string, port int) (time.Duration, error) {
+	var dialer net.Dialer
+	startTime := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return 0, err
+	}
+	delay := time.Since(startTime)
+	conn.Close()
+	return delay, nil
+}
+
+// UDPProber 向目标的 Sirius 回显服务发送带会话标识和序号的回显报文，测量收到对应回显的往返时延
+// 同一个探测器的多个样本属于同一会话并复用同一个套接字，根据回显中的计数区分去程和回程丢包、发现乱序
+type UDPProber struct {
+	echoCounter
+	conn net.Conn
+}
+
+// Probe 实现 Prober 接口
+func (p *UDPProber) Probe(ctx context.Context, host string, port int) (time.Duration, error) {
+	if p.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return 0, err
+		}
+		p.conn = conn
+	}
+	deadline, _ := ctx.Deadline()
+	p.conn.SetDeadline(deadline)
+
+	request := p.next()
+	startTime := time.Now()
+	if _, err := p.conn.Write(request.marshal()); err != nil {
+		return 0, err
+	}
+	p.sent++
+	buf := make([]byte, 1500)
+	for {
+		n, err := p.conn.Read(buf)
+		if err != nil {
+			return 0, err
+		}
+		// 之前超时样本迟到的回显只计数
+		if p.match(request, buf[:n]) {
+			return time.Since(startTime), nil
+		}
+	}
+}
+
+// Close 关闭探测使用的套接字
+func (p *UDPProber) Close() error {
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.Close()
+}
+
+// TCPEchoProber 在与目标 Sirius 回显服务建立的连接上发送回显报文，测量不含建连的往返时延
+// 同一个探测器的多个样本复用同一个连接，样本失败后关闭连接，下一个样本重新建连
+type TCPEchoProber struct {
+	echo echoCounter
+	conn net.Conn
+}
+
+// Probe 实现 Prober 接口
+func (p *TCPEchoProber) Probe(ctx context.Context, host string, port int) (time.Duration, error) {
+	if p.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return 0, err
+		}
+		p.conn = conn
+	}
+	deadline, _ := ctx.Deadline()
+	p.conn.SetDeadline(deadline)
+
+	request := p.echo.next()
+	startTime := time.Now()
+	delay, err := func() (time.Duration, error) {
+		if _, err := p.conn.Write(request.marshal()); err != nil {
+			return 0, err
+		}
+		buf := make([]byte, echoSize)
+		for {
+			if _, err := io.ReadFull(p.conn, buf); err != nil {
+				return 0, err
+			}
+			if p.echo.match(request, buf) {
+				return time.Since(startTime), nil
+			}
+		}
+	}()
+	if err != nil {
+		p.Close()
+	}
+	return delay, err
+}
+
+// Close 关闭探测使用的连接
+func (p *TCPEchoProber) Close() error {
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// echoCounter 探测方记录的一个回显会话的收发情况
+type echoCounter struct {
+	session   uint32
+	seq       uint32 // 下一个报文的序号
+	sent      int    // 发出的报文数
+	replies   int    // 收到的回显数，含迟到的回显
+	received  uint32 // 回显服务收到的报文数，取回显中的最大值
+	reordered uint32 // 去程乱序的报文数，取回显中的最大值
+	late      int    // 迟到的回显数，即回程乱序的报文数
+}
+
+// next 生成会话中的下一个回显报文，第一个报文随机生成会话标识
+func (c *echoCounter) next() *echoFrame {
+	if c.seq == 0 {
+		var b [4]byte
+		rand.Read(b[:])
+		c.session = binary.BigEndian.Uint32(b[:])
+	}
+	f := &echoFrame{Session: c.session, Seq: c.seq, SentAt: time.Now().UnixNano()}
+	c.seq++
+	return f
+}
+
+// match 判断 b 是否为 request 的回显，同时记录本会话全部回显中的计数
+func (c *echoCounter) match(request *echoFrame, b []byte) bool {
+	reply, ok := parseEchoFrame(b)
+	if !ok || reply.Session != request.Session || reply.Seq > request.Seq {
+		return false
+	}
+	c.replies++
+	c.received = max(c.received, reply.Received)
+	c.reordered = max(c.reordered, reply.Reordered)
+	if reply.Seq < request.Seq {
+		c.late++
+		return false
+	}
+	return true
+}
+
+// pathStats 本会话的单向丢包和乱序
+// 去程丢包按回显服务收到的报文数计算，最后几个报文的回显全部丢失时会把回程丢包计入去程；
+// 对端没有填写计数（例如只是原样返回报文）或没有收到任何回显时返回 false
+func (c *echoCounter) pathStats() (PathStats, bool) {
+	if c.received == 0 || c.sent == 0 {
+		return PathStats{}, false
+	}
+	received := min(int(c.received), c.sent)
+	return PathStats{
+		ForwardLoss: float64(c.sent-received) / float64(c.sent),
+		ReverseLoss: float64(received-min(c.replies, received)) / float64(received),
+		Reordered:   int(c.reordered) + c.late,
+	}, true
+}
+
+// HTTPProber 测量 HTTP(S) 首字节时延，包含建连、TLS 握手和服务端处理时间
+type HTTPProber struct {
+	TLS  bool   // 是否使用 HTTPS
+	Path string // 请求路径
+}
+
+// Probe 实现 Prober 接口
+func (p HTTPProber) Probe(ctx context.Context, host string, port int) (time.Duration, error) {
+	scheme := "http"
+	if p.TLS {
+		scheme = "https"
+	}
+	path := p.Path
+	if path == "" {
+		path = "/"
+	}
+	url := fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host, strconv.Itoa(port)), path)
+
+	var firstByte time.Time
+	trace := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			// 只测量时延，不校验证书，目标通常以 IP 访问
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	startTime := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if firstByte.IsZero() {
+		return 0, errors.New("no response byte received")
+	}
+	return firstByte.Sub(startTime), nil
+}
+
+// TLSProber 测量 TLS 握手时延，不包含 TCP 建连
+type TLSProber struct{}
+
+// Probe 实现 Prober 接口
+func (TLSProber) Probe(ctx context.Context, host string, port int) (time.Duration, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	// 只测量时延，不校验证书
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+	startTime := time.Now()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return 0, err
+	}
+	return time.Since(startTime), nil
+}
+
+// ICMPProber 测量 ICMP Echo 往返时延
+// 优先使用无需 root 的 ICMP 数据报套接字（需 net.ipv4.ping_group_range 允许），失败时使用原始套接字
+type ICMPProber struct{}
+
+// Probe 实现 Prober 接口，port 无意义
+func (ICMPProber) Probe(ctx context.Context, host string, port int) (time.Duration, error) {
+	ipAddr, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return 0, err
+	}
+	var dst net.IP
+	for _, addr := range ipAddr {
+		if addr.IP.To4() != nil {
+			dst = addr.IP
+			break
+		}
+	}
+	if dst == nil {
+		return 0, fmt.Errorf("no IPv4 address for %s", host)
+	}
+
+	privileged := false
+	conn, err := icmp.ListenPacket("udp4", "0.0.0.0")
+	if err != nil {
+		conn, err = icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+		if err != nil {
+			return 0, err
+		}
+		privileged = true
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	id := os.Getpid() & 0xffff
+	seq := int(time.Now().UnixNano() & 0xffff)
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("sirius")},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+	var target net.Addr = &net.UDPAddr{IP: dst}
+	if privileged {
+		target = &net.IPAddr{IP: dst}
+	}
+
+	startTime := time.Now()
+	if _, err := conn.WriteTo(data, target); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		reply, err := icmp.ParseMessage(1, buf[:n])
+		if err != nil || reply.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+		echo, ok := reply.Body.(*icmp.Echo)
+		// 数据报套接字的 ID 由内核改写，只比较序号
+		if !ok || echo.Seq != seq || (privileged && echo.ID != id) {
+			continue
+		}
+		return time.Since(startTime), nil
+	}
+}
+
+// classifyError 将探测错误归类，便于控制面区分超时、拒绝和不可达
+func classifyError(err error) protocol.ProbeErrorClass {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return protocol.ProbeErrorClass_PROBE_ERROR_NONE
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return protocol.ProbeErrorClass_PROBE_ERROR_TIMEOUT
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return protocol.ProbeErrorClass_PROBE_ERROR_REFUSED
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.EHOSTDOWN):
+		return protocol.ProbeErrorClass_PROBE_ERROR_UNREACHABLE
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.EOF):
+		return protocol.ProbeErrorClass_PROBE_ERROR_RESET
+	default:
+		return protocol.ProbeErrorClass_PROBE_ERROR_OTHER
+	}
+}
+
+// newProber 根据探测任务创建探测器
+func newProber(task *protocol.ProbeTask) (Prober, error) {
+	switch task.Type {
+	case protocol.ProbeType_PROBE_TYPE_TCP:
+		return TCPProber{}, nil
+	case protocol.ProbeType_PROBE_TYPE_UDP:
+		return &UDPProber{}, nil
+	case protocol.ProbeType_PROBE_TYPE_TCP_ECHO:
+		return &TCPEchoProber{}, nil
+	case protocol.ProbeType_PROBE_TYPE_HTTP:
+		return HTTPProber{Path: task.Path}, nil
+	case protocol.ProbeType_PROBE_TYPE_HTTPS:
+		return HTTPProber{TLS: true, Path: task.Path}, nil
+	case protocol.ProbeType_PROBE_TYPE_TLS:
+		return TLSProber{}, nil
+	case protocol.ProbeType_PROBE_TYPE_ICMP:
+		return ICMPProber{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported probe type %v", task.Type)
+	}
+}
+
+// probeParams 探测任务的目标端口和超时，未指定时使用默认值，responderPort 为对端回显服务的端口
+func probeParams(task *protocol.ProbeTask, responderPort int) (int, time.Duration) {
+	port := int(task.Port)
+	if port == 0 {
+		var ok bool
+		if port, ok = DefaultProbePorts[task.Type]; !ok {
+			port = responderPort
+		}
+	}
+	timeout := time.Duration(task.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+	return port, timeout
+}
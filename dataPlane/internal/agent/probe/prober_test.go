@@ -0,0 +1,110 @@
+package probe
+
+import (
+	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/probe/protocol"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// splitHostPort 拆分测试服务地址
+func splitHostPort(t *testing.T, addr string) (string, int) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("invalid address %s: %v", addr, err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
+// runProber 对目标执行一次探测
+func runProber(t *testing.T, prober Prober, addr string) time.Duration {
+	host, port := splitHostPort(t, addr)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	delay, err := prober.Probe(ctx, host, port)
+	if err != nil {
+		t.Fatalf("%T probe failed: %v", prober, err)
+	}
+	if delay <= 0 {
+		t.Fatalf("%T returned non-positive delay %v", prober, delay)
+	}
+	return delay
+}
+
+// TestTCPProber 测试 TCP 建连探测，以及端口未监听时返回错误
+func TestTCPProber(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	runProber(t, TCPProber{}, addr)
+
+	lis.Close()
+	host, port := splitHostPort(t, addr)
+	if _, err := (TCPProber{}).Probe(context.Background(), host, port); err == nil {
+		t.Fatal("expected error probing closed port")
+	}
+}
+
+// TestUDPProber 测试 UDP 回显探测
+func TestUDPProber(t *testing.T) {
+	r, err := ListenResponder("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenResponder failed: %v", err)
+	}
+	defer r.Close()
+	prober := &UDPProber{}
+	defer prober.Close()
+	runProber(t, prober, r.Addr().String())
+}
+
+// TestHTTPProber 测试 HTTP、HTTPS 首字节探测和 TLS 握手探测
+func TestHTTPProber(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+
+	runProber(t, HTTPProber{Path: "/health"}, plain.Listener.Addr().String())
+	runProber(t, HTTPProber{TLS: true}, secure.Listener.Addr().String())
+	runProber(t, TLSProber{}, secure.Listener.Addr().String())
+}
+
+// TestNewProber 测试按任务选择探测器和默认参数
+func TestNewProber(t *testing.T) {
+	task := &protocol.ProbeTask{Type: protocol.ProbeType_PROBE_TYPE_HTTPS, Path: "/ping"}
+	prober, err := newProber(task)
+	if err != nil {
+		t.Fatalf("newProber failed: %v", err)
+	}
+	if p, ok := prober.(HTTPProber); !ok || !p.TLS || p.Path != "/ping" {
+		t.Fatalf("unexpected prober %#v", prober)
+	}
+	responderPort := config.Default().ResponderPort
+	if port, timeout := probeParams(task, responderPort); port != 443 || timeout != DefaultProbeTimeout {
+		t.Fatalf("unexpected defaults: port=%d timeout=%v", port, timeout)
+	}
+	// TCP、TCP 回显和 UDP 探测默认探测对端的回显服务
+	for _, probeType := range []protocol.ProbeType{protocol.ProbeType_PROBE_TYPE_TCP, protocol.ProbeType_PROBE_TYPE_TCP_ECHO, protocol.ProbeType_PROBE_TYPE_UDP} {
+		if port, _ := probeParams(&protocol.ProbeTask{Type: probeType}, responderPort); port != responderPort {
+			t.Fatalf("%v: expected responder port %d, got %d", probeType, responderPort, port)
+		}
+	}
+	task = &protocol.ProbeTask{Type: protocol.ProbeType_PROBE_TYPE_TCP, Port: 8080, TimeoutMs: 200}
+	if port, timeout := probeParams(task, responderPort); port != 8080 || timeout != 200*time.Millisecond {
+		t.Fatalf("unexpected params: port=%d timeout=%v", port, timeout)
+	}
+	if _, err := newProber(&protocol.ProbeTask{Type: protocol.ProbeType(99)}); err == nil {
+		t.Fatal("expected error for unknown probe type")
+	}
+}
@@ -21,10 +21,185 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// 探测任务的更新方式
+type ProbeTaskUpdate int32
+
+const (
+	ProbeTaskUpdate_PROBE_TASK_REPLACE ProbeTaskUpdate = 0 // 用本次下发的任务替换数据面的全部任务
+	ProbeTaskUpdate_PROBE_TASK_ADD     ProbeTaskUpdate = 1 // 新增本次下发的任务，目标和探测类型相同的任务被覆盖，其余任务不变
+	ProbeTaskUpdate_PROBE_TASK_REMOVE  ProbeTaskUpdate = 2 // 删除目标和探测类型相同的任务，其余任务不变
+)
+
+// Enum value maps for ProbeTaskUpdate.
+var (
+	ProbeTaskUpdate_name = map[int32]string{
+		0: "PROBE_TASK_REPLACE",
+		1: "PROBE_TASK_ADD",
+		2: "PROBE_TASK_REMOVE",
+	}
+	ProbeTaskUpdate_value = map[string]int32{
+		"PROBE_TASK_REPLACE": 0,
+		"PROBE_TASK_ADD":     1,
+		"PROBE_TASK_REMOVE":  2,
+	}
+)
+
+func (x ProbeTaskUpdate) Enum() *ProbeTaskUpdate {
+	p := new(ProbeTaskUpdate)
+	*p = x
+	return p
+}
+
+func (x ProbeTaskUpdate) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProbeTaskUpdate) Descriptor() protoreflect.EnumDescriptor {
+	return file_probe_proto_enumTypes[0].Descriptor()
+}
+
+func (ProbeTaskUpdate) Type() protoreflect.EnumType {
+	return &file_probe_proto_enumTypes[0]
+}
+
+func (x ProbeTaskUpdate) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProbeTaskUpdate.Descriptor instead.
+func (ProbeTaskUpdate) EnumDescriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{0}
+}
+
+// 探测类型
+type ProbeType int32
+
+const (
+	ProbeType_PROBE_TYPE_TCP      ProbeType = 0 // TCP 建连时延
+	ProbeType_PROBE_TYPE_UDP      ProbeType = 1 // UDP 回显往返时延，目标需运行 Sirius 回显服务
+	ProbeType_PROBE_TYPE_HTTP     ProbeType = 2 // HTTP 首字节时延
+	ProbeType_PROBE_TYPE_HTTPS    ProbeType = 3 // HTTPS 首字节时延
+	ProbeType_PROBE_TYPE_TLS      ProbeType = 4 // TLS 握手时延，不含 TCP 建连
+	ProbeType_PROBE_TYPE_ICMP     ProbeType = 5 // ICMP Echo 往返时延
+	ProbeType_PROBE_TYPE_TCP_ECHO ProbeType = 6 // 在已建立的 TCP 连接上测量回显往返时延，目标需运行 Sirius 回显服务
+)
+
+// Enum value maps for ProbeType.
+var (
+	ProbeType_name = map[int32]string{
+		0: "PROBE_TYPE_TCP",
+		1: "PROBE_TYPE_UDP",
+		2: "PROBE_TYPE_HTTP",
+		3: "PROBE_TYPE_HTTPS",
+		4: "PROBE_TYPE_TLS",
+		5: "PROBE_TYPE_ICMP",
+		6: "PROBE_TYPE_TCP_ECHO",
+	}
+	ProbeType_value = map[string]int32{
+		"PROBE_TYPE_TCP":      0,
+		"PROBE_TYPE_UDP":      1,
+		"PROBE_TYPE_HTTP":     2,
+		"PROBE_TYPE_HTTPS":    3,
+		"PROBE_TYPE_TLS":      4,
+		"PROBE_TYPE_ICMP":     5,
+		"PROBE_TYPE_TCP_ECHO": 6,
+	}
+)
+
+func (x ProbeType) Enum() *ProbeType {
+	p := new(ProbeType)
+	*p = x
+	return p
+}
+
+func (x ProbeType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProbeType) Descriptor() protoreflect.EnumDescriptor {
+	return file_probe_proto_enumTypes[1].Descriptor()
+}
+
+func (ProbeType) Type() protoreflect.EnumType {
+	return &file_probe_proto_enumTypes[1]
+}
+
+func (x ProbeType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProbeType.Descriptor instead.
+func (ProbeType) EnumDescriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{1}
+}
+
+// 探测失败的原因
+type ProbeErrorClass int32
+
+const (
+	ProbeErrorClass_PROBE_ERROR_NONE        ProbeErrorClass = 0 // 探测成功
+	ProbeErrorClass_PROBE_ERROR_TIMEOUT     ProbeErrorClass = 1 // 超时
+	ProbeErrorClass_PROBE_ERROR_REFUSED     ProbeErrorClass = 2 // 连接被拒绝
+	ProbeErrorClass_PROBE_ERROR_UNREACHABLE ProbeErrorClass = 3 // 网络或主机不可达
+	ProbeErrorClass_PROBE_ERROR_RESET       ProbeErrorClass = 4 // 连接被重置
+	ProbeErrorClass_PROBE_ERROR_OTHER       ProbeErrorClass = 5 // 其他错误
+)
+
+// Enum value maps for ProbeErrorClass.
+var (
+	ProbeErrorClass_name = map[int32]string{
+		0: "PROBE_ERROR_NONE",
+		1: "PROBE_ERROR_TIMEOUT",
+		2: "PROBE_ERROR_REFUSED",
+		3: "PROBE_ERROR_UNREACHABLE",
+		4: "PROBE_ERROR_RESET",
+		5: "PROBE_ERROR_OTHER",
+	}
+	ProbeErrorClass_value = map[string]int32{
+		"PROBE_ERROR_NONE":        0,
+		"PROBE_ERROR_TIMEOUT":     1,
+		"PROBE_ERROR_REFUSED":     2,
+		"PROBE_ERROR_UNREACHABLE": 3,
+		"PROBE_ERROR_RESET":       4,
+		"PROBE_ERROR_OTHER":       5,
+	}
+)
+
+func (x ProbeErrorClass) Enum() *ProbeErrorClass {
+	p := new(ProbeErrorClass)
+	*p = x
+	return p
+}
+
+func (x ProbeErrorClass) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProbeErrorClass) Descriptor() protoreflect.EnumDescriptor {
+	return file_probe_proto_enumTypes[2].Descriptor()
+}
+
+func (ProbeErrorClass) Type() protoreflect.EnumType {
+	return &file_probe_proto_enumTypes[2]
+}
+
+func (x ProbeErrorClass) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProbeErrorClass.Descriptor instead.
+func (ProbeErrorClass) EnumDescriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{2}
+}
+
 // 定义 ProbeTaskRequest，包含多个探测任务
 type ProbeTaskRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Tasks         []*ProbeTask           `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"` // 多个探测任务
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Tasks  []*ProbeTask           `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"`                               // 多个探测任务
+	Update ProbeTaskUpdate        `protobuf:"varint,2,opt,name=update,proto3,enum=probe.ProbeTaskUpdate" json:"update,omitempty"` // 更新方式，默认替换全部任务
+	// 任务表版本号：替换时不得小于数据面当前的版本，增删时必须等于当前的版本，否则数据面拒绝更新并返回 "stale"
+	// 0 表示旧版本控制面，总是替换全部任务
+	Generation    uint64 `protobuf:"varint,3,opt,name=generation,proto3" json:"generation,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -66,13 +241,38 @@ func (x *ProbeTaskRequest) GetTasks() []*ProbeTask {
 	return nil
 }
 
+func (x *ProbeTaskRequest) GetUpdate() ProbeTaskUpdate {
+	if x != nil {
+		return x.Update
+	}
+	return ProbeTaskUpdate_PROBE_TASK_REPLACE
+}
+
+func (x *ProbeTaskRequest) GetGeneration() uint64 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
 // 定义单个探测任务
 type ProbeTask struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ip1           string                 `protobuf:"bytes,1,opt,name=ip1,proto3" json:"ip1,omitempty"` // 源 IP 地址
-	Ip2           string                 `protobuf:"bytes,2,opt,name=ip2,proto3" json:"ip2,omitempty"` // 目标 IP 地址
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Ip1             string                 `protobuf:"bytes,1,opt,name=ip1,proto3" json:"ip1,omitempty"`                                                    // 源 IP 地址
+	Ip2             string                 `protobuf:"bytes,2,opt,name=ip2,proto3" json:"ip2,omitempty"`                                                    // 目标 IP 地址
+	NodeId1         string                 `protobuf:"bytes,3,opt,name=node_id1,json=nodeId1,proto3" json:"node_id1,omitempty"`                             // 源节点 ID
+	NodeId2         string                 `protobuf:"bytes,4,opt,name=node_id2,json=nodeId2,proto3" json:"node_id2,omitempty"`                             // 目标节点 ID
+	Type            ProbeType              `protobuf:"varint,5,opt,name=type,proto3,enum=probe.ProbeType" json:"type,omitempty"`                            // 探测类型
+	Port            int32                  `protobuf:"varint,6,opt,name=port,proto3" json:"port,omitempty"`                                                 // 目标端口，0 表示使用该探测类型的默认端口
+	TimeoutMs       int64                  `protobuf:"varint,7,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`                      // 单次探测超时，单位毫秒，0 表示使用默认超时
+	Path            string                 `protobuf:"bytes,8,opt,name=path,proto3" json:"path,omitempty"`                                                  // HTTP(S) 探测的请求路径，默认为 "/"
+	Samples         int32                  `protobuf:"varint,9,opt,name=samples,proto3" json:"samples,omitempty"`                                           // 每次探测发送的样本数，0 表示使用默认值
+	IntervalMs      int64                  `protobuf:"varint,10,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`                  // 相邻样本之间的间隔，单位毫秒，0 表示使用默认值
+	Phase           float64                `protobuf:"fixed64,11,opt,name=phase,proto3" json:"phase,omitempty"`                                             // 任务在探测周期内的开始位置，0 到 1 之间，数据面按自己的探测间隔换算为延迟，用于错开探测
+	ProbeIntervalMs int64                  `protobuf:"varint,12,opt,name=probe_interval_ms,json=probeIntervalMs,proto3" json:"probe_interval_ms,omitempty"` // 本任务的探测间隔，单位毫秒，按数据面的探测间隔取整，0 表示每轮都探测
+	TtlMs           int64                  `protobuf:"varint,13,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"`                                 // 任务有效期，单位毫秒，从数据面收到任务时起算，过期后删除，0 表示不过期
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ProbeTask) Reset() {
@@ -119,10 +319,89 @@ func (x *ProbeTask) GetIp2() string {
 	return ""
 }
 
+func (x *ProbeTask) GetNodeId1() string {
+	if x != nil {
+		return x.NodeId1
+	}
+	return ""
+}
+
+func (x *ProbeTask) GetNodeId2() string {
+	if x != nil {
+		return x.NodeId2
+	}
+	return ""
+}
+
+func (x *ProbeTask) GetType() ProbeType {
+	if x != nil {
+		return x.Type
+	}
+	return ProbeType_PROBE_TYPE_TCP
+}
+
+func (x *ProbeTask) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *ProbeTask) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+func (x *ProbeTask) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ProbeTask) GetSamples() int32 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *ProbeTask) GetIntervalMs() int64 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+func (x *ProbeTask) GetPhase() float64 {
+	if x != nil {
+		return x.Phase
+	}
+	return 0
+}
+
+func (x *ProbeTask) GetProbeIntervalMs() int64 {
+	if x != nil {
+		return x.ProbeIntervalMs
+	}
+	return 0
+}
+
+func (x *ProbeTask) GetTtlMs() int64 {
+	if x != nil {
+		return x.TtlMs
+	}
+	return 0
+}
+
 // 控制面返回任务执行结果的响应
 type ProbeTaskResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 返回状态信息，例如 "ok"
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`                         // 返回状态信息，"ok" 或 "stale"
+	Generation    uint64                 `protobuf:"varint,2,opt,name=generation,proto3" json:"generation,omitempty"`                // 数据面当前的任务表版本
+	TaskCount     int32                  `protobuf:"varint,3,opt,name=task_count,json=taskCount,proto3" json:"task_count,omitempty"` // 数据面当前的任务数
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -164,28 +443,41 @@ func (x *ProbeTaskResponse) GetStatus() string {
 	return ""
 }
 
-// 定义 ProbeResultRequest，包含多个探测结果
-type ProbeResultRequest struct {
+func (x *ProbeTaskResponse) GetGeneration() uint64 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
+func (x *ProbeTaskResponse) GetTaskCount() int32 {
+	if x != nil {
+		return x.TaskCount
+	}
+	return 0
+}
+
+// 查询数据面当前探测任务的请求
+type ListProbeTasksRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Results       []*ProbeResult         `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"` // 多个探测结果
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProbeResultRequest) Reset() {
-	*x = ProbeResultRequest{}
+func (x *ListProbeTasksRequest) Reset() {
+	*x = ListProbeTasksRequest{}
 	mi := &file_probe_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProbeResultRequest) String() string {
+func (x *ListProbeTasksRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProbeResultRequest) ProtoMessage() {}
+func (*ListProbeTasksRequest) ProtoMessage() {}
 
-func (x *ProbeResultRequest) ProtoReflect() protoreflect.Message {
+func (x *ListProbeTasksRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_probe_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -197,43 +489,35 @@ func (x *ProbeResultRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProbeResultRequest.ProtoReflect.Descriptor instead.
-func (*ProbeResultRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListProbeTasksRequest.ProtoReflect.Descriptor instead.
+func (*ListProbeTasksRequest) Descriptor() ([]byte, []int) {
 	return file_probe_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *ProbeResultRequest) GetResults() []*ProbeResult {
-	if x != nil {
-		return x.Results
-	}
-	return nil
-}
-
-// 定义 ProbeResult，包含探测结果
-type ProbeResult struct {
+// 数据面当前的探测任务
+type ListProbeTasksResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ip1           string                 `protobuf:"bytes,1,opt,name=ip1,proto3" json:"ip1,omitempty"`                            // 源 IP 地址
-	Ip2           string                 `protobuf:"bytes,2,opt,name=ip2,proto3" json:"ip2,omitempty"`                            // 目标 IP 地址
-	TcpDelay      int64                  `protobuf:"varint,3,opt,name=tcp_delay,json=tcpDelay,proto3" json:"tcp_delay,omitempty"` // TCP 延迟，单位纳秒
-	Timestamp     string                 `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                // 时间戳，格式为 RFC3339
+	Generation    uint64                 `protobuf:"varint,1,opt,name=generation,proto3" json:"generation,omitempty"` // 任务表版本
+	Tasks         []*ProbeTaskState      `protobuf:"bytes,2,rep,name=tasks,proto3" json:"tasks,omitempty"`            // 全部未过期的任务，按收到的先后排列
+	Rounds        *ProbeRoundStats       `protobuf:"bytes,3,opt,name=rounds,proto3" json:"rounds,omitempty"`          // 探测轮次的统计
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProbeResult) Reset() {
-	*x = ProbeResult{}
+func (x *ListProbeTasksResponse) Reset() {
+	*x = ListProbeTasksResponse{}
 	mi := &file_probe_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProbeResult) String() string {
+func (x *ListProbeTasksResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProbeResult) ProtoMessage() {}
+func (*ListProbeTasksResponse) ProtoMessage() {}
 
-func (x *ProbeResult) ProtoReflect() protoreflect.Message {
+func (x *ListProbeTasksResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_probe_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -245,62 +529,143 @@ func (x *ProbeResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProbeResult.ProtoReflect.Descriptor instead.
-func (*ProbeResult) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListProbeTasksResponse.ProtoReflect.Descriptor instead.
+func (*ListProbeTasksResponse) Descriptor() ([]byte, []int) {
 	return file_probe_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *ProbeResult) GetIp1() string {
+func (x *ListProbeTasksResponse) GetGeneration() uint64 {
 	if x != nil {
-		return x.Ip1
+		return x.Generation
 	}
-	return ""
+	return 0
 }
 
-func (x *ProbeResult) GetIp2() string {
+func (x *ListProbeTasksResponse) GetTasks() []*ProbeTaskState {
 	if x != nil {
-		return x.Ip2
+		return x.Tasks
 	}
-	return ""
+	return nil
 }
 
-func (x *ProbeResult) GetTcpDelay() int64 {
+func (x *ListProbeTasksResponse) GetRounds() *ProbeRoundStats {
 	if x != nil {
-		return x.TcpDelay
+		return x.Rounds
+	}
+	return nil
+}
+
+// 数据面探测轮次的统计，超时的轮次说明探测无法在一个探测间隔内完成
+type ProbeRoundStats struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Rounds         uint64                 `protobuf:"varint,1,opt,name=rounds,proto3" json:"rounds,omitempty"`                                         // 已执行的轮数
+	Overruns       uint64                 `protobuf:"varint,2,opt,name=overruns,proto3" json:"overruns,omitempty"`                                     // 超时的轮数：耗时超过探测间隔，或有任务被跳过、截断
+	Skipped        uint64                 `protobuf:"varint,3,opt,name=skipped,proto3" json:"skipped,omitempty"`                                       // 截止时间已到仍未开始而被跳过的任务数
+	Cut            uint64                 `protobuf:"varint,4,opt,name=cut,proto3" json:"cut,omitempty"`                                               // 截止时间已到时尚未发完全部样本的任务数
+	LastDurationMs int64                  `protobuf:"varint,5,opt,name=last_duration_ms,json=lastDurationMs,proto3" json:"last_duration_ms,omitempty"` // 最近一轮的耗时，单位毫秒
+	MaxDurationMs  int64                  `protobuf:"varint,6,opt,name=max_duration_ms,json=maxDurationMs,proto3" json:"max_duration_ms,omitempty"`    // 耗时最长的一轮，单位毫秒
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ProbeRoundStats) Reset() {
+	*x = ProbeRoundStats{}
+	mi := &file_probe_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeRoundStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeRoundStats) ProtoMessage() {}
+
+func (x *ProbeRoundStats) ProtoReflect() protoreflect.Message {
+	mi := &file_probe_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeRoundStats.ProtoReflect.Descriptor instead.
+func (*ProbeRoundStats) Descriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ProbeRoundStats) GetRounds() uint64 {
+	if x != nil {
+		return x.Rounds
 	}
 	return 0
 }
 
-func (x *ProbeResult) GetTimestamp() string {
+func (x *ProbeRoundStats) GetOverruns() uint64 {
 	if x != nil {
-		return x.Timestamp
+		return x.Overruns
 	}
-	return ""
+	return 0
 }
 
-// 数据面向控制面返回探测结果的响应
-type ProbeResultResponse struct {
+func (x *ProbeRoundStats) GetSkipped() uint64 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+func (x *ProbeRoundStats) GetCut() uint64 {
+	if x != nil {
+		return x.Cut
+	}
+	return 0
+}
+
+func (x *ProbeRoundStats) GetLastDurationMs() int64 {
+	if x != nil {
+		return x.LastDurationMs
+	}
+	return 0
+}
+
+func (x *ProbeRoundStats) GetMaxDurationMs() int64 {
+	if x != nil {
+		return x.MaxDurationMs
+	}
+	return 0
+}
+
+// 单个探测任务及其状态
+type ProbeTaskState struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 返回状态信息，例如 "ok"
+	Task          *ProbeTask             `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	ReceivedAt    int64                  `protobuf:"varint,2,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"` // 收到任务的时间，Unix 毫秒
+	ExpiresAt     int64                  `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`    // 过期时间，Unix 毫秒，0 表示不过期
+	LastRunAt     int64                  `protobuf:"varint,4,opt,name=last_run_at,json=lastRunAt,proto3" json:"last_run_at,omitempty"`  // 最近一次探测的时间，Unix 毫秒，0 表示尚未探测
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProbeResultResponse) Reset() {
-	*x = ProbeResultResponse{}
-	mi := &file_probe_proto_msgTypes[5]
+func (x *ProbeTaskState) Reset() {
+	*x = ProbeTaskState{}
+	mi := &file_probe_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProbeResultResponse) String() string {
+func (x *ProbeTaskState) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProbeResultResponse) ProtoMessage() {}
+func (*ProbeTaskState) ProtoMessage() {}
 
-func (x *ProbeResultResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_probe_proto_msgTypes[5]
+func (x *ProbeTaskState) ProtoReflect() protoreflect.Message {
+	mi := &file_probe_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -311,58 +676,763 @@ func (x *ProbeResultResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProbeResultResponse.ProtoReflect.Descriptor instead.
-func (*ProbeResultResponse) Descriptor() ([]byte, []int) {
-	return file_probe_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use ProbeTaskState.ProtoReflect.Descriptor instead.
+func (*ProbeTaskState) Descriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *ProbeResultResponse) GetStatus() string {
+func (x *ProbeTaskState) GetTask() *ProbeTask {
 	if x != nil {
-		return x.Status
+		return x.Task
 	}
-	return ""
+	return nil
+}
+
+func (x *ProbeTaskState) GetReceivedAt() int64 {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return 0
+}
+
+func (x *ProbeTaskState) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *ProbeTaskState) GetLastRunAt() int64 {
+	if x != nil {
+		return x.LastRunAt
+	}
+	return 0
+}
+
+// 定义 ProbeResultRequest，包含多个探测结果
+type ProbeResultRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*ProbeResult         `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"` // 多个探测结果
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeResultRequest) Reset() {
+	*x = ProbeResultRequest{}
+	mi := &file_probe_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeResultRequest) ProtoMessage() {}
+
+func (x *ProbeResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_probe_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeResultRequest.ProtoReflect.Descriptor instead.
+func (*ProbeResultRequest) Descriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ProbeResultRequest) GetResults() []*ProbeResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// 定义 ProbeResult，包含探测结果
+type ProbeResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ip1           string                 `protobuf:"bytes,1,opt,name=ip1,proto3" json:"ip1,omitempty"`                                                              // 源 IP 地址
+	Ip2           string                 `protobuf:"bytes,2,opt,name=ip2,proto3" json:"ip2,omitempty"`                                                              // 目标 IP 地址
+	TcpDelay      int64                  `protobuf:"varint,3,opt,name=tcp_delay,json=tcpDelay,proto3" json:"tcp_delay,omitempty"`                                   // 平均时延，单位毫秒，保留以兼容旧版本
+	Timestamp     string                 `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                                                  // 时间戳，格式为 RFC3339
+	NodeId1       string                 `protobuf:"bytes,5,opt,name=node_id1,json=nodeId1,proto3" json:"node_id1,omitempty"`                                       // 源节点 ID
+	NodeId2       string                 `protobuf:"bytes,6,opt,name=node_id2,json=nodeId2,proto3" json:"node_id2,omitempty"`                                       // 目标节点 ID
+	Type          ProbeType              `protobuf:"varint,7,opt,name=type,proto3,enum=probe.ProbeType" json:"type,omitempty"`                                      // 探测类型
+	MinUs         int64                  `protobuf:"varint,8,opt,name=min_us,json=minUs,proto3" json:"min_us,omitempty"`                                            // 最小时延，单位微秒
+	AvgUs         int64                  `protobuf:"varint,9,opt,name=avg_us,json=avgUs,proto3" json:"avg_us,omitempty"`                                            // 平均时延，单位微秒
+	MaxUs         int64                  `protobuf:"varint,10,opt,name=max_us,json=maxUs,proto3" json:"max_us,omitempty"`                                           // 最大时延，单位微秒
+	StddevUs      int64                  `protobuf:"varint,11,opt,name=stddev_us,json=stddevUs,proto3" json:"stddev_us,omitempty"`                                  // 时延标准差，单位微秒
+	JitterUs      int64                  `protobuf:"varint,12,opt,name=jitter_us,json=jitterUs,proto3" json:"jitter_us,omitempty"`                                  // 抖动，相邻样本时延差的绝对值的平均，单位微秒
+	Loss          float64                `protobuf:"fixed64,13,opt,name=loss,proto3" json:"loss,omitempty"`                                                         // 丢包率，0 到 1 之间
+	Sent          int32                  `protobuf:"varint,14,opt,name=sent,proto3" json:"sent,omitempty"`                                                          // 发送的样本数
+	Received      int32                  `protobuf:"varint,15,opt,name=received,proto3" json:"received,omitempty"`                                                  // 成功的样本数
+	Failed        bool                   `protobuf:"varint,16,opt,name=failed,proto3" json:"failed,omitempty"`                                                      // 全部样本失败，链路不通
+	ErrorClass    ProbeErrorClass        `protobuf:"varint,17,opt,name=error_class,json=errorClass,proto3,enum=probe.ProbeErrorClass" json:"error_class,omitempty"` // 失败原因分类，取最后一个失败样本
+	Error         string                 `protobuf:"bytes,18,opt,name=error,proto3" json:"error,omitempty"`                                                         // 最后一个失败样本的错误信息
+	OneWay        bool                   `protobuf:"varint,19,opt,name=one_way,json=oneWay,proto3" json:"one_way,omitempty"`                                        // 是否测得单向丢包和乱序，只有对端运行 Sirius 回显服务的 UDP 探测才有
+	ForwardLoss   float64                `protobuf:"fixed64,20,opt,name=forward_loss,json=forwardLoss,proto3" json:"forward_loss,omitempty"`                        // 去程丢包率，回显服务未收到的报文比例
+	ReverseLoss   float64                `protobuf:"fixed64,21,opt,name=reverse_loss,json=reverseLoss,proto3" json:"reverse_loss,omitempty"`                        // 回程丢包率，回显服务收到但回显丢失的报文比例
+	Reordered     int32                  `protobuf:"varint,22,opt,name=reordered,proto3" json:"reordered,omitempty"`                                                // 去程和回程乱序的报文数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeResult) Reset() {
+	*x = ProbeResult{}
+	mi := &file_probe_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeResult) ProtoMessage() {}
+
+func (x *ProbeResult) ProtoReflect() protoreflect.Message {
+	mi := &file_probe_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeResult.ProtoReflect.Descriptor instead.
+func (*ProbeResult) Descriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ProbeResult) GetIp1() string {
+	if x != nil {
+		return x.Ip1
+	}
+	return ""
+}
+
+func (x *ProbeResult) GetIp2() string {
+	if x != nil {
+		return x.Ip2
+	}
+	return ""
+}
+
+func (x *ProbeResult) GetTcpDelay() int64 {
+	if x != nil {
+		return x.TcpDelay
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *ProbeResult) GetNodeId1() string {
+	if x != nil {
+		return x.NodeId1
+	}
+	return ""
+}
+
+func (x *ProbeResult) GetNodeId2() string {
+	if x != nil {
+		return x.NodeId2
+	}
+	return ""
+}
+
+func (x *ProbeResult) GetType() ProbeType {
+	if x != nil {
+		return x.Type
+	}
+	return ProbeType_PROBE_TYPE_TCP
+}
+
+func (x *ProbeResult) GetMinUs() int64 {
+	if x != nil {
+		return x.MinUs
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetAvgUs() int64 {
+	if x != nil {
+		return x.AvgUs
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetMaxUs() int64 {
+	if x != nil {
+		return x.MaxUs
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetStddevUs() int64 {
+	if x != nil {
+		return x.StddevUs
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetJitterUs() int64 {
+	if x != nil {
+		return x.JitterUs
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetLoss() float64 {
+	if x != nil {
+		return x.Loss
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetSent() int32 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetReceived() int32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetFailed() bool {
+	if x != nil {
+		return x.Failed
+	}
+	return false
+}
+
+func (x *ProbeResult) GetErrorClass() ProbeErrorClass {
+	if x != nil {
+		return x.ErrorClass
+	}
+	return ProbeErrorClass_PROBE_ERROR_NONE
+}
+
+func (x *ProbeResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ProbeResult) GetOneWay() bool {
+	if x != nil {
+		return x.OneWay
+	}
+	return false
+}
+
+func (x *ProbeResult) GetForwardLoss() float64 {
+	if x != nil {
+		return x.ForwardLoss
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetReverseLoss() float64 {
+	if x != nil {
+		return x.ReverseLoss
+	}
+	return 0
+}
+
+func (x *ProbeResult) GetReordered() int32 {
+	if x != nil {
+		return x.Reordered
+	}
+	return 0
+}
+
+// 数据面向控制面返回探测结果的响应
+type ProbeResultResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 返回状态信息，例如 "ok"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeResultResponse) Reset() {
+	*x = ProbeResultResponse{}
+	mi := &file_probe_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeResultResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeResultResponse) ProtoMessage() {}
+
+func (x *ProbeResultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_probe_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeResultResponse.ProtoReflect.Descriptor instead.
+func (*ProbeResultResponse) Descriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ProbeResultResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// 定义 RouteTableRequest，包含某个节点的完整路由表
+type RouteTableRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          string                 `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`        // 路由表所属节点 IP
+	Version       uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // 路由表版本号，单调递增
+	Routes        []*RouteEntry          `protobuf:"bytes,3,rep,name=routes,proto3" json:"routes,omitempty"`    // 每个目的节点一条路由
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteTableRequest) Reset() {
+	*x = RouteTableRequest{}
+	mi := &file_probe_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteTableRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteTableRequest) ProtoMessage() {}
+
+func (x *RouteTableRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_probe_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteTableRequest.ProtoReflect.Descriptor instead.
+func (*RouteTableRequest) Descriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RouteTableRequest) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+func (x *RouteTableRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *RouteTableRequest) GetRoutes() []*RouteEntry {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
+}
+
+// 定义单个目的节点的路由
+type RouteEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Destination   string                 `protobuf:"bytes,1,opt,name=destination,proto3" json:"destination,omitempty"`        // 目的节点 IP
+	NextHop       string                 `protobuf:"bytes,2,opt,name=next_hop,json=nextHop,proto3" json:"next_hop,omitempty"` // 最优路径的下一跳节点 IP
+	Paths         []*RoutePath           `protobuf:"bytes,3,rep,name=paths,proto3" json:"paths,omitempty"`                    // 至多 K 条候选路径，第一条为最优路径
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteEntry) Reset() {
+	*x = RouteEntry{}
+	mi := &file_probe_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteEntry) ProtoMessage() {}
+
+func (x *RouteEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_probe_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteEntry.ProtoReflect.Descriptor instead.
+func (*RouteEntry) Descriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RouteEntry) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *RouteEntry) GetNextHop() string {
+	if x != nil {
+		return x.NextHop
+	}
+	return ""
+}
+
+func (x *RouteEntry) GetPaths() []*RoutePath {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+// 定义一条覆盖网络路径
+type RoutePath struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []string               `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`   // 依次经过的节点，包含源节点和目的节点
+	Delay         float64                `protobuf:"fixed64,2,opt,name=delay,proto3" json:"delay,omitempty"` // 路径总延迟，单位毫秒
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoutePath) Reset() {
+	*x = RoutePath{}
+	mi := &file_probe_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoutePath) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoutePath) ProtoMessage() {}
+
+func (x *RoutePath) ProtoReflect() protoreflect.Message {
+	mi := &file_probe_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoutePath.ProtoReflect.Descriptor instead.
+func (*RoutePath) Descriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RoutePath) GetNodes() []string {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *RoutePath) GetDelay() float64 {
+	if x != nil {
+		return x.Delay
+	}
+	return 0
+}
+
+// 数据面返回路由表生效结果的响应
+type RouteTableResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Status         string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`                                        // 返回状态信息，"ok" 或 "stale"
+	AppliedVersion uint64                 `protobuf:"varint,2,opt,name=applied_version,json=appliedVersion,proto3" json:"applied_version,omitempty"` // 数据面当前生效的路由表版本
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RouteTableResponse) Reset() {
+	*x = RouteTableResponse{}
+	mi := &file_probe_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteTableResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteTableResponse) ProtoMessage() {}
+
+func (x *RouteTableResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_probe_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteTableResponse.ProtoReflect.Descriptor instead.
+func (*RouteTableResponse) Descriptor() ([]byte, []int) {
+	return file_probe_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RouteTableResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RouteTableResponse) GetAppliedVersion() uint64 {
+	if x != nil {
+		return x.AppliedVersion
+	}
+	return 0
 }
 
 var File_probe_proto protoreflect.FileDescriptor
 
 var file_probe_proto_rawDesc = string([]byte{
 	0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70,
-	0x72, 0x6f, 0x62, 0x65, 0x22, 0x3a, 0x0a, 0x10, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73,
-	0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x05, 0x74, 0x61, 0x73, 0x6b,
-	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e,
-	0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x05, 0x74, 0x61, 0x73, 0x6b, 0x73,
-	0x22, 0x2f, 0x0a, 0x09, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x10, 0x0a,
-	0x03, 0x69, 0x70, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x69, 0x70, 0x31, 0x12,
-	0x10, 0x0a, 0x03, 0x69, 0x70, 0x32, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x69, 0x70,
-	0x32, 0x22, 0x2b, 0x0a, 0x11, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x42,
-	0x0a, 0x12, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18,
-	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72,
-	0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x73, 0x22, 0x6c, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x70, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x69, 0x70, 0x31, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x70, 0x32, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x03, 0x69, 0x70, 0x32, 0x12, 0x1b, 0x0a, 0x09, 0x74, 0x63, 0x70, 0x5f, 0x64, 0x65, 0x6c,
-	0x61, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x74, 0x63, 0x70, 0x44, 0x65, 0x6c,
-	0x61, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
-	0x22, 0x2d, 0x0a, 0x13, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
-	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x32,
-	0x57, 0x0a, 0x10, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x53, 0x65, 0x72, 0x76,
-	0x69, 0x63, 0x65, 0x12, 0x43, 0x0a, 0x0e, 0x53, 0x65, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x62, 0x65,
-	0x54, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72,
-	0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18,
+	0x72, 0x6f, 0x62, 0x65, 0x22, 0x8a, 0x01, 0x0a, 0x10, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61,
+	0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x05, 0x74, 0x61, 0x73,
+	0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65,
+	0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x05, 0x74, 0x61, 0x73, 0x6b,
+	0x73, 0x12, 0x2e, 0x0a, 0x06, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54,
+	0x61, 0x73, 0x6b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x06, 0x75, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x22, 0xe6, 0x02, 0x0a, 0x09, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x12,
+	0x10, 0x0a, 0x03, 0x69, 0x70, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x69, 0x70,
+	0x31, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x70, 0x32, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x69, 0x70, 0x32, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x31, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x31, 0x12, 0x19,
+	0x0a, 0x08, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x32, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x32, 0x12, 0x24, 0x0a, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e,
+	0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x70,
+	0x6f, 0x72, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d,
+	0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x4d, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65,
+	0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73,
+	0x12, 0x1f, 0x0a, 0x0b, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x6d, 0x73, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4d,
+	0x73, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x70, 0x72, 0x6f, 0x62, 0x65,
+	0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x6d, 0x73, 0x18, 0x0c, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61,
+	0x6c, 0x4d, 0x73, 0x12, 0x15, 0x0a, 0x06, 0x74, 0x74, 0x6c, 0x5f, 0x6d, 0x73, 0x18, 0x0d, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x74, 0x6c, 0x4d, 0x73, 0x22, 0x6a, 0x0a, 0x11, 0x50, 0x72,
+	0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x67, 0x65, 0x6e,
+	0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x61, 0x73, 0x6b, 0x5f,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x74, 0x61, 0x73,
+	0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x17, 0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x72,
+	0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
+	0x95, 0x01, 0x0a, 0x16, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73,
+	0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x67, 0x65,
+	0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a,
+	0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2b, 0x0a, 0x05, 0x74, 0x61,
+	0x73, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x62,
+	0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x52, 0x05, 0x74, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x2e, 0x0a, 0x06, 0x72, 0x6f, 0x75, 0x6e, 0x64,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e,
+	0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
+	0x06, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x22, 0xc3, 0x01, 0x0a, 0x0f, 0x50, 0x72, 0x6f, 0x62,
+	0x65, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x72,
+	0x6f, 0x75, 0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x72, 0x6f, 0x75,
+	0x6e, 0x64, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x75, 0x6e, 0x73, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x75, 0x6e, 0x73, 0x12,
+	0x18, 0x0a, 0x07, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x07, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x75, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x63, 0x75, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x6c,
+	0x61, 0x73, 0x74, 0x5f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x73, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x6c, 0x61, 0x73, 0x74, 0x44, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x4d, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6d, 0x61, 0x78, 0x5f, 0x64, 0x75, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d,
+	0x6d, 0x61, 0x78, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x73, 0x22, 0x96, 0x01,
+	0x0a, 0x0e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x12, 0x24, 0x0a, 0x04, 0x74, 0x61, 0x73, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10,
 	0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0x5f, 0x0a, 0x12, 0x50, 0x72, 0x6f, 0x62,
-	0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x49,
-	0x0a, 0x10, 0x53, 0x65, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x73, 0x12, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65,
-	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e,
+	0x52, 0x04, 0x74, 0x61, 0x73, 0x6b, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x72, 0x65, 0x63,
+	0x65, 0x69, 0x76, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72,
+	0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x12, 0x1e, 0x0a, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x72,
+	0x75, 0x6e, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x6c, 0x61, 0x73,
+	0x74, 0x52, 0x75, 0x6e, 0x41, 0x74, 0x22, 0x42, 0x0a, 0x12, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x07,
+	0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e,
 	0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x3b, 0x70,
+	0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0xef, 0x04, 0x0a, 0x0b, 0x50,
+	0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x70,
+	0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x69, 0x70, 0x31, 0x12, 0x10, 0x0a, 0x03,
+	0x69, 0x70, 0x32, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x69, 0x70, 0x32, 0x12, 0x1b,
+	0x0a, 0x09, 0x74, 0x63, 0x70, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x74, 0x63, 0x70, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x6f, 0x64,
+	0x65, 0x5f, 0x69, 0x64, 0x31, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6e, 0x6f, 0x64,
+	0x65, 0x49, 0x64, 0x31, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x32,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x32, 0x12,
+	0x24, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e,
+	0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x15, 0x0a, 0x06, 0x6d, 0x69, 0x6e, 0x5f, 0x75, 0x73, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6d, 0x69, 0x6e, 0x55, 0x73, 0x12, 0x15, 0x0a, 0x06,
+	0x61, 0x76, 0x67, 0x5f, 0x75, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x61, 0x76,
+	0x67, 0x55, 0x73, 0x12, 0x15, 0x0a, 0x06, 0x6d, 0x61, 0x78, 0x5f, 0x75, 0x73, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x6d, 0x61, 0x78, 0x55, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x74,
+	0x64, 0x64, 0x65, 0x76, 0x5f, 0x75, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x73,
+	0x74, 0x64, 0x64, 0x65, 0x76, 0x55, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6a, 0x69, 0x74, 0x74, 0x65,
+	0x72, 0x5f, 0x75, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x6a, 0x69, 0x74, 0x74,
+	0x65, 0x72, 0x55, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f, 0x73, 0x73, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x04, 0x6c, 0x6f, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x65, 0x6e, 0x74,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x73, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x61, 0x69, 0x6c,
+	0x65, 0x64, 0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64,
+	0x12, 0x37, 0x0a, 0x0b, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18,
+	0x11, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72,
+	0x6f, 0x62, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x52, 0x0a, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12,
+	0x17, 0x0a, 0x07, 0x6f, 0x6e, 0x65, 0x5f, 0x77, 0x61, 0x79, 0x18, 0x13, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x06, 0x6f, 0x6e, 0x65, 0x57, 0x61, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x66, 0x6f, 0x72, 0x77,
+	0x61, 0x72, 0x64, 0x5f, 0x6c, 0x6f, 0x73, 0x73, 0x18, 0x14, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b,
+	0x66, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x4c, 0x6f, 0x73, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x72,
+	0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x5f, 0x6c, 0x6f, 0x73, 0x73, 0x18, 0x15, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x0b, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x4c, 0x6f, 0x73, 0x73, 0x12, 0x1c,
+	0x0a, 0x09, 0x72, 0x65, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x65, 0x64, 0x18, 0x16, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x09, 0x72, 0x65, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x65, 0x64, 0x22, 0x2d, 0x0a, 0x13,
+	0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x6c, 0x0a, 0x11, 0x52,
+	0x6f, 0x75, 0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x29,
+	0x0a, 0x06, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x06, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x22, 0x71, 0x0a, 0x0a, 0x52, 0x6f, 0x75,
+	0x74, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69,
+	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65,
+	0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x65, 0x78,
+	0x74, 0x5f, 0x68, 0x6f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6e, 0x65, 0x78,
+	0x74, 0x48, 0x6f, 0x70, 0x12, 0x26, 0x0a, 0x05, 0x70, 0x61, 0x74, 0x68, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x52, 0x6f, 0x75, 0x74,
+	0x65, 0x50, 0x61, 0x74, 0x68, 0x52, 0x05, 0x70, 0x61, 0x74, 0x68, 0x73, 0x22, 0x37, 0x0a, 0x09,
+	0x52, 0x6f, 0x75, 0x74, 0x65, 0x50, 0x61, 0x74, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x64,
+	0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x12,
+	0x14, 0x0a, 0x05, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05,
+	0x64, 0x65, 0x6c, 0x61, 0x79, 0x22, 0x55, 0x0a, 0x12, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61,
+	0x62, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x61, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x5f, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x61, 0x70,
+	0x70, 0x6c, 0x69, 0x65, 0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x2a, 0x54, 0x0a, 0x0f,
+	0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12,
+	0x16, 0x0a, 0x12, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x52, 0x45,
+	0x50, 0x4c, 0x41, 0x43, 0x45, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x42, 0x45,
+	0x5f, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x41, 0x44, 0x44, 0x10, 0x01, 0x12, 0x15, 0x0a, 0x11, 0x50,
+	0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x52, 0x45, 0x4d, 0x4f, 0x56, 0x45,
+	0x10, 0x02, 0x2a, 0xa0, 0x01, 0x0a, 0x09, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x79, 0x70, 0x65,
+	0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x54,
+	0x43, 0x50, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x55, 0x44, 0x50, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x50, 0x52, 0x4f, 0x42,
+	0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x48, 0x54, 0x54, 0x50, 0x10, 0x02, 0x12, 0x14, 0x0a,
+	0x10, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x48, 0x54, 0x54, 0x50,
+	0x53, 0x10, 0x03, 0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x54, 0x4c, 0x53, 0x10, 0x04, 0x12, 0x13, 0x0a, 0x0f, 0x50, 0x52, 0x4f, 0x42, 0x45,
+	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x49, 0x43, 0x4d, 0x50, 0x10, 0x05, 0x12, 0x17, 0x0a, 0x13,
+	0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x54, 0x43, 0x50, 0x5f, 0x45,
+	0x43, 0x48, 0x4f, 0x10, 0x06, 0x2a, 0xa4, 0x01, 0x0a, 0x0f, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x45,
+	0x72, 0x72, 0x6f, 0x72, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x10, 0x50, 0x52, 0x4f,
+	0x42, 0x45, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12,
+	0x17, 0x0a, 0x13, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x54,
+	0x49, 0x4d, 0x45, 0x4f, 0x55, 0x54, 0x10, 0x01, 0x12, 0x17, 0x0a, 0x13, 0x50, 0x52, 0x4f, 0x42,
+	0x45, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x52, 0x45, 0x46, 0x55, 0x53, 0x45, 0x44, 0x10,
+	0x02, 0x12, 0x1b, 0x0a, 0x17, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52,
+	0x5f, 0x55, 0x4e, 0x52, 0x45, 0x41, 0x43, 0x48, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x03, 0x12, 0x15,
+	0x0a, 0x11, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x52, 0x45,
+	0x53, 0x45, 0x54, 0x10, 0x04, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x45,
+	0x52, 0x52, 0x4f, 0x52, 0x5f, 0x4f, 0x54, 0x48, 0x45, 0x52, 0x10, 0x05, 0x32, 0xa6, 0x01, 0x0a,
+	0x10, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x12, 0x43, 0x0a, 0x0e, 0x53, 0x65, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61,
+	0x73, 0x6b, 0x73, 0x12, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62,
+	0x65, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x70,
+	0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4d, 0x0a, 0x0e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x72,
+	0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x1c, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0x5f, 0x0a, 0x12, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x49, 0x0a, 0x10, 0x53,
+	0x65, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12,
+	0x19, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x70, 0x72, 0x6f,
+	0x62, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0x51, 0x0a, 0x0c, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x41, 0x0a, 0x0a, 0x50, 0x75, 0x73, 0x68, 0x52, 0x6f,
+	0x75, 0x74, 0x65, 0x73, 0x12, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x52, 0x6f, 0x75,
+	0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19,
+	0x2e, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61, 0x62, 0x6c,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x3b, 0x70,
 	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 })
 
@@ -378,27 +1448,52 @@ func file_probe_proto_rawDescGZIP() []byte {
 	return file_probe_proto_rawDescData
 }
 
-var file_probe_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_probe_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_probe_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_probe_proto_goTypes = []any{
-	(*ProbeTaskRequest)(nil),    // 0: probe.ProbeTaskRequest
-	(*ProbeTask)(nil),           // 1: probe.ProbeTask
-	(*ProbeTaskResponse)(nil),   // 2: probe.ProbeTaskResponse
-	(*ProbeResultRequest)(nil),  // 3: probe.ProbeResultRequest
-	(*ProbeResult)(nil),         // 4: probe.ProbeResult
-	(*ProbeResultResponse)(nil), // 5: probe.ProbeResultResponse
+	(ProbeTaskUpdate)(0),           // 0: probe.ProbeTaskUpdate
+	(ProbeType)(0),                 // 1: probe.ProbeType
+	(ProbeErrorClass)(0),           // 2: probe.ProbeErrorClass
+	(*ProbeTaskRequest)(nil),       // 3: probe.ProbeTaskRequest
+	(*ProbeTask)(nil),              // 4: probe.ProbeTask
+	(*ProbeTaskResponse)(nil),      // 5: probe.ProbeTaskResponse
+	(*ListProbeTasksRequest)(nil),  // 6: probe.ListProbeTasksRequest
+	(*ListProbeTasksResponse)(nil), // 7: probe.ListProbeTasksResponse
+	(*ProbeRoundStats)(nil),        // 8: probe.ProbeRoundStats
+	(*ProbeTaskState)(nil),         // 9: probe.ProbeTaskState
+	(*ProbeResultRequest)(nil),     // 10: probe.ProbeResultRequest
+	(*ProbeResult)(nil),            // 11: probe.ProbeResult
+	(*ProbeResultResponse)(nil),    // 12: probe.ProbeResultResponse
+	(*RouteTableRequest)(nil),      // 13: probe.RouteTableRequest
+	(*RouteEntry)(nil),             // 14: probe.RouteEntry
+	(*RoutePath)(nil),              // 15: probe.RoutePath
+	(*RouteTableResponse)(nil),     // 16: probe.RouteTableResponse
 }
 var file_probe_proto_depIdxs = []int32{
-	1, // 0: probe.ProbeTaskRequest.tasks:type_name -> probe.ProbeTask
-	4, // 1: probe.ProbeResultRequest.results:type_name -> probe.ProbeResult
-	0, // 2: probe.ProbeTaskService.SendProbeTasks:input_type -> probe.ProbeTaskRequest
-	3, // 3: probe.ProbeResultService.SendProbeResults:input_type -> probe.ProbeResultRequest
-	2, // 4: probe.ProbeTaskService.SendProbeTasks:output_type -> probe.ProbeTaskResponse
-	5, // 5: probe.ProbeResultService.SendProbeResults:output_type -> probe.ProbeResultResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	4,  // 0: probe.ProbeTaskRequest.tasks:type_name -> probe.ProbeTask
+	0,  // 1: probe.ProbeTaskRequest.update:type_name -> probe.ProbeTaskUpdate
+	1,  // 2: probe.ProbeTask.type:type_name -> probe.ProbeType
+	9,  // 3: probe.ListProbeTasksResponse.tasks:type_name -> probe.ProbeTaskState
+	8,  // 4: probe.ListProbeTasksResponse.rounds:type_name -> probe.ProbeRoundStats
+	4,  // 5: probe.ProbeTaskState.task:type_name -> probe.ProbeTask
+	11, // 6: probe.ProbeResultRequest.results:type_name -> probe.ProbeResult
+	1,  // 7: probe.ProbeResult.type:type_name -> probe.ProbeType
+	2,  // 8: probe.ProbeResult.error_class:type_name -> probe.ProbeErrorClass
+	14, // 9: probe.RouteTableRequest.routes:type_name -> probe.RouteEntry
+	15, // 10: probe.RouteEntry.paths:type_name -> probe.RoutePath
+	3,  // 11: probe.ProbeTaskService.SendProbeTasks:input_type -> probe.ProbeTaskRequest
+	6,  // 12: probe.ProbeTaskService.ListProbeTasks:input_type -> probe.ListProbeTasksRequest
+	10, // 13: probe.ProbeResultService.SendProbeResults:input_type -> probe.ProbeResultRequest
+	13, // 14: probe.RouteService.PushRoutes:input_type -> probe.RouteTableRequest
+	5,  // 15: probe.ProbeTaskService.SendProbeTasks:output_type -> probe.ProbeTaskResponse
+	7,  // 16: probe.ProbeTaskService.ListProbeTasks:output_type -> probe.ListProbeTasksResponse
+	12, // 17: probe.ProbeResultService.SendProbeResults:output_type -> probe.ProbeResultResponse
+	16, // 18: probe.RouteService.PushRoutes:output_type -> probe.RouteTableResponse
+	15, // [15:19] is the sub-list for method output_type
+	11, // [11:15] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_probe_proto_init() }
@@ -411,13 +1506,14 @@ func file_probe_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_probe_proto_rawDesc), len(file_probe_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      3,
+			NumMessages:   14,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   3,
 		},
 		GoTypes:           file_probe_proto_goTypes,
 		DependencyIndexes: file_probe_proto_depIdxs,
+		EnumInfos:         file_probe_proto_enumTypes,
 		MessageInfos:      file_probe_proto_msgTypes,
 	}.Build()
 	File_probe_proto = out.File
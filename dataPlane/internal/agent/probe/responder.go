@@ -0,0 +1,226 @@
+package probe
+
+import (
+	"bytes"
+	"dataPlane/internal/agent/config"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// 回显报文的长度和标识
+const echoSize = 36
+
+var echoMagic = []byte("SRE1")
+
+// 空闲超过该时长的 UDP 会话被清理
+var echoSessionIdle = time.Minute
+
+// 最多同时记录的 UDP 会话数，超过时清理最久没有收到报文的会话，避免伪造来源的报文占满内存
+var echoMaxSessions = 4096
+
+// echoFrame 回显报文，所有字段以大端序依次编码：
+// magic(4) session(4) seq(4) received(4) reordered(4) sentAt(8) echoedAt(8)
+// 探测方只填写 session、seq 和 sentAt，回显服务填写其余字段后原样返回
+type echoFrame struct {
+	Session   uint32 // 探测方为一次探测生成的随机会话标识
+	Seq       uint32 // 会话内从 0 开始的序号
+	Received  uint32 // 回显服务收到该会话的报文数，含本报文
+	Reordered uint32 // 回显服务收到的序号小于此前最大序号的报文数
+	SentAt    int64  // 探测方的发送时间，Unix 纳秒
+	EchoedAt  int64  // 回显服务收到报文的时间，Unix 纳秒
+}
+
+func (f *echoFrame) marshal() []byte {
+	b := make([]byte, echoSize)
+	copy(b, echoMagic)
+	binary.BigEndian.PutUint32(b[4:], f.Session)
+	binary.BigEndian.PutUint32(b[8:], f.Seq)
+	binary.BigEndian.PutUint32(b[12:], f.Received)
+	binary.BigEndian.PutUint32(b[16:], f.Reordered)
+	binary.BigEndian.PutUint64(b[20:], uint64(f.SentAt))
+	binary.BigEndian.PutUint64(b[28:], uint64(f.EchoedAt))
+	return b
+}
+
+// parseEchoFrame 解析回显报文，长度或标识不符时返回 false
+func parseEchoFrame(b []byte) (echoFrame, bool) {
+	if len(b) != echoSize || !bytes.Equal(b[:4], echoMagic) {
+		return echoFrame{}, false
+	}
+	return echoFrame{
+		Session:   binary.BigEndian.Uint32(b[4:]),
+		Seq:       binary.BigEndian.Uint32(b[8:]),
+		Received:  binary.BigEndian.Uint32(b[12:]),
+		Reordered: binary.BigEndian.Uint32(b[16:]),
+		SentAt:    int64(binary.BigEndian.Uint64(b[20:])),
+		EchoedAt:  int64(binary.BigEndian.Uint64(b[28:])),
+	}, true
+}
+
+// echoSession 回显服务记录的单个会话的收包情况
+type echoSession struct {
+	received  uint32
+	reordered uint32
+	maxSeq    uint32
+	lastSeen  time.Time
+}
+
+// record 记录收到的回显报文 f，并填写 f 中由回显服务填写的字段
+func (s *echoSession) record(f *echoFrame, now time.Time) {
+	if s.received > 0 && f.Seq < s.maxSeq {
+		s.reordered++
+	}
+	s.maxSeq = max(s.maxSeq, f.Seq)
+	s.received++
+	s.lastSeen = now
+	f.Received, f.Reordered, f.EchoedAt = s.received, s.reordered, now.UnixNano()
+}
+
+// Responder Sirius 回显服务，在同一端口上提供 TCP 和 UDP 回显，供对端测量往返时延、单向丢包和乱序
+// UDP 会话以来源 IP 和会话标识区分，TCP 每个连接为一个会话；不是回显报文的 UDP 报文直接丢弃，避免被用于反射攻击
+type Responder struct {
+	tcp net.Listener
+	udp net.PacketConn
+
+	mu       sync.Mutex
+	sessions map[string]*echoSession
+	swept    time.Time
+}
+
+// ListenResponder 在 addr 上监听 TCP 和 UDP，端口为 0 时 UDP 使用 TCP 分配到的端口
+func ListenResponder(addr string) (*Responder, error) {
+	tcp, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	udp, err := net.ListenPacket("udp", tcp.Addr().String())
+	if err != nil {
+		tcp.Close()
+		return nil, err
+	}
+	r := &Responder{tcp: tcp, udp: udp, sessions: map[string]*echoSession{}, swept: time.Now()}
+	go r.serveTCP()
+	go r.serveUDP()
+	return r, nil
+}
+
+// Addr 回显服务的监听地址
+func (r *Responder) Addr() net.Addr {
+	return r.tcp.Addr()
+}
+
+// Close 停止回显服务
+func (r *Responder) Close() error {
+	return errors.Join(r.tcp.Close(), r.udp.Close())
+}
+
+func (r *Responder) serveUDP() {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := r.udp.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Responder stopped reading UDP: %v", err)
+			}
+			return
+		}
+		f, ok := parseEchoFrame(buf[:n])
+		if !ok {
+			continue
+		}
+		r.record(addr, &f)
+		r.udp.WriteTo(f.marshal(), addr)
+	}
+}
+
+// record 按会话记录收到的 UDP 回显报文，并顺带清理空闲的会话，会话数达到 echoMaxSessions 时清理最久没有收到报文的会话
+func (r *Responder) record(addr net.Addr, f *echoFrame) {
+	host := addr.String()
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		host = udpAddr.IP.String()
+	}
+	key := fmt.Sprintf("%s/%d", host, f.Session)
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Sub(r.swept) >= echoSessionIdle {
+		for k, s := range r.sessions {
+			if now.Sub(s.lastSeen) >= echoSessionIdle {
+				delete(r.sessions, k)
+			}
+		}
+		r.swept = now
+	}
+	s, ok := r.sessions[key]
+	if !ok {
+		if len(r.sessions) >= echoMaxSessions {
+			r.evictOldest()
+		}
+		s = &echoSession{}
+		r.sessions[key] = s
+	}
+	s.record(f, now)
+}
+
+// evictOldest 清理最久没有收到报文的 UDP 会话，调用方持有 r.mu
+func (r *Responder) evictOldest() {
+	var oldest string
+	var lastSeen time.Time
+	for k, s := range r.sessions {
+		if oldest == "" || s.lastSeen.Before(lastSeen) {
+			oldest, lastSeen = k, s.lastSeen
+		}
+	}
+	delete(r.sessions, oldest)
+}
+
+func (r *Responder) serveTCP() {
+	for {
+		conn, err := r.tcp.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Responder stopped accepting TCP: %v", err)
+			}
+			return
+		}
+		go serveEchoConn(conn)
+	}
+}
+
+// serveEchoConn 逐个回显连接上的回显报文，对端关闭连接或发来其他数据时关闭连接
+// 只建连不发送数据的 TCP 探测同样由此处理
+func serveEchoConn(conn net.Conn) {
+	defer conn.Close()
+	var s echoSession
+	buf := make([]byte, echoSize)
+	for {
+		conn.SetReadDeadline(time.Now().Add(echoSessionIdle))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		f, ok := parseEchoFrame(buf)
+		if !ok {
+			return
+		}
+		s.record(&f, time.Now())
+		if _, err := conn.Write(f.marshal()); err != nil {
+			return
+		}
+	}
+}
+
+// StartResponder 在 cfg.ResponderPort 上启动回显服务，TCP 和 UDP 共用
+// 监听失败时退出，否则对端对本节点的探测全部失败，本节点在所有对端看来都不可达
+func StartResponder(cfg *config.Config) {
+	if _, err := ListenResponder(fmt.Sprintf(":%d", cfg.ResponderPort)); err != nil {
+		log.Fatalf("Failed to start probe responder: %v", err)
+	}
+	fmt.Printf("Probe responder is listening on port %d (tcp/udp)\n", cfg.ResponderPort)
+}
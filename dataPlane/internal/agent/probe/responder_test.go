@@ -0,0 +1,132 @@
+package probe
+
+import (
+	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/probe/protocol"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestResponder 测试回显服务：UDP 探测测得单向丢包和乱序，TCP 回显和 TCP 建连探测均可用
+func TestResponder(t *testing.T) {
+	r, err := ListenResponder("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenResponder failed: %v", err)
+	}
+	defer r.Close()
+	host, port := splitHostPort(t, r.Addr().String())
+
+	probeType := func(probeType protocol.ProbeType) *ProbeResult {
+		t.Helper()
+		result, err := performProbe(context.Background(), &protocol.ProbeTask{
+			Ip1:        "127.0.0.1",
+			Ip2:        host,
+			Type:       probeType,
+			Port:       int32(port),
+			TimeoutMs:  1000,
+			Samples:    4,
+			IntervalMs: 1,
+		}, config.Default().ResponderPort)
+		if err != nil {
+			t.Fatalf("performProbe %v failed: %v", probeType, err)
+		}
+		if result.Failed || result.Stats.Received != 4 {
+			t.Fatalf("%v: unexpected result %+v", probeType, result)
+		}
+		return result
+	}
+
+	udp := probeType(protocol.ProbeType_PROBE_TYPE_UDP)
+	if udp.Path == nil || *udp.Path != (PathStats{}) {
+		t.Errorf("expected one-way stats without loss, got %+v", udp.Path)
+	}
+	if echo := probeType(protocol.ProbeType_PROBE_TYPE_TCP_ECHO); echo.Path != nil {
+		t.Errorf("expected no one-way stats for tcp echo, got %+v", echo.Path)
+	}
+	probeType(protocol.ProbeType_PROBE_TYPE_TCP)
+}
+
+// TestResponderRejects 测试回显服务丢弃不是回显报文的 UDP 报文，会话数达到上限时清理最久没有收到报文的会话
+func TestResponderRejects(t *testing.T) {
+	r, err := ListenResponder("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenResponder failed: %v", err)
+	}
+	defer r.Close()
+	conn, err := net.Dial("udp", r.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("not an echo frame"))
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if n, err := conn.Read(make([]byte, 1500)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected no reply, got %d bytes, %v", n, err)
+	}
+
+	saved := echoMaxSessions
+	echoMaxSessions = 2
+	t.Cleanup(func() { echoMaxSessions = saved })
+	addr := &net.UDPAddr{IP: net.ParseIP("10.0.0.1")}
+	r.record(addr, &echoFrame{Session: 1})
+	r.record(addr, &echoFrame{Session: 2})
+	r.sessions["10.0.0.1/1"].lastSeen = time.Now().Add(-time.Second)
+	r.record(addr, &echoFrame{Session: 3})
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sessions["10.0.0.1/1"]; ok || len(r.sessions) != 2 {
+		t.Fatalf("expected the oldest session to be evicted, got %v", r.sessions)
+	}
+}
+
+// TestEchoPathStats 测试按回显中的计数区分去程、回程丢包和乱序
+func TestEchoPathStats(t *testing.T) {
+	var counter echoCounter
+	var session echoSession
+	var requests []*echoFrame
+	for i := 0; i < 5; i++ {
+		requests = append(requests, counter.next())
+		counter.sent++
+	}
+	// 回显服务依次收到 0、2、1、4，3 在去程丢失
+	replies := map[uint32][]byte{}
+	for _, seq := range []uint32{0, 2, 1, 4} {
+		f := *requests[seq]
+		session.record(&f, time.Now())
+		replies[seq] = f.marshal()
+	}
+	if session.received != 4 || session.reordered != 1 {
+		t.Fatalf("unexpected session %+v", session)
+	}
+
+	// 1 的回显丢失，0 的回显在等待 4 的回显时迟到
+	if counter.match(requests[0], replies[2]) {
+		t.Fatal("reply to a later request must not match")
+	}
+	if !counter.match(requests[2], replies[2]) || counter.match(requests[4], replies[0]) || !counter.match(requests[4], replies[4]) {
+		t.Fatal("unexpected match result")
+	}
+	path, ok := counter.pathStats()
+	if !ok {
+		t.Fatal("expected one-way stats")
+	}
+	// 去程 1/5 丢失，回显服务收到的 4 个报文中 1 个回显丢失，去程和回程各乱序 1 个
+	if want := (PathStats{ForwardLoss: 0.2, ReverseLoss: 0.25, Reordered: 2}); path != want {
+		t.Errorf("expected %+v, got %+v", want, path)
+	}
+
+	// 对端原样返回报文时没有计数
+	var verbatim echoCounter
+	request := verbatim.next()
+	verbatim.sent++
+	if !verbatim.match(request, request.marshal()) {
+		t.Fatal("expected verbatim echo to match")
+	}
+	if _, ok := verbatim.pathStats(); ok {
+		t.Error("expected no one-way stats for a verbatim echo")
+	}
+}
@@ -0,0 +1,157 @@
+package probe
+
+import (
+	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/probe/protocol"
+	"fmt"
+	"github.com/panjf2000/ants/v2"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RoundStats 探测轮次的统计，用于发现一轮探测无法在 ProbeInterval 内完成的情况
+type RoundStats struct {
+	Rounds       uint64        // 已执行的轮数
+	Overruns     uint64        // 超时的轮数：耗时超过探测间隔，或有任务被跳过、截断
+	Skipped      uint64        // 截止时间已到仍未开始而被跳过的任务数
+	Cut          uint64        // 截止时间已到时尚未发完全部样本的任务数
+	LastDuration time.Duration // 最近一轮的耗时
+	MaxDuration  time.Duration // 耗时最长的一轮
+}
+
+var (
+	roundMu    sync.Mutex
+	roundStats RoundStats
+)
+
+// Rounds 返回探测轮次统计的快照
+func Rounds() RoundStats {
+	roundMu.Lock()
+	defer roundMu.Unlock()
+	return roundStats
+}
+
+// recordRound 记录一轮探测，返回该轮是否超时
+func recordRound(duration, cycle time.Duration, skipped, cut uint64) bool {
+	overrun := (cycle > 0 && duration > cycle) || skipped > 0 || cut > 0
+	roundMu.Lock()
+	defer roundMu.Unlock()
+	roundStats.Rounds++
+	roundStats.Skipped += skipped
+	roundStats.Cut += cut
+	roundStats.LastDuration = duration
+	roundStats.MaxDuration = max(roundStats.MaxDuration, duration)
+	if overrun {
+		roundStats.Overruns++
+	}
+	return overrun
+}
+
+// runRound 执行一轮探测，返回全部探测结果，结果顺序与任务顺序一致
+// 任务按控制面指定的 phase 加上不超过 cfg.ProbeJitter 的随机抖动错开开始时间，由 pool 中的协程执行
+// 全部探测须在 cycle 内完成：截止时间到达后不再开始新的任务，进行中的任务不再发送新的样本，cycle 为 0 时不设截止时间也不错开
+func runRound(cfg *config.Config, pool *ants.Pool, tasks []*protocol.ProbeTask, cycle time.Duration) []*ProbeResult {
+	start := time.Now()
+	ctx, cancel := roundContext(start, cycle)
+	defer cancel()
+
+	// 抖动占用错开的范围，保证加上抖动后最慢的任务仍能在 cycle 内完成
+	window := spreadWindow(tasks, cycle)
+	jitter := min(cfg.ProbeJitter, window)
+	window -= jitter
+
+	finished := make([]*ProbeResult, len(tasks))
+	var skipped, cut atomic.Uint64
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		run := func() {
+			defer wg.Done()
+			// 在协程池中排队时截止时间已到
+			if ctxDone(ctx) {
+				skipped.Add(1)
+				return
+			}
+			result, err := performProbe(ctx, task, cfg.ResponderPort)
+			if count, _ := sampleParams(task); ctxDone(ctx) && (result == nil || result.Stats.Sent < count) {
+				cut.Add(1)
+			}
+			if err != nil {
+				fmt.Printf("Error performing probe for %s -> %s: %v\n", task.Ip1, task.Ip2, err)
+				return
+			}
+			// 失败的探测同样上报，控制面据此判断链路不通
+			if result.Failed {
+				fmt.Printf("Probe %s -> %s failed (%v): %s\n", task.Ip1, task.Ip2, result.ErrorClass, result.Error)
+			}
+			finished[i] = result
+		}
+		delay := startDelay(task, window)
+		if jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(jitter)))
+		}
+		// 等待到任务的开始位置后再提交，协程池已满时阻塞到有空闲协程
+		time.AfterFunc(delay, func() {
+			if err := pool.Submit(run); err != nil {
+				fmt.Printf("Failed to submit probe for %s -> %s: %v\n", task.Ip1, task.Ip2, err)
+				wg.Done()
+			}
+		})
+	}
+	wg.Wait()
+
+	duration := time.Since(start)
+	if recordRound(duration, cycle, skipped.Load(), cut.Load()) {
+		fmt.Printf("Probe round overran: %d tasks took %v (interval %v), %d skipped, %d cut short\n",
+			len(tasks), duration.Round(time.Millisecond), cycle, skipped.Load(), cut.Load())
+	}
+
+	var results []*ProbeResult
+	for _, result := range finished {
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// roundContext 在本轮结束时到期的 context，cycle 为 0 时不设截止时间
+func roundContext(start time.Time, cycle time.Duration) (context.Context, context.CancelFunc) {
+	if cycle <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), start.Add(cycle))
+}
+
+// ctxDone ctx 已取消或已到截止时间，连接的读写超时可能先于 ctx 的定时器触发
+func ctxDone(ctx context.Context) bool {
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return true
+	}
+	return ctx.Err() != nil
+}
+
+// spreadWindow 任务开始时间可以分布的范围，为 cycle 减去最慢的一个任务全部样本超时所需的时间
+func spreadWindow(tasks []*protocol.ProbeTask, cycle time.Duration) time.Duration {
+	var longest time.Duration
+	for _, task := range tasks {
+		_, timeout := probeParams(task, 0) // 只用到超时，与端口无关
+		count, interval := sampleParams(task)
+		longest = max(longest, time.Duration(count)*timeout+time.Duration(count-1)*interval)
+	}
+	if cycle <= longest {
+		return 0
+	}
+	return cycle - longest
+}
+
+// startDelay 任务相对本轮探测开始的延迟，phase 不在 [0, 1) 内时立即开始
+func startDelay(task *protocol.ProbeTask, window time.Duration) time.Duration {
+	if task.Phase <= 0 || task.Phase >= 1 {
+		return 0
+	}
+	return time.Duration(task.Phase * float64(window))
+}
@@ -0,0 +1,137 @@
+package probe
+
+import (
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/probe/protocol"
+	"fmt"
+	"github.com/panjf2000/ants/v2"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestPool(t *testing.T, size int) *ants.Pool {
+	pool, err := ants.NewPool(size)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	t.Cleanup(pool.Release)
+	return pool
+}
+
+// TestRunRound 测试并发探测：结果顺序与任务一致，类型无效的任务被跳过
+func TestRunRound(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	host, port := splitHostPort(t, lis.Addr().String())
+	var tasks []*protocol.ProbeTask
+	for i := 0; i < 6; i++ {
+		tasks = append(tasks, &protocol.ProbeTask{
+			Ip1:     fmt.Sprintf("10.0.0.%d", i),
+			Ip2:     host,
+			Type:    protocol.ProbeType_PROBE_TYPE_TCP,
+			Port:    int32(port),
+			Samples: 1,
+		})
+	}
+	tasks[3].Type = protocol.ProbeType(99)
+
+	before := Rounds()
+	results := runRound(config.Default(), newTestPool(t, 2), tasks, 0)
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	for i, want := range []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.4", "10.0.0.5"} {
+		if results[i].IP1 != want {
+			t.Fatalf("result %d: expected source %s, got %s", i, want, results[i].IP1)
+		}
+	}
+	if after := Rounds(); after.Rounds != before.Rounds+1 || after.Overruns != before.Overruns {
+		t.Fatalf("expected one round without overrun, got %+v (before %+v)", after, before)
+	}
+}
+
+// TestRunRoundDeadline 测试本轮截止时间到达后截断进行中的探测、跳过排队的探测，并记为超时的一轮
+func TestRunRoundDeadline(t *testing.T) {
+	// 只收不回的 UDP 端口，每个样本都要等到超时
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			if _, _, err := conn.ReadFrom(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	host, port := splitHostPort(t, conn.LocalAddr().String())
+	var tasks []*protocol.ProbeTask
+	for i := 0; i < 3; i++ {
+		tasks = append(tasks, &protocol.ProbeTask{
+			Ip1:        fmt.Sprintf("10.0.0.%d", i),
+			Ip2:        host,
+			Type:       protocol.ProbeType_PROBE_TYPE_UDP,
+			Port:       int32(port),
+			TimeoutMs:  200,
+			Samples:    3,
+			IntervalMs: 1,
+		})
+	}
+
+	// 只有一个协程：先开始的任务发出第二个样本时到达截止时间，其余任务仍在排队
+	before := Rounds()
+	start := time.Now()
+	results := runRound(config.Default(), newTestPool(t, 1), tasks, 300*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("round took %v, expected it to stop at the deadline", elapsed)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if stats := results[0].Stats; !results[0].Failed || stats.Sent != 1 || stats.Received != 0 {
+		t.Fatalf("expected only the first sample to count, got %+v", stats)
+	}
+	after := Rounds()
+	if after.Overruns != before.Overruns+1 || after.Skipped != before.Skipped+2 || after.Cut != before.Cut+1 {
+		t.Fatalf("unexpected round stats %+v (before %+v)", after, before)
+	}
+}
+
+// 测试按 phase 错开探测的开始时间
+func TestStartDelay(t *testing.T) {
+	tasks := []*protocol.ProbeTask{
+		{TimeoutMs: 1000, Samples: 3, IntervalMs: 500, Phase: 0.5},
+		{TimeoutMs: 200, Samples: 1, Phase: 0.25},
+		{TimeoutMs: 100, Samples: 1, Phase: 1.5},
+	}
+	// 最慢的任务需要 3*1s+2*500ms
+	window := spreadWindow(tasks, 10*time.Second)
+	if window != 6*time.Second {
+		t.Fatalf("expected a 6s window, got %v", window)
+	}
+	for i, want := range []time.Duration{3 * time.Second, 1500 * time.Millisecond, 0} {
+		if got := startDelay(tasks[i], window); got != want {
+			t.Errorf("task %d: expected delay %v, got %v", i, want, got)
+		}
+	}
+	if window := spreadWindow(tasks, 4*time.Second); window != 0 {
+		t.Errorf("expected no spread when the cycle is too short, got %v", window)
+	}
+}
@@ -0,0 +1,93 @@
+package probe
+
+import (
+	"dataPlane/internal/agent/probe/protocol"
+	"math"
+	"time"
+)
+
+// 每次探测默认发送的样本数和样本间隔
+var (
+	DefaultProbeSamples   = 5
+	DefaultSampleInterval = 100 * time.Millisecond
+)
+
+// ProbeStats 一次探测多个样本的统计结果，时延单位为微秒
+type ProbeStats struct {
+	MinUs    int64
+	AvgUs    int64
+	MaxUs    int64
+	StdDevUs int64
+	JitterUs int64   // 相邻成功样本时延差的绝对值的平均
+	Loss     float64 // 丢包率，0 到 1 之间
+	Sent     int
+	Received int
+}
+
+// PathStats 回显探测测得的单向丢包和乱序
+type PathStats struct {
+	ForwardLoss float64 // 去程丢包率，回显服务未收到的报文比例
+	ReverseLoss float64 // 回程丢包率，回显服务收到但回显丢失的报文比例
+	Reordered   int     // 去程和回程乱序的报文数
+}
+
+// pathProber 能测得单向丢包和乱序的探测器
+type pathProber interface {
+	pathStats() (PathStats, bool)
+}
+
+// computeStats 根据成功样本的时延计算统计结果，sent 为发送的样本总数
+func computeStats(samples []time.Duration, sent int) ProbeStats {
+	stats := ProbeStats{Sent: sent, Received: len(samples)}
+	if sent > 0 {
+		stats.Loss = float64(sent-len(samples)) / float64(sent)
+	}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	var sum, jitterSum float64
+	min, max := samples[0], samples[0]
+	for i, sample := range samples {
+		sum += float64(sample.Microseconds())
+		if sample < min {
+			min = sample
+		}
+		if sample > max {
+			max = sample
+		}
+		if i > 0 {
+			jitterSum += math.Abs(float64((sample - samples[i-1]).Microseconds()))
+		}
+	}
+	avg := sum / float64(len(samples))
+
+	var variance float64
+	for _, sample := range samples {
+		d := float64(sample.Microseconds()) - avg
+		variance += d * d
+	}
+	variance /= float64(len(samples))
+
+	stats.MinUs = min.Microseconds()
+	stats.AvgUs = int64(math.Round(avg))
+	stats.MaxUs = max.Microseconds()
+	stats.StdDevUs = int64(math.Round(math.Sqrt(variance)))
+	if len(samples) > 1 {
+		stats.JitterUs = int64(math.Round(jitterSum / float64(len(samples)-1)))
+	}
+	return stats
+}
+
+// sampleParams 探测任务的样本数和样本间隔，未指定时使用默认值
+func sampleParams(task *protocol.ProbeTask) (int, time.Duration) {
+	samples := int(task.Samples)
+	if samples <= 0 {
+		samples = DefaultProbeSamples
+	}
+	interval := time.Duration(task.IntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = DefaultSampleInterval
+	}
+	return samples, interval
+}
@@ -0,0 +1,112 @@
+package probe
+
+import (
+	"context"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/probe/protocol"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestComputeStats 测试多个样本的最小、平均、最大时延、标准差、抖动和丢包率
+func TestComputeStats(t *testing.T) {
+	samples := []time.Duration{
+		100 * time.Microsecond,
+		300 * time.Microsecond,
+		200 * time.Microsecond,
+		400 * time.Microsecond,
+	}
+	stats := computeStats(samples, 5)
+	want := ProbeStats{
+		MinUs:    100,
+		AvgUs:    250,
+		MaxUs:    400,
+		StdDevUs: 112, // sqrt(12500)
+		JitterUs: 167, // (200+100+200)/3
+		Loss:     0.2,
+		Sent:     5,
+		Received: 4,
+	}
+	if stats != want {
+		t.Fatalf("unexpected stats: got %+v, want %+v", stats, want)
+	}
+
+	// 全部丢包
+	stats = computeStats(nil, 3)
+	if stats.Loss != 1 || stats.Received != 0 || stats.AvgUs != 0 {
+		t.Fatalf("unexpected stats for lost samples: %+v", stats)
+	}
+}
+
+// TestPerformProbeSamples 测试一次探测发送多个样本并以微秒精度上报
+func TestPerformProbeSamples(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	host, port := splitHostPort(t, lis.Addr().String())
+	result, err := performProbe(context.Background(), &protocol.ProbeTask{
+		Ip1:        "127.0.0.1",
+		Ip2:        host,
+		Type:       protocol.ProbeType_PROBE_TYPE_TCP,
+		Port:       int32(port),
+		Samples:    4,
+		IntervalMs: 1,
+	}, config.Default().ResponderPort)
+	if err != nil {
+		t.Fatalf("performProbe failed: %v", err)
+	}
+	stats := result.Stats
+	if stats.Sent != 4 || stats.Received != 4 || stats.Loss != 0 {
+		t.Fatalf("unexpected sample counts: %+v", stats)
+	}
+	if stats.AvgUs <= 0 || stats.MinUs > stats.AvgUs || stats.AvgUs > stats.MaxUs {
+		t.Fatalf("inconsistent delays: %+v", stats)
+	}
+}
+
+// TestPerformProbeFailure 测试全部样本失败时返回带失败原因的结果而不是错误
+func TestPerformProbeFailure(t *testing.T) {
+	// 占用一个端口后关闭，确保该端口无人监听
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	host, port := splitHostPort(t, lis.Addr().String())
+	lis.Close()
+
+	result, err := performProbe(context.Background(), &protocol.ProbeTask{
+		Ip1:        "127.0.0.1",
+		Ip2:        host,
+		Type:       protocol.ProbeType_PROBE_TYPE_TCP,
+		Port:       int32(port),
+		Samples:    2,
+		IntervalMs: 1,
+	}, config.Default().ResponderPort)
+	if err != nil {
+		t.Fatalf("performProbe returned error instead of failed result: %v", err)
+	}
+	if !result.Failed || result.Stats.Loss != 1 || result.Error == "" {
+		t.Fatalf("expected failed result, got %+v", result)
+	}
+	if result.ErrorClass != protocol.ProbeErrorClass_PROBE_ERROR_REFUSED {
+		t.Fatalf("expected refused, got %v", result.ErrorClass)
+	}
+
+	// 非法的探测类型仍然返回错误
+	if _, err := performProbe(context.Background(), &protocol.ProbeTask{Type: protocol.ProbeType(100)}, config.Default().ResponderPort); err == nil {
+		t.Fatal("expected error for unsupported probe type")
+	}
+}
@@ -0,0 +1,176 @@
+package probe
+
+import (
+	"dataPlane/internal/agent/probe/protocol"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TaskTable 数据面的探测任务表，按目标和探测类型区分任务
+// 控制面可以替换全部任务，也可以增删部分任务，任务表版本号防止旧的更新覆盖新的任务
+type TaskTable struct {
+	mu         sync.Mutex
+	generation uint64
+	tasks      map[string]*taskEntry
+	seq        uint64 //收到任务的顺序，列出任务时按此排序
+	now        func() time.Time
+}
+
+type taskEntry struct {
+	task       *protocol.ProbeTask
+	seq        uint64
+	receivedAt time.Time
+	expiresAt  time.Time //零值表示不过期
+	lastRun    time.Time //零值表示尚未探测
+}
+
+// NewTaskTable 创建空的探测任务表
+func NewTaskTable() *TaskTable {
+	return &TaskTable{tasks: map[string]*taskEntry{}, now: time.Now}
+}
+
+// taskKey 任务的标识，目标节点和探测类型、端口都相同的任务视为同一个任务
+func taskKey(task *protocol.ProbeTask) string {
+	target := task.NodeId2
+	if target == "" {
+		target = task.Ip2
+	}
+	return fmt.Sprintf("%s/%v/%d", target, task.Type, task.Port)
+}
+
+// Apply 按请求的更新方式修改任务表，版本号不满足要求时不做修改并返回 "stale"
+func (t *TaskTable) Apply(request *protocol.ProbeTaskRequest) *protocol.ProbeTaskResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	status := "ok"
+	switch {
+	case request.Generation == 0:
+		// 旧版本控制面不带版本号，总是替换全部任务
+		t.replace(request.Tasks, now)
+	case request.Update == protocol.ProbeTaskUpdate_PROBE_TASK_REPLACE:
+		if request.Generation < t.generation {
+			status = "stale"
+			break
+		}
+		t.replace(request.Tasks, now)
+		t.generation = request.Generation
+	case request.Generation != t.generation:
+		// 增删必须基于当前的任务表，控制面收到 stale 后应重新下发全部任务
+		status = "stale"
+	case request.Update == protocol.ProbeTaskUpdate_PROBE_TASK_ADD:
+		for _, task := range request.Tasks {
+			t.add(task, now, t.tasks)
+		}
+	case request.Update == protocol.ProbeTaskUpdate_PROBE_TASK_REMOVE:
+		for _, task := range request.Tasks {
+			delete(t.tasks, taskKey(task))
+		}
+	default:
+		status = "stale"
+	}
+	return &protocol.ProbeTaskResponse{Status: status, Generation: t.generation, TaskCount: int32(len(t.tasks))}
+}
+
+func (t *TaskTable) replace(tasks []*protocol.ProbeTask, now time.Time) {
+	prev := t.tasks
+	t.tasks = make(map[string]*taskEntry, len(tasks))
+	for _, task := range tasks {
+		t.add(task, now, prev)
+	}
+}
+
+// add 新增任务，prev 中已有同一任务时保留其顺序和最近一次的探测时间，避免更新后立即重复探测
+func (t *TaskTable) add(task *protocol.ProbeTask, now time.Time, prev map[string]*taskEntry) {
+	key := taskKey(task)
+	entry := &taskEntry{task: task, receivedAt: now}
+	if old, ok := prev[key]; ok {
+		entry.seq, entry.lastRun = old.seq, old.lastRun
+	} else {
+		t.seq++
+		entry.seq = t.seq
+	}
+	if task.TtlMs > 0 {
+		entry.expiresAt = now.Add(time.Duration(task.TtlMs) * time.Millisecond)
+	}
+	t.tasks[key] = entry
+}
+
+// expire 删除已过期的任务
+func (t *TaskTable) expire(now time.Time) {
+	for key, entry := range t.tasks {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			fmt.Printf("Probe task %s -> %s expired\n", entry.task.Ip1, entry.task.Ip2)
+			delete(t.tasks, key)
+		}
+	}
+}
+
+// sorted 按收到的先后排列的全部任务
+func (t *TaskTable) sorted() []*taskEntry {
+	entries := make([]*taskEntry, 0, len(t.tasks))
+	for _, entry := range t.tasks {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	return entries
+}
+
+// Due 返回本轮应当执行的任务并记录探测时间，round 为探测循环的间隔
+// 任务的探测间隔按 round 取整，未指定或短于 round 的任务每轮都探测
+func (t *TaskTable) Due(round time.Duration) []*protocol.ProbeTask {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.expire(now)
+	var due []*protocol.ProbeTask
+	for _, entry := range t.sorted() {
+		interval := time.Duration(entry.task.ProbeIntervalMs) * time.Millisecond
+		// 容忍半轮的误差，避免间隔恰为整数轮的任务因定时抖动推迟一轮
+		if !entry.lastRun.IsZero() && now.Sub(entry.lastRun) < interval-round/2 {
+			continue
+		}
+		entry.lastRun = now
+		due = append(due, entry.task)
+	}
+	return due
+}
+
+// Tasks 返回全部未过期的任务
+func (t *TaskTable) Tasks() []*protocol.ProbeTask {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(t.now())
+	var tasks []*protocol.ProbeTask
+	for _, entry := range t.sorted() {
+		tasks = append(tasks, entry.task)
+	}
+	return tasks
+}
+
+// List 返回任务表版本和全部未过期任务的状态
+func (t *TaskTable) List() *protocol.ListProbeTasksResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(t.now())
+	resp := &protocol.ListProbeTasksResponse{Generation: t.generation}
+	for _, entry := range t.sorted() {
+		resp.Tasks = append(resp.Tasks, &protocol.ProbeTaskState{
+			Task:       entry.task,
+			ReceivedAt: entry.receivedAt.UnixMilli(),
+			ExpiresAt:  unixMilli(entry.expiresAt),
+			LastRunAt:  unixMilli(entry.lastRun),
+		})
+	}
+	return resp
+}
+
+// unixMilli 零值时间返回 0
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
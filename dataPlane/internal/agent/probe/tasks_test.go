@@ -0,0 +1,128 @@
+package probe
+
+import (
+	"dataPlane/internal/agent/probe/protocol"
+	"testing"
+	"time"
+)
+
+// 固定时钟的任务表
+func newTestTaskTable() (*TaskTable, *time.Time) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	table := NewTaskTable()
+	table.now = func() time.Time { return now }
+	return table, &now
+}
+
+func newTask(peer string) *protocol.ProbeTask {
+	return &protocol.ProbeTask{NodeId1: "node-1", NodeId2: peer, Ip2: "10.0.0." + peer[len(peer)-1:]}
+}
+
+func taskPeers(tasks []*protocol.ProbeTask) []string {
+	var ids []string
+	for _, task := range tasks {
+		ids = append(ids, task.NodeId2)
+	}
+	return ids
+}
+
+func expectPeers(t *testing.T, got []*protocol.ProbeTask, want ...string) {
+	t.Helper()
+	ids := taskPeers(got)
+	if len(ids) != len(want) {
+		t.Fatalf("expected tasks for %v, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("expected tasks for %v, got %v", want, ids)
+		}
+	}
+}
+
+// 测试一次下发多个任务时全部保留，旧版本控制面的请求替换全部任务
+func TestTaskTableReplace(t *testing.T) {
+	table, _ := newTestTaskTable()
+	resp := table.Apply(&protocol.ProbeTaskRequest{Tasks: []*protocol.ProbeTask{newTask("node-2"), newTask("node-3"), newTask("node-4")}})
+	if resp.Status != "ok" || resp.TaskCount != 3 {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+	expectPeers(t, table.Tasks(), "node-2", "node-3", "node-4")
+
+	// 版本号不得回退
+	if resp := table.Apply(&protocol.ProbeTaskRequest{Generation: 5, Tasks: []*protocol.ProbeTask{newTask("node-5")}}); resp.Status != "ok" || resp.Generation != 5 {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+	if resp := table.Apply(&protocol.ProbeTaskRequest{Generation: 4, Tasks: []*protocol.ProbeTask{newTask("node-6")}}); resp.Status != "stale" || resp.Generation != 5 || resp.TaskCount != 1 {
+		t.Fatalf("expected stale replace to be rejected, got %v", resp)
+	}
+	expectPeers(t, table.Tasks(), "node-5")
+}
+
+// 测试增删任务必须基于当前版本
+func TestTaskTableIncremental(t *testing.T) {
+	table, _ := newTestTaskTable()
+	table.Apply(&protocol.ProbeTaskRequest{Generation: 3, Tasks: []*protocol.ProbeTask{newTask("node-2"), newTask("node-3")}})
+
+	add := &protocol.ProbeTaskRequest{Update: protocol.ProbeTaskUpdate_PROBE_TASK_ADD, Generation: 3, Tasks: []*protocol.ProbeTask{newTask("node-4"), newTask("node-2")}}
+	if resp := table.Apply(add); resp.Status != "ok" || resp.TaskCount != 3 {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+	// 覆盖已有任务时保留原来的顺序
+	expectPeers(t, table.Tasks(), "node-2", "node-3", "node-4")
+
+	remove := &protocol.ProbeTaskRequest{Update: protocol.ProbeTaskUpdate_PROBE_TASK_REMOVE, Generation: 3, Tasks: []*protocol.ProbeTask{{NodeId2: "node-3"}}}
+	if resp := table.Apply(remove); resp.Status != "ok" || resp.TaskCount != 2 {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+	expectPeers(t, table.Tasks(), "node-2", "node-4")
+
+	add.Generation = 2
+	if resp := table.Apply(add); resp.Status != "stale" {
+		t.Fatalf("expected add based on an old generation to be rejected, got %v", resp)
+	}
+	remove.Generation = 4
+	remove.Tasks = []*protocol.ProbeTask{{NodeId2: "node-2"}}
+	if resp := table.Apply(remove); resp.Status != "stale" || resp.TaskCount != 2 {
+		t.Fatalf("expected remove based on a newer generation to be rejected, got %v", resp)
+	}
+}
+
+// 测试任务的探测间隔和有效期
+func TestTaskTableDue(t *testing.T) {
+	table, now := newTestTaskTable()
+	slow := newTask("node-3")
+	slow.ProbeIntervalMs = 30000
+	expiring := newTask("node-4")
+	expiring.TtlMs = 25000
+	table.Apply(&protocol.ProbeTaskRequest{Generation: 1, Tasks: []*protocol.ProbeTask{newTask("node-2"), slow, expiring}})
+
+	round := 10 * time.Second
+	var rounds [][]string
+	for i := 0; i < 4; i++ {
+		rounds = append(rounds, taskPeers(table.Due(round)))
+		*now = now.Add(round)
+	}
+	want := [][]string{{"node-2", "node-3", "node-4"}, {"node-2", "node-4"}, {"node-2", "node-4"}, {"node-2", "node-3"}}
+	for i := range want {
+		if len(rounds[i]) != len(want[i]) {
+			t.Fatalf("round %d: expected %v, got %v", i, want[i], rounds[i])
+		}
+		for j := range want[i] {
+			if rounds[i][j] != want[i][j] {
+				t.Fatalf("round %d: expected %v, got %v", i, want[i], rounds[i])
+			}
+		}
+	}
+
+	list := table.List()
+	if list.Generation != 1 || len(list.Tasks) != 2 {
+		t.Fatalf("expected 2 tasks in generation 1, got %v", list)
+	}
+	if state := list.Tasks[1]; state.Task.NodeId2 != "node-3" || state.LastRunAt != now.Add(-round).UnixMilli() || state.ExpiresAt != 0 {
+		t.Errorf("unexpected task state: %v", state)
+	}
+
+	// 替换全部任务后保留最近一次的探测时间
+	table.Apply(&protocol.ProbeTaskRequest{Generation: 2, Tasks: []*protocol.ProbeTask{slow}})
+	expectPeers(t, table.Due(round))
+}
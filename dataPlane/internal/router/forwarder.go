@@ -0,0 +1,336 @@
+package router
+
+import (
+	"bufio"
+	"crypto/tls"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/pki"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 包级全局变量，可在外部修改
+var RelayDialTimeout = 5 * time.Second // 连接下一跳或目标地址的超时时间
+
+// 中继头部魔数
+var relayMagic = [4]byte{'S', 'R', 'S', '1'}
+
+// relayHeader 每条中继连接开头携带的头部
+// Hops 为剩余待经过的节点（含目的节点）；为空时表示入口节点需要查路由表
+type relayHeader struct {
+	Destination string   // 目的 Sirius 节点 IP
+	Hops        []string // 剩余路径
+	Target      string   // 出口节点最终连接的地址 host:port
+}
+
+// writeHeader 编码头部：魔数 | 目的节点 | 跳数 | 各跳 | 目标地址，字符串均为 2 字节长度前缀
+func writeHeader(w io.Writer, h relayHeader) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, relayMagic[:]...)
+	buf = appendString(buf, h.Destination)
+	buf = append(buf, byte(len(h.Hops)))
+	for _, hop := range h.Hops {
+		buf = appendString(buf, hop)
+	}
+	buf = appendString(buf, h.Target)
+	_, err := w.Write(buf)
+	return err
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+// readHeader 解码头部
+func readHeader(r io.Reader) (relayHeader, error) {
+	var h relayHeader
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return h, err
+	}
+	if magic != relayMagic {
+		return h, errors.New("invalid relay header")
+	}
+	var err error
+	if h.Destination, err = readString(r); err != nil {
+		return h, err
+	}
+	var count [1]byte
+	if _, err := io.ReadFull(r, count[:]); err != nil {
+		return h, err
+	}
+	for i := 0; i < int(count[0]); i++ {
+		hop, err := readString(r)
+		if err != nil {
+			return h, err
+		}
+		h.Hops = append(h.Hops, hop)
+	}
+	if h.Target, err = readString(r); err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+func readString(r io.Reader) (string, error) {
+	var size [2]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return "", err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// Forwarder 用户态 TCP 中继转发器
+// 入口节点根据路由表选出最优路径并写入头部，中间节点按头部逐跳转发，出口节点连接目标地址
+// 启用 TLS 时节点之间的中继连接须双向认证，只有本机应用可以明文连接入口节点
+type Forwarder struct {
+	self      string          // 本节点 IP
+	addrs     map[string]bool // 本节点的通告地址，出口节点只连接这些地址和 targets
+	targets   []string        // 出口节点除本节点通告地址外允许连接的目标，IP 或 CIDR
+	port      string          // 中继端口，所有节点使用同一端口，用于连接下一跳
+	table     *RouteTable     // 控制面下发的路由表
+	tlsConfig *tls.Config     // 节点之间中继连接的 TLS 配置，为 nil 时不加密
+	listener  net.Listener
+	wg        sync.WaitGroup
+}
+
+// NewForwarder 创建转发器，addrs 为本节点的通告地址，中继端口和允许的目标取自 cfg
+func NewForwarder(cfg *config.Config, self string, addrs []string, table *RouteTable, tlsConfig *tls.Config) *Forwarder {
+	f := &Forwarder{
+		self:      self,
+		addrs:     map[string]bool{},
+		targets:   cfg.RelayTargets,
+		port:      strconv.Itoa(cfg.RelayPort),
+		table:     table,
+		tlsConfig: tlsConfig,
+	}
+	for _, addr := range append([]string{self}, addrs...) {
+		if ip := net.ParseIP(addr); ip != nil {
+			f.addrs[ip.String()] = true
+		}
+	}
+	return f
+}
+
+// Start 在 listenAddr 上监听中继连接
+func (f *Forwarder) Start(listenAddr string) error {
+	lis, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return err
+	}
+	f.listener = lis
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				log.Printf("Relay accept error: %v", err)
+				continue
+			}
+			go f.handle(conn)
+		}
+	}()
+	return nil
+}
+
+// Addr 监听地址
+func (f *Forwarder) Addr() net.Addr {
+	return f.listener.Addr()
+}
+
+// Close 停止接收新连接，已建立的中继连接会继续直到任一端关闭
+func (f *Forwarder) Close() error {
+	err := f.listener.Close()
+	f.wg.Wait()
+	return err
+}
+
+// handle 处理一条中继连接
+func (f *Forwarder) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(RelayDialTimeout))
+	reader := bufio.NewReader(conn)
+	if f.tlsConfig != nil {
+		// 本机应用以明文头部开头，其余连接必须完成双向 TLS 握手
+		first, err := reader.Peek(1)
+		if err != nil {
+			log.Printf("Failed to read relay connection from %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+		if !isLoopback(conn.RemoteAddr()) || first[0] != relayMagic[0] {
+			tlsConn := tls.Server(&bufferedConn{Conn: conn, r: reader}, f.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				log.Printf("Rejected relay connection from %s: %v", conn.RemoteAddr(), err)
+				return
+			}
+			conn, reader = tlsConn, bufio.NewReader(tlsConn)
+		}
+	}
+	header, err := readHeader(reader)
+	if err != nil {
+		log.Printf("Failed to read relay header from %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	upstream, err := f.next(header)
+	if err != nil {
+		log.Printf("Failed to relay %s -> %s (%s): %v", conn.RemoteAddr(), header.Destination, header.Target, err)
+		return
+	}
+	defer upstream.Close()
+
+	// 头部之后可能已有数据被读入缓冲区，需先转发
+	splice(conn, reader, upstream)
+}
+
+// next 根据头部决定下一跳，返回已写好头部的下一跳连接或出口目标连接
+func (f *Forwarder) next(header relayHeader) (net.Conn, error) {
+	hops := header.Hops
+	if len(hops) == 0 && header.Destination != f.self {
+		// 入口节点：查路由表，没有路由时直连目的节点
+		hops = []string{header.Destination}
+		if route, ok := f.table.Lookup(header.Destination); ok && len(route.Paths) > 0 && len(route.Paths[0]) > 1 {
+			hops = route.Paths[0][1:]
+		}
+	}
+	if len(hops) > 0 && hops[0] == f.self {
+		hops = hops[1:]
+	}
+
+	// 出口节点：校验后直接连接目标地址
+	if len(hops) == 0 {
+		if err := f.allowTarget(header.Target); err != nil {
+			return nil, err
+		}
+		return net.DialTimeout("tcp", header.Target, RelayDialTimeout)
+	}
+
+	dialer := &net.Dialer{Timeout: RelayDialTimeout}
+	var upstream net.Conn
+	var err error
+	if f.tlsConfig != nil {
+		upstream, err = tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(hops[0], f.port), f.tlsConfig)
+	} else {
+		upstream, err = dialer.Dial("tcp", net.JoinHostPort(hops[0], f.port))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to next hop %s: %v", hops[0], err)
+	}
+	header.Hops = hops
+	if err := writeHeader(upstream, header); err != nil {
+		upstream.Close()
+		return nil, err
+	}
+	return upstream, nil
+}
+
+// allowTarget 出口节点只连接本节点的通告地址或 targets 中的地址，避免成为任意地址的开放代理
+func (f *Forwarder) allowTarget(target string) error {
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		return fmt.Errorf("invalid target %q: %v", target, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("target %s is not an IP address", host)
+	}
+	if f.addrs[ip.String()] {
+		return nil
+	}
+	for _, allowed := range f.targets {
+		if _, network, err := net.ParseCIDR(allowed); err == nil && network.Contains(ip) {
+			return nil
+		}
+		if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("target %s is not an address of this node or in the allowed targets", target)
+}
+
+// isLoopback 连接是否来自本机回环地址
+func isLoopback(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	return ok && tcpAddr.IP.IsLoopback()
+}
+
+// bufferedConn 先读出 r 中已缓冲的数据的连接
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// splice 双向拷贝数据，一个方向结束后半关闭对端的写方向
+func splice(client net.Conn, clientReader io.Reader, upstream net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(upstream, clientReader)
+		closeWrite(upstream)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(client, upstream)
+		closeWrite(client)
+	}()
+	wg.Wait()
+}
+
+func closeWrite(conn net.Conn) {
+	// *net.TCPConn 半关闭连接，*tls.Conn 发送 close_notify
+	if c, ok := conn.(interface{ CloseWrite() error }); ok {
+		c.CloseWrite()
+		return
+	}
+	conn.Close()
+}
+
+// Dial 通过入口节点的中继端口建立到目的节点上 target 地址的加速连接
+// 启用 TLS 时入口节点只接受来自本机的明文连接，relayAddr 应为本机地址
+func Dial(relayAddr, destination, target string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", relayAddr, RelayDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeHeader(conn, relayHeader{Destination: destination, Target: target}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// StartForwarder 在 cfg.RelayPort 上启动全局路由表对应的转发器，启用 TLS 时使用本节点证书
+func StartForwarder(cfg *config.Config, self string, addrs []string) (*Forwarder, error) {
+	tlsConfig, err := pki.RelayTLSConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load relay TLS config: %v", err)
+	}
+	forwarder := NewForwarder(cfg, self, addrs, DefaultRouteTable(), tlsConfig)
+	if err := forwarder.Start(":" + forwarder.port); err != nil {
+		return nil, err
+	}
+	log.Printf("Relay forwarder is listening on port %s", forwarder.port)
+	return forwarder, nil
+}
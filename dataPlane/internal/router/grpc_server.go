@@ -0,0 +1,46 @@
+package router
+
+import (
+	"context"
+	"dataPlane/internal/agent/probe/protocol"
+	"fmt"
+)
+
+// RouteServiceServer 实现 RouteService 服务接口
+type RouteServiceServer struct {
+	protocol.UnimplementedRouteServiceServer
+	table *RouteTable
+}
+
+// NewRouteServiceServer 创建 RouteService 服务，路由写入 table
+func NewRouteServiceServer(table *RouteTable) *RouteServiceServer {
+	return &RouteServiceServer{table: table}
+}
+
+// PushRoutes 实现 PushRoutes 方法，接收控制面下发的路由表
+func (s *RouteServiceServer) PushRoutes(ctx context.Context, request *protocol.RouteTableRequest) (*protocol.RouteTableResponse, error) {
+	return s.Apply(request), nil
+}
+
+// Apply 应用控制面下发的路由表，供 gRPC 服务和长连接共用
+func (s *RouteServiceServer) Apply(request *protocol.RouteTableRequest) *protocol.RouteTableResponse {
+	routes := make([]Route, 0, len(request.Routes))
+	for _, entry := range request.Routes {
+		route := Route{
+			Destination: entry.Destination,
+			NextHop:     entry.NextHop,
+		}
+		for _, path := range entry.Paths {
+			route.Paths = append(route.Paths, path.Nodes)
+		}
+		routes = append(routes, route)
+	}
+
+	applied, ok := s.table.Apply(request.Version, routes)
+	if !ok {
+		fmt.Printf("Rejected stale route table: version %d, current version %d\n", request.Version, applied)
+		return &protocol.RouteTableResponse{Status: "stale", AppliedVersion: applied}
+	}
+	fmt.Printf("Applied route table version %d with %d routes\n", applied, len(routes))
+	return &protocol.RouteTableResponse{Status: "ok", AppliedVersion: applied}
+}
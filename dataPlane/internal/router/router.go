@@ -1 +1,66 @@
 package router
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Route 单个目的节点的路由
+type Route struct {
+	Destination string     // 目的节点 IP
+	NextHop     string     // 最优路径的下一跳节点 IP
+	Paths       [][]string // 候选路径，第一条为最优路径，包含源节点和目的节点
+}
+
+// RouteTable 控制面下发的路由表，带版本号
+type RouteTable struct {
+	mu      sync.RWMutex
+	version uint64
+	routes  map[string]Route
+}
+
+// 数据面全局路由表
+var defaultTable = NewRouteTable()
+
+// NewRouteTable 创建空路由表
+func NewRouteTable() *RouteTable {
+	return &RouteTable{routes: make(map[string]Route)}
+}
+
+// DefaultRouteTable 获取数据面全局路由表
+func DefaultRouteTable() *RouteTable {
+	return defaultTable
+}
+
+// Apply 用新版本整体替换路由表
+// 版本号小于当前版本的路由表视为过期，不生效；与当前版本相同时只有路由完全一致才视为重复下发并确认，否则拒绝
+// 返回当前生效的版本以及是否已应用
+func (t *RouteTable) Apply(version uint64, routes []Route) (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	table := make(map[string]Route, len(routes))
+	for _, route := range routes {
+		table[route.Destination] = route
+	}
+	if version < t.version || (version == t.version && !reflect.DeepEqual(table, t.routes)) {
+		return t.version, false
+	}
+	t.routes = table
+	t.version = version
+	return t.version, true
+}
+
+// Lookup 查询到目的节点的路由
+func (t *RouteTable) Lookup(destination string) (Route, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	route, ok := t.routes[destination]
+	return route, ok
+}
+
+// Version 当前生效的路由表版本
+func (t *RouteTable) Version() uint64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.version
+}
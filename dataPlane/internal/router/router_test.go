@@ -0,0 +1,273 @@
+package router
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"dataPlane/internal/agent/config"
+	"dataPlane/internal/agent/pki"
+	"dataPlane/internal/agent/probe/protocol"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestPushRoutes 测试路由表下发与过期版本拒绝
+func TestPushRoutes(t *testing.T) {
+	table := NewRouteTable()
+	server := NewRouteServiceServer(table)
+
+	request := &protocol.RouteTableRequest{
+		Node:    "10.0.0.1",
+		Version: 2,
+		Routes: []*protocol.RouteEntry{
+			{
+				Destination: "10.0.0.3",
+				NextHop:     "10.0.0.2",
+				Paths: []*protocol.RoutePath{
+					{Nodes: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, Delay: 3},
+					{Nodes: []string{"10.0.0.1", "10.0.0.3"}, Delay: 5},
+				},
+			},
+		},
+	}
+	resp, err := server.PushRoutes(context.Background(), request)
+	if err != nil {
+		t.Fatalf("PushRoutes failed: %v", err)
+	}
+	if resp.Status != "ok" || resp.AppliedVersion != 2 {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+	route, ok := table.Lookup("10.0.0.3")
+	if !ok || route.NextHop != "10.0.0.2" || len(route.Paths) != 2 {
+		t.Fatalf("unexpected route: %+v", route)
+	}
+
+	// 旧版本的路由表应被拒绝，且不影响当前路由
+	resp, err = server.PushRoutes(context.Background(), &protocol.RouteTableRequest{Node: "10.0.0.1", Version: 1})
+	if err != nil {
+		t.Fatalf("PushRoutes failed: %v", err)
+	}
+	if resp.Status != "stale" || resp.AppliedVersion != 2 {
+		t.Fatalf("expected stale response, got %v", resp)
+	}
+	if _, ok := table.Lookup("10.0.0.3"); !ok {
+		t.Fatal("stale table must not replace current routes")
+	}
+
+	// 相同版本重复下发相同的路由时确认，路由不同时拒绝
+	if resp, _ := server.PushRoutes(context.Background(), request); resp.Status != "ok" || resp.AppliedVersion != 2 {
+		t.Fatalf("expected re-push of the same table to be acknowledged, got %v", resp)
+	}
+	if resp, _ := server.PushRoutes(context.Background(), &protocol.RouteTableRequest{Node: "10.0.0.1", Version: 2}); resp.Status != "stale" {
+		t.Fatalf("expected different table with the current version to be rejected, got %v", resp)
+	}
+	if _, ok := table.Lookup("10.0.0.3"); !ok {
+		t.Fatal("rejected table must not replace current routes")
+	}
+}
+
+// startEcho 启动回显服务
+func startEcho(t *testing.T, addr string) net.Listener {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return lis
+}
+
+// roundTrip 通过中继发送一条消息并读取回显
+func roundTrip(relayAddr, destination, target, msg string) (string, error) {
+	conn, err := Dial(relayAddr, destination, target)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		return "", err
+	}
+	conn.(*net.TCPConn).CloseWrite()
+	reply, err := io.ReadAll(conn)
+	return string(reply), err
+}
+
+// startForwarders 选择一个空闲端口作为所有节点的中继端口，在回环地址 127.0.0.1~3 上各启动一个节点
+// 入口节点 127.0.0.1 经 127.0.0.2 转发到 127.0.0.3，出口节点另外允许连接 targets，返回各节点的转发器和入口地址
+func startForwarders(t *testing.T, tlsConfig *tls.Config, targets ...string) ([]*Forwarder, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	cfg := config.Default()
+	cfg.RelayPort = lis.Addr().(*net.TCPAddr).Port
+	lis.Close()
+	cfg.RelayTargets = targets
+
+	nodes := []string{"127.0.0.1", "127.0.0.2", "127.0.0.3"}
+	forwarders := make([]*Forwarder, len(nodes))
+	for i, node := range nodes {
+		forwarders[i] = NewForwarder(cfg, node, []string{node}, NewRouteTable(), tlsConfig)
+		if err := forwarders[i].Start(net.JoinHostPort(node, forwarders[i].port)); err != nil {
+			t.Fatalf("Failed to start forwarder on %s: %v", node, err)
+		}
+		t.Cleanup(func() { forwarders[i].Close() })
+	}
+	forwarders[0].table.Apply(1, []Route{{
+		Destination: "127.0.0.3",
+		NextHop:     "127.0.0.2",
+		Paths:       [][]string{{"127.0.0.1", "127.0.0.2", "127.0.0.3"}},
+	}})
+	return forwarders, net.JoinHostPort(nodes[0], forwarders[0].port)
+}
+
+// TestForwarderMultiHop 测试 127.0.0.1 -> 127.0.0.2 -> 127.0.0.3 的多跳转发
+func TestForwarderMultiHop(t *testing.T) {
+	forwarders, relayAddr := startForwarders(t, nil)
+	echo := startEcho(t, "127.0.0.3:0")
+	defer echo.Close()
+
+	reply, err := roundTrip(relayAddr, "127.0.0.3", echo.Addr().String(), "hello sirius")
+	if err != nil || reply != "hello sirius" {
+		t.Fatalf("relay through 127.0.0.2 failed: reply=%q err=%v", reply, err)
+	}
+
+	// 关闭中间节点后，按路由表转发的连接应当失败
+	forwarders[1].Close()
+	if reply, _ := roundTrip(relayAddr, "127.0.0.3", echo.Addr().String(), "hello sirius"); reply != "" {
+		t.Fatalf("expected relay to fail without 127.0.0.2, got %q", reply)
+	}
+
+	// 没有路由的目的节点直连
+	forwarders[0].table.Apply(2, nil)
+	reply, err = roundTrip(relayAddr, "127.0.0.3", echo.Addr().String(), "direct")
+	if err != nil || reply != "direct" {
+		t.Fatalf("direct relay failed: reply=%q err=%v", reply, err)
+	}
+}
+
+// TestForwarderTarget 测试出口节点只连接本节点的通告地址和允许的目标
+func TestForwarderTarget(t *testing.T) {
+	_, relayAddr := startForwarders(t, nil, "127.0.0.4/32")
+	foreign := startEcho(t, "127.0.0.5:0")
+	defer foreign.Close()
+	echo := startEcho(t, "127.0.0.4:0")
+	defer echo.Close()
+
+	if reply, _ := roundTrip(relayAddr, "127.0.0.3", foreign.Addr().String(), "hello sirius"); reply != "" {
+		t.Fatalf("expected relay to a foreign target to be rejected, got %q", reply)
+	}
+	reply, err := roundTrip(relayAddr, "127.0.0.3", echo.Addr().String(), "hello sirius")
+	if err != nil || reply != "hello sirius" {
+		t.Fatalf("relay to an allowed target failed: reply=%q err=%v", reply, err)
+	}
+}
+
+// TestForwarderTLS 测试启用 TLS 后节点之间的中继连接须出示同一 CA 签发的证书
+func TestForwarderTLS(t *testing.T) {
+	ca, caKey := newRelayCA(t)
+	tlsConfig := relayTLSConfig(t, ca, caKey)
+	forwarders, relayAddr := startForwarders(t, tlsConfig)
+	echo := startEcho(t, "127.0.0.3:0")
+	defer echo.Close()
+
+	// 本机应用明文连接入口节点，节点之间使用 TLS
+	reply, err := roundTrip(relayAddr, "127.0.0.3", echo.Addr().String(), "hello sirius")
+	if err != nil || reply != "hello sirius" {
+		t.Fatalf("relay over TLS failed: reply=%q err=%v", reply, err)
+	}
+
+	// 其他 CA 签发的证书不能使用中间节点
+	otherCA, otherKey := newRelayCA(t)
+	rogue := relayTLSConfig(t, otherCA, otherKey)
+	rogue.VerifyConnection = nil
+	conn, err := tls.Dial("tcp", forwarders[1].Addr().String(), rogue)
+	if err == nil {
+		defer conn.Close()
+		writeHeader(conn, relayHeader{Destination: "127.0.0.3", Hops: []string{"127.0.0.3"}, Target: echo.Addr().String()})
+		conn.Write([]byte("hello sirius"))
+		conn.CloseWrite()
+		if reply, _ := io.ReadAll(conn); len(reply) > 0 {
+			t.Fatalf("expected certificate from another CA to be rejected, got %q", reply)
+		}
+	}
+}
+
+// newRelayCA 生成测试用的 CA
+func newRelayCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sirius-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+// relayTLSConfig 用 ca 签发节点证书并写入临时目录，返回 pki.RelayTLSConfig 生成的中继 TLS 配置
+func relayTLSConfig(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "node-1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.CAFile = filepath.Join(dir, "ca.crt")
+	cfg.CertFile = filepath.Join(dir, "node.crt")
+	cfg.KeyFile = filepath.Join(dir, "node.key")
+	os.WriteFile(cfg.CAFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0644)
+	os.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+
+	tlsConfig, err := pki.RelayTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("RelayTLSConfig failed: %v", err)
+	}
+	return tlsConfig
+}